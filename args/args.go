@@ -2,6 +2,7 @@ package args
 
 import (
 	"errors"
+	"fmt"
 	"math/rand"
 	"net/url"
 	"os"
@@ -32,6 +33,22 @@ type PipelineArgs struct {
 	// If Step is nil, then all steps are ran
 	Step *int64
 
+	// StepName is a glob pattern that selects the steps to run by their name or by the slug of their name, like 'test*'.
+	// Unlike 'Step', it does not change when steps are added to the pipeline.
+	StepName string
+
+	// StepWithDeps is the ID of a step to run together with every step that it depends on, directly or indirectly.
+	// Unlike 'Step', the arguments that the step requires do not have to be provided with '-arg'.
+	StepWithDeps *int64
+
+	// Until is the name of a step to run together with every step that it depends on. It is the same as 'StepWithDeps', but the step is selected by its name.
+	// Like 'StepName', it can be a glob pattern.
+	Until string
+
+	// From is the name or glob pattern of a step to run together with every step that depends on it.
+	// If it is provided with 'Until', then only the steps between the two steps are ran.
+	From string
+
 	// BuildID is a unique identifier typically assigned by a CI system.
 	// In Dagger / CLI clients, this will likely be populated by a random UUID if not provided.
 	BuildID string
@@ -56,16 +73,64 @@ type PipelineArgs struct {
 	//    * This might be a good option if implementing a Scribe client in a provider.
 	// * 's3://bucket-name/path'
 	// * 'gcs://bucket-name/path'
-	// If 'State' is not provided, then one is created using os.Tmpdir.
+	// If 'State' is not provided, then a directory for the build is created in os.TempDir (see 'DefaultState').
 	State string
 
+	// StateFallback are the URLs of other states that values are read from if they are not in 'State'. Values are never written to them.
+	// Clients that run every pipeline in its own CI job use them to read the states of the jobs that a job depends on.
+	StateFallback []string
+
 	// PipelineName can be provided in a multi-pipeline setup to run an entire pipeline rather than the entire suite of pipelines.
 	PipelineName []string
 
 	// Event can be provided in a multi-pipeline setup locally to simulate an event.
 	Event string
+
+	// MaxConcurrency is the maximum number of steps in a pipeline that run at the same time when running a pipeline locally.
+	// If it is 0, then there is no limit.
+	MaxConcurrency int
+
+	// KeepGoing is true if the '--keep-going' flag was provided. When a step fails, the steps that do not depend on it keep running
+	// and every failed step is reported at the end. By default ('--fail-fast'), the first failure cancels every other running step.
+	KeepGoing bool
+
+	// Cache is a URL where the outputs of cached steps are stored between runs. It supports the same schemes as 'State'.
+	// If 'Cache' is not provided, then the user's cache directory is used.
+	Cache string
+
+	// NoCache is true if the '--no-cache' flag was provided. Cached steps always run and their outputs are not stored.
+	NoCache bool
+
+	// List is the format that the pipelines and steps are listed in if the '--list' flag was provided. If it is set, then the pipeline is not ran.
+	// It is either ListFormatTable or ListFormatJSON.
+	List string
+
+	// Validate is true if the '--validate' flag was provided. The problems in the pipeline are reported instead of running the pipeline.
+	Validate bool
+
+	// DroneSteps is true if the '--drone-steps' flag was provided. The Drone client creates one Drone step for every step in the pipeline
+	// instead of one Drone step for every pipeline, so that every step has its own logs and timings in Drone.
+	DroneSteps bool
+
+	// DroneLanguage is the language of the config that the Drone client generates. It is either DroneLanguageYAML or DroneLanguageStarlark.
+	DroneLanguage string
+
+	// Resume is the build ID of a previous local run that should be resumed. The previous run's state is reused, and the steps that succeeded in it are skipped.
+	// If it is set, then it is also used as the BuildID. The run history is found using 'State', so it must be the same as in the previous run.
+	// Only the dagger client records the run history, so only its runs can be resumed.
+	Resume string
 }
 
+const (
+	ListFormatTable = "table"
+	ListFormatJSON  = "json"
+)
+
+const (
+	DroneLanguageYAML     = "yaml"
+	DroneLanguageStarlark = "starlark"
+)
+
 type pipelineNames struct {
 	names []string
 }
@@ -84,16 +149,45 @@ func (p *pipelineNames) Type() string {
 	return "[]string"
 }
 
+// DataDir returns the directory where the state and the run history of local runs are stored by default.
+func DataDir() string {
+	return filepath.Join(os.TempDir(), "scribe")
+}
+
+// DefaultState returns the state URL that is used for the build if the '--state' flag is not provided.
+func DefaultState(buildID string) string {
+	u := &url.URL{
+		Scheme: "file",
+		Path:   filepath.Join(DataDir(), "state", buildID),
+	}
+
+	return u.String()
+}
+
+// defaultCacheDir returns the directory in the user's cache directory where cached step outputs are stored by default.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "scribe")
+}
+
 func ParseArguments(args []string) (*PipelineArgs, error) {
-	var defaultState = &url.URL{
+	var defaultCache = &url.URL{
 		Scheme: "file",
-		Path:   filepath.Join(os.TempDir(), stringutil.Random(8)),
+		Path:   defaultCacheDir(),
 	}
 
 	var (
 		flagSet       = flag.NewFlagSet("run", flag.ContinueOnError)
 		client        string
 		step          OptionalInt
+		stepName      string
+		stepWithDeps  OptionalInt
+		until         string
+		from          string
 		logLevel      string
 		pathOverride  string
 		version       string
@@ -101,23 +195,50 @@ func ParseArguments(args []string) (*PipelineArgs, error) {
 		noStdinPrompt bool
 		argMap        = ArgMap(map[string]string{})
 		state         string
+		stateFallback []string
 		event         string
 		pipelineName  pipelineNames
+		concurrency   int
+		failFast      bool
+		keepGoing     bool
+		cache         string
+		noCache       bool
+		resume        string
+		list          string
+		validate      bool
+		droneSteps    bool
+		droneLanguage string
 	)
 
 	// Flags with shorthand options
-	flagSet.StringVarP(&client, "client", "c", "dagger", "dagger|drone. Default: dagger")
+	flagSet.StringVarP(&client, "client", "c", "dagger", "dagger|drone|github|gitlab|graphviz|mermaid|plan. Default: dagger")
 	flagSet.StringVarP(&logLevel, "log-level", "l", "info", "The level of detail in the pipeline's log output. Default: 'warn'. Options: [trace, debug, info, warn, error]")
 	flagSet.StringVarP(&buildID, "build-id", "b", stringutil.Random(12), "A unique identifier typically assigned by a build system. Defaults to a random string if no build ID is provided")
-	flagSet.StringVarP(&state, "state", "s", defaultState.String(), "A URI that refers to a state file or directory where state between steps is stored. Must include a protocol, like 'file://', 'gcs://', or 's3://'")
+	flagSet.StringVarP(&state, "state", "s", "", "A URI that refers to a state file or directory where state between steps is stored. Must include a protocol, like 'file://', 'gcs://', or 's3://'")
+	flagSet.StringArrayVar(&stateFallback, "state-fallback", nil, "A URI that refers to a state that values are read from if they are not in the state provided with '--state'. This argument can be provided multiple times")
 	flagSet.StringVarP(&event, "event", "e", "git-commit", "The name of an event to run. The default behavior is to run all pipelines that do not have a source event")
 	flagSet.VarP(&pipelineName, "pipeline", "p", "A pipeline name, giving a value for this flag will result in only the pipeline of the specified name being executed. The default empty string will run all pipelines.")
 
 	flagSet.Var(&step, "step", "A number that defines what specific step to run")
+	flagSet.StringVar(&stepName, "step-name", "", "The name, slug, or glob pattern of the steps to run, like 'test*'")
+	flagSet.Var(&stepWithDeps, "step-with-deps", "A number that defines a specific step to run together with every step that it depends on")
+	flagSet.StringVar(&until, "until", "", "The name of a step to run together with every step that it depends on")
+	flagSet.StringVar(&from, "from", "", "The name of a step to run together with every step that depends on it")
 	flagSet.Var(&argMap, "arg", "Provide pre-available arguments for use in pipeline steps. This argument can be provided multiple times. Format: '-arg={key}={value}")
 	flagSet.BoolVar(&noStdinPrompt, "no-stdin", false, "If this flag is provided, then the CLI pipeline will not request absent arguments via stdin")
 	flagSet.StringVar(&pathOverride, "path", "", "Providing the path argument overrides the $PWD of the pipeline for generation")
 	flagSet.StringVar(&version, "version", "latest", "The version is provided by the 'scribe' command, however if only using 'go run', it can be provided here")
+	flagSet.IntVar(&concurrency, "max-concurrency", 0, "The maximum number of steps in a pipeline that run at the same time. The default value of 0 means there is no limit")
+	flagSet.BoolVar(&failFast, "fail-fast", false, "Cancel every running step as soon as one step fails. This is the default behavior")
+	flagSet.BoolVar(&keepGoing, "keep-going", false, "Keep running the steps that do not depend on a failed step and report every failed step at the end")
+	flagSet.StringVar(&cache, "cache", defaultCache.String(), "A URI that refers to a directory or bucket where the outputs of cached steps are stored between runs. Must include a protocol, like 'file://', 'gcs://', or 's3://'")
+	flagSet.BoolVar(&noCache, "no-cache", false, "If this flag is provided, then cached steps always run and their outputs are not stored")
+	flagSet.StringVar(&list, "list", "", "List every pipeline, its steps, and the events that trigger it instead of running the pipeline. Options: [table, json]. Default: 'table'")
+	flagSet.Lookup("list").NoOptDefVal = ListFormatTable
+	flagSet.BoolVar(&validate, "validate", false, "Report the problems in the pipeline, like arguments that no step provides, instead of running the pipeline")
+	flagSet.BoolVar(&droneSteps, "drone-steps", false, "Generate one Drone step for every step in the pipeline instead of one Drone step for every pipeline")
+	flagSet.StringVar(&droneLanguage, "drone-language", DroneLanguageYAML, "The language of the config that the Drone client generates. Options: [yaml, starlark]. Default: 'yaml'")
+	flagSet.StringVar(&resume, "resume", "", "The build ID of a previous local run to resume. The previous run's state is reused and the steps that succeeded in it are skipped. Only runs of the dagger client can be resumed")
 
 	if err := flagSet.Parse(args); err != nil {
 		return nil, err
@@ -133,6 +254,23 @@ func ParseArguments(args []string) (*PipelineArgs, error) {
 		return nil, errors.New("both '-step' and '-pipeline' (-p) can not be provided at the same time")
 	}
 
+	// Validation: `-step` and `-step-name` only run the steps that they select, so they can not be combined with each other or with the flags that also select the steps around them.
+	if step.Valid && (stepName != "" || stepWithDeps.Valid || until != "" || from != "") {
+		return nil, errors.New("'--step' can not be provided with '--step-name', '--step-with-deps', '--until', or '--from'")
+	}
+
+	if stepName != "" && (stepWithDeps.Valid || until != "" || from != "") {
+		return nil, errors.New("'--step-name' can not be provided with '--step-with-deps', '--until', or '--from'")
+	}
+
+	if stepWithDeps.Valid && until != "" {
+		return nil, errors.New("both '--step-with-deps' and '--until' can not be provided at the same time")
+	}
+
+	if (stepName != "" || stepWithDeps.Valid || until != "" || from != "") && len(pipelineName.names) != 0 {
+		return nil, errors.New("'--step-name', '--step-with-deps', '--until', and '--from' can not be provided with '-pipeline' (-p)")
+	}
+
 	arguments := &PipelineArgs{
 		CanStdinPrompt: !noStdinPrompt,
 		Client:         client,
@@ -140,14 +278,64 @@ func ParseArguments(args []string) (*PipelineArgs, error) {
 		LogLevel:       level,
 		BuildID:        buildID,
 		State:          state,
+		StateFallback:  stateFallback,
 		PipelineName:   pipelineName.names,
 		Event:          event,
+		MaxConcurrency: concurrency,
+		KeepGoing:      keepGoing,
+		Cache:          cache,
+		NoCache:        noCache,
+		StepName:       stepName,
+		Until:          until,
+		From:           from,
+		DroneSteps:     droneSteps,
+	}
+
+	if concurrency < 0 {
+		return nil, errors.New("'--max-concurrency' can not be negative")
+	}
+
+	if list != "" && list != ListFormatTable && list != ListFormatJSON {
+		return nil, fmt.Errorf("unknown '--list' format '%s'. Options: [%s, %s]", list, ListFormatTable, ListFormatJSON)
+	}
+	arguments.List = list
+
+	if list != "" && validate {
+		return nil, errors.New("both '--list' and '--validate' can not be provided at the same time")
+	}
+	arguments.Validate = validate
+
+	if droneLanguage != DroneLanguageYAML && droneLanguage != DroneLanguageStarlark {
+		return nil, fmt.Errorf("unknown '--drone-language' '%s'. Options: [%s, %s]", droneLanguage, DroneLanguageYAML, DroneLanguageStarlark)
+	}
+	arguments.DroneLanguage = droneLanguage
+
+	if failFast && keepGoing {
+		return nil, errors.New("both '--fail-fast' and '--keep-going' can not be provided at the same time")
+	}
+
+	if resume != "" {
+		if flagSet.Changed("build-id") && buildID != resume {
+			return nil, errors.New("'--build-id' must be the same as '--resume' when resuming a build")
+		}
+
+		arguments.Resume = resume
+		arguments.BuildID = resume
+	}
+
+	// The state for each build is stored in its own directory so that it can be found again when the build is resumed.
+	if arguments.State == "" {
+		arguments.State = DefaultState(arguments.BuildID)
 	}
 
 	if step.Valid {
 		arguments.Step = &step.Value
 	}
 
+	if stepWithDeps.Valid {
+		arguments.StepWithDeps = &stepWithDeps.Value
+	}
+
 	path := flagSet.Arg(flagSet.NArg() - 1)
 
 	if path == "" {
@@ -0,0 +1,24 @@
+package scribe
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/pipeline"
+)
+
+// cacheStore creates the CacheStore that the actions returned by 'Scribe.Cache' use. It is created once, the first time that one of them runs.
+type cacheStore struct {
+	once  sync.Once
+	store *pipeline.CacheStore
+	err   error
+}
+
+func (c *cacheStore) get(ctx context.Context, pargs *args.PipelineArgs) (*pipeline.CacheStore, error) {
+	c.once.Do(func() {
+		c.store, c.err = pipeline.NewCacheStoreFromArgs(ctx, pargs)
+	})
+
+	return c.store, c.err
+}
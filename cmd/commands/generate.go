@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/grafana/scribe"
+	"github.com/grafana/scribe/stringutil"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	ErrorConfigDrift  = errors.New("generated configuration is different from the file")
+	ErrorNotGenerator = errors.New("'generate' requires a client that generates configuration, like 'drone'")
+)
+
+// GenerateArgs separates the arguments of the "generate" subcommand from the arguments of the default command.
+// 'scribe generate [--check file] [flags] [path]' is the same as 'scribe [flags] [path]', except that the generated configuration is compared with 'file' if '--check' is provided.
+// It returns the value of '--check' and the rest of the arguments.
+func GenerateArgs(pargs []string) (string, []string, error) {
+	var (
+		check string
+		rest  = make([]string, 0, len(pargs))
+	)
+
+	for i := 0; i < len(pargs); i++ {
+		v := pargs[i]
+		switch {
+		case v == "--check" || v == "-check":
+			if i+1 == len(pargs) {
+				return "", nil, errors.New("'--check' requires the path to the committed configuration file")
+			}
+			check = pargs[i+1]
+			i++
+		case strings.HasPrefix(v, "--check="), strings.HasPrefix(v, "-check="):
+			check = v[strings.Index(v, "=")+1:]
+		default:
+			rest = append(rest, v)
+		}
+	}
+
+	return check, rest, nil
+}
+
+// ValidateGenerateClient returns ErrorNotGenerator if the client runs the pipeline instead of generating configuration for it.
+func ValidateGenerateClient(client string) error {
+	if slices.Contains(scribe.LocalModes, client) || client == scribe.ClientCLI {
+		return fmt.Errorf("%w. client: '%s'", ErrorNotGenerator, client)
+	}
+
+	return nil
+}
+
+// Check runs the pipeline with the provided options, which should use a client that generates configuration, and compares the configuration with the file at 'path'.
+// If they are different, then a unified diff from the file to the generated configuration is written to 'w', and an error wrapping ErrorConfigDrift is returned.
+func Check(ctx context.Context, opts *RunOpts, path string, w io.Writer) error {
+	committed, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading configuration file: %w", err)
+	}
+
+	generated := &bytes.Buffer{}
+	o := *opts
+	o.Stdout = generated
+
+	if err := Run(ctx, &o).Run(); err != nil {
+		return fmt.Errorf("error generating configuration: %w", err)
+	}
+
+	diff := stringutil.UnifiedDiff(path, path+" (generated)", string(committed), generated.String())
+	if diff == "" {
+		return nil
+	}
+
+	fmt.Fprint(w, diff)
+	return fmt.Errorf("%w: '%s'. Regenerate the file to update it", ErrorConfigDrift, path)
+}
@@ -0,0 +1,19 @@
+package commands
+
+import "github.com/grafana/scribe/args"
+
+// ListArgs converts the arguments of the "list" subcommand into the arguments of the default command.
+// 'scribe list [--json] [flags] [path]' is the same as 'scribe --list=table|json [flags] [path]'.
+func ListArgs(pargs []string) []string {
+	format := args.ListFormatTable
+	rest := make([]string, 0, len(pargs)+1)
+	for _, v := range pargs {
+		if v == "--json" {
+			format = args.ListFormatJSON
+			continue
+		}
+		rest = append(rest, v)
+	}
+
+	return append([]string{"--list=" + format}, rest...)
+}
@@ -81,10 +81,48 @@ func Run(ctx context.Context, opts *RunOpts) *exec.Cmd {
 	// So the path to the pipeline is not preserved, which is why we have to provide the path as an argument
 	cmdArgs := []string{"run", path, "--client", args.Client, "--log-level", args.LogLevel.String(), "--path", args.Path, "--version", version, "--build-id", args.BuildID, "--event", args.Event, "--state", state}
 
+	for _, v := range args.StateFallback {
+		cmdArgs = append(cmdArgs, "--state-fallback", v)
+	}
+
 	for k, v := range args.ArgMap {
 		cmdArgs = append(cmdArgs, "--arg", fmt.Sprintf("%s=%s", k, v))
 	}
 
+	if args.MaxConcurrency != 0 {
+		cmdArgs = append(cmdArgs, "--max-concurrency", strconv.Itoa(args.MaxConcurrency))
+	}
+
+	if args.KeepGoing {
+		cmdArgs = append(cmdArgs, "--keep-going")
+	}
+
+	if args.List != "" {
+		cmdArgs = append(cmdArgs, "--list="+args.List)
+	}
+
+	if args.Validate {
+		cmdArgs = append(cmdArgs, "--validate")
+	}
+
+	if args.DroneSteps {
+		cmdArgs = append(cmdArgs, "--drone-steps")
+	}
+
+	if args.DroneLanguage != "" {
+		cmdArgs = append(cmdArgs, "--drone-language", args.DroneLanguage)
+	}
+
+	if args.Resume != "" {
+		cmdArgs = append(cmdArgs, "--resume", args.Resume)
+	}
+
+	if args.NoCache {
+		cmdArgs = append(cmdArgs, "--no-cache")
+	} else if args.Cache != "" {
+		cmdArgs = append(cmdArgs, "--cache", args.Cache)
+	}
+
 	if args.PipelineName != nil {
 		for _, v := range args.PipelineName {
 			cmdArgs = append(cmdArgs, fmt.Sprintf("--pipeline=\"%s\"", v))
@@ -93,6 +131,22 @@ func Run(ctx context.Context, opts *RunOpts) *exec.Cmd {
 		cmdArgs = append(cmdArgs, "--step", strconv.FormatInt(*args.Step, 10))
 	}
 
+	if args.StepName != "" {
+		cmdArgs = append(cmdArgs, "--step-name", args.StepName)
+	}
+
+	if args.StepWithDeps != nil {
+		cmdArgs = append(cmdArgs, "--step-with-deps", strconv.FormatInt(*args.StepWithDeps, 10))
+	}
+
+	if args.Until != "" {
+		cmdArgs = append(cmdArgs, "--until", args.Until)
+	}
+
+	if args.From != "" {
+		cmdArgs = append(cmdArgs, "--from", args.From)
+	}
+
 	logger.Infoln("Running scribe pipeline with command", append([]string{"go"}, cmdArgs...))
 
 	cmd := exec.CommandContext(ctx, "go", cmdArgs...)
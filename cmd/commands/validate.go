@@ -0,0 +1,7 @@
+package commands
+
+// ValidateArgs converts the arguments of the "validate" subcommand into the arguments of the default command.
+// 'scribe validate [flags] [path]' is the same as 'scribe --validate [flags] [path]'.
+func ValidateArgs(pargs []string) []string {
+	return append([]string{"--validate"}, pargs...)
+}
@@ -45,12 +45,39 @@ func main() {
 
 	log.Debugln("Running version", Version)
 	var (
-		ctx = context.Background()
+		ctx      = context.Background()
+		pargs    = os.Args[1:]
+		generate bool
+		check    string
 	)
 
-	args := commands.MustParseArgs(os.Args[1:])
+	if len(pargs) != 0 {
+		switch pargs[0] {
+		// 'scribe list' lists the pipelines and steps instead of running them.
+		case "list":
+			pargs = commands.ListArgs(pargs[1:])
+		// 'scribe validate' reports the problems in the pipeline instead of running it.
+		case "validate":
+			pargs = commands.ValidateArgs(pargs[1:])
+		// 'scribe generate' writes the configuration for a client like drone, or compares it with a file if '--check' is provided.
+		case "generate":
+			c, rest, err := commands.GenerateArgs(pargs[1:])
+			if err != nil {
+				log.Fatalln(err)
+			}
+			generate, check, pargs = true, c, rest
+		}
+	}
+
+	args := commands.MustParseArgs(pargs)
+
+	if generate {
+		if err := commands.ValidateGenerateClient(args.Client); err != nil {
+			log.Fatalln(err)
+		}
+	}
 
-	cmd := commands.Run(ctx, &commands.RunOpts{
+	runOpts := &commands.RunOpts{
 		Version: Version,
 		State:   args.State,
 		Path:    args.Path,
@@ -58,7 +85,17 @@ func main() {
 		Stdout:  os.Stdout,
 		Stderr:  os.Stderr,
 		Stdin:   os.Stdin,
-	})
+	}
+
+	if check != "" {
+		if err := commands.Check(ctx, runOpts, check, os.Stdout); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	cmd := commands.Run(ctx, runOpts)
 
 	var (
 		c        = make(chan os.Signal, 1)
@@ -2,6 +2,7 @@ package cmdutil
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/grafana/scribe/args"
 	"github.com/grafana/scribe/pipeline"
@@ -16,6 +17,22 @@ type CommandOpts struct {
 	CompiledPipeline string
 }
 
+// argFlags returns the '--arg' flags for each value in the ArgMap, sorted by key so that generated commands are stable.
+func argFlags(argMap args.ArgMap) []string {
+	keys := make([]string, 0, len(argMap))
+	for k := range argMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	flags := make([]string, len(keys))
+	for i, k := range keys {
+		flags[i] = fmt.Sprintf("--arg=%s=%s", k, argMap[k])
+	}
+
+	return flags
+}
+
 // StepCommand returns the command string for running a single step.
 // The path argument can be omitted, which is particularly helpful if the current directory is a pipeline.
 func StepCommand(opts CommandOpts) ([]string, error) {
@@ -29,6 +46,10 @@ func StepCommand(opts CommandOpts) ([]string, error) {
 		args = append(args, fmt.Sprintf("--state=%s", opts.State))
 	}
 
+	for _, v := range opts.StateFallback {
+		args = append(args, fmt.Sprintf("--state-fallback=%s", v))
+	}
+
 	if opts.LogLevel != 0 {
 		args = append(args, fmt.Sprintf("--log-level=%s", opts.LogLevel.String()))
 	}
@@ -37,10 +58,12 @@ func StepCommand(opts CommandOpts) ([]string, error) {
 		args = append(args, fmt.Sprintf("--version=%s", opts.Version))
 	}
 
+	if opts.NoCache {
+		args = append(args, "--no-cache")
+	}
+
 	if len(opts.ArgMap) != 0 {
-		for k, v := range opts.ArgMap {
-			args = append(args, fmt.Sprintf("--arg=%s=%s", k, v))
-		}
+		args = append(args, argFlags(opts.ArgMap)...)
 	}
 
 	name := "scribe"
@@ -73,6 +96,10 @@ func PipelineCommand(opts PipelineCommandOpts) ([]string, error) {
 		args = append(args, fmt.Sprintf("--state=%s", opts.State))
 	}
 
+	for _, v := range opts.StateFallback {
+		args = append(args, fmt.Sprintf("--state-fallback=%s", v))
+	}
+
 	if opts.LogLevel != 0 {
 		args = append(args, fmt.Sprintf("--log-level=%s", opts.LogLevel))
 	}
@@ -86,9 +113,7 @@ func PipelineCommand(opts PipelineCommandOpts) ([]string, error) {
 	}
 
 	if len(opts.ArgMap) != 0 {
-		for k, v := range opts.ArgMap {
-			args = append(args, fmt.Sprintf("--arg=%s=%s", k, v))
-		}
+		args = append(args, argFlags(opts.ArgMap)...)
 	}
 
 	name := "scribe"
@@ -49,14 +49,12 @@ func main() {
 	// In parallel, install the yarn and go dependencies, and cache the node_modules and $GOPATH/pkg folders.
 	// The cache should invalidate if the yarn.lock or go.sum files have changed
 	sw.Add(
-		pipeline.NamedStep("install frontend dependencies", sw.Cache(
-			yarn.InstallAction(),
-			fs.Cache("node_modules", fs.FileHasChanged("yarn.lock")),
-		)).WithImage("node:latest"),
-		pipeline.NamedStep("install backend dependencies", sw.Cache(
-			golang.ModDownload(),
-			fs.Cache("$GOPATH/pkg", fs.FileHasChanged("go.sum")),
-		)).WithImage("node:latest"),
+		pipeline.NamedStep("install frontend dependencies", yarn.InstallAction()).
+			WithImage("node:latest").
+			WithCache(fs.Cache("node_modules", fs.FileHasChanged("yarn.lock"))),
+		pipeline.NamedStep("install backend dependencies", golang.ModDownload()).
+			WithImage("golang:1.19").
+			WithCache(fs.Cache("$GOPATH/pkg", fs.FileHasChanged("go.sum"))),
 		writeVersion(sw).WithName("write-version-file"),
 	)
 
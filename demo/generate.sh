@@ -4,5 +4,17 @@ for demo in ./demo/* ; do
     if [ -d "$demo" ]; then
       echo "go run $demo -path=$demo -client=drone > $demo/gen_drone.yml"
       go run $demo --path=$demo --client=drone > $demo/gen_drone.yml
+      if [ -f "$demo/gen_drone_steps.yml" ]; then
+        echo "go run $demo -path=$demo -client=drone -drone-steps > $demo/gen_drone_steps.yml"
+        go run $demo --path=$demo --client=drone --drone-steps > $demo/gen_drone_steps.yml
+      fi
+      if [ -f "$demo/gen_drone.star" ]; then
+        echo "go run $demo -path=$demo -client=drone -drone-language=starlark > $demo/gen_drone.star"
+        go run $demo --path=$demo --client=drone --drone-language=starlark > $demo/gen_drone.star
+      fi
+      echo "go run $demo -path=$demo -client=github > $demo/gen_github.yml"
+      go run $demo --path=$demo --client=github > $demo/gen_github.yml
+      echo "go run $demo -path=$demo -client=gitlab > $demo/gen_gitlab.yml"
+      go run $demo --path=$demo --client=gitlab > $demo/gen_gitlab.yml
     fi
 done
@@ -35,14 +35,10 @@ func writeVersion(sw *scribe.Scribe) pipeline.Step {
 
 func installDependencies(sw *scribe.Scribe) {
 	sw.Add(
-		pipeline.NamedStep("install frontend dependencies", sw.Cache(
-			yarn.InstallAction(),
-			fs.Cache("node_modules", fs.FileHasChanged("yarn.lock")),
-		)),
-		pipeline.NamedStep("install backend dependencies", sw.Cache(
-			golang.ModDownload(),
-			fs.Cache("$GOPATH/pkg", fs.FileHasChanged("go.sum")),
-		)),
+		pipeline.NamedStep("install frontend dependencies", yarn.InstallAction()).
+			WithCache(fs.Cache("node_modules", fs.FileHasChanged("yarn.lock"))),
+		pipeline.NamedStep("install backend dependencies", golang.ModDownload()).
+			WithCache(fs.Cache("$GOPATH/pkg", fs.FileHasChanged("go.sum"))),
 	)
 }
 
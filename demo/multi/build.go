@@ -43,7 +43,7 @@ var stepBuildBackend = pipeline.NamedStep("build-backend", actionBuildBackend).
 	Requires(ArgumentNodeDependencies, pipeline.ArgumentSourceFS).
 	Provides(ArgumentCompiledBackend)
 
-var stepBuildFrontend = pipeline.NamedStep("build-backend", actionBuildFrontend).
+var stepBuildFrontend = pipeline.NamedStep("build-frontend", actionBuildFrontend).
 	Requires(ArgumentGoDependencies, pipeline.ArgumentSourceFS).
 	Provides(ArgumentCompiledFrontend)
 
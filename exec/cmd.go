@@ -62,25 +62,43 @@ func RunCommand(ctx context.Context, stdout, stderr io.Writer, name string, arg
 
 // RunAction returns an action that runs a given command and set of arguments.
 // The command's stdout and stderr are assigned the systems' stdout/stderr streams.
+// The step's environment (opts.Env) is added to the command's environment.
 func RunAction(name string, arg ...string) pipeline.Action {
 	return func(ctx context.Context, opts pipeline.ActionOpts) error {
-		return RunCommand(ctx, opts.Stdout, opts.Stderr, name, arg...)
+		return RunCommandWithOpts(ctx, RunOpts{
+			Path:   ".",
+			Name:   name,
+			Args:   arg,
+			Stdout: opts.Stdout,
+			Stderr: opts.Stderr,
+			Env:    opts.Env,
+		})
 	}
 }
 
 // Run returns an action that runs a given command and set of arguments.
 // The command's stdout and stderr are assigned the systems' stdout/stderr streams.
+// The step's environment (opts.Env) is added to the command's environment.
 func RunAt(path string, name string, arg ...string) pipeline.Action {
 	return func(ctx context.Context, opts pipeline.ActionOpts) error {
-		return RunCommandAt(ctx, opts.Stdout, opts.Stderr, path, name, arg...)
+		return RunCommandWithOpts(ctx, RunOpts{
+			Path:   path,
+			Name:   name,
+			Args:   arg,
+			Stdout: opts.Stdout,
+			Stderr: opts.Stderr,
+			Env:    opts.Env,
+		})
 	}
 }
 
+// Run runs a given command and set of arguments with the step's environment (opts.Env) added to the command's environment.
 func Run(ctx context.Context, opts pipeline.ActionOpts, name string, args ...string) error {
 	return RunCommandWithOpts(ctx, RunOpts{
 		Name:   name,
 		Args:   args,
 		Stdout: opts.Stdout,
 		Stderr: opts.Stderr,
+		Env:    opts.Env,
 	})
 }
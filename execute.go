@@ -62,11 +62,16 @@ func executeWithLogging(log logrus.FieldLogger, ef executeFunc) executeFunc {
 	return func(ctx context.Context, collection *pipeline.Collection) error {
 		err := ef(ctx, collection)
 		if err != nil {
-			if errors.Is(err, ErrorCancelled) {
+			if errors.Is(err, ErrorCancelled) || errors.Is(err, context.Canceled) {
 				log.WithFields(logrus.Fields{
 					"status":       "cancelled",
 					"completed_at": time.Now().Unix(),
 				}).WithError(err).Infoln("execution completed")
+			} else if errors.Is(err, ErrorTimeout) || errors.Is(err, context.DeadlineExceeded) {
+				log.WithFields(logrus.Fields{
+					"status":       "timeout",
+					"completed_at": time.Now().Unix(),
+				}).WithError(err).Infoln("execution completed")
 			} else {
 				log.WithFields(logrus.Fields{
 					"status":       "error",
@@ -110,28 +115,83 @@ func executeWithSteps(
 	}
 }
 
-func executeWithPipelines(
-	args *args.PipelineArgs,
-	name string,
-	n *counter,
-	ef executeFunc,
-) executeFunc {
-	return func(ctx context.Context, collection *pipeline.Collection) error {
-		// If the user has specified specific pipelines, then cut the "Collection" to only include those pipelines.
-		if len(args.PipelineName) != 0 {
-			pipelines, err := collection.PipelinesByName(ctx, args.PipelineName)
-			if err != nil {
-				return fmt.Errorf("could not find any pipelines that match '%v'. Error: %w", args.PipelineName, err)
-			}
-			c := pipeline.NewCollection()
-			if err := c.AddPipelines(pipelines...); err != nil {
-				return err
-			}
-			collection = c
+// selectSteps reduces the collection to the pipelines selected with the '--pipeline' argument, or to the steps selected with the '--step-name', '--step-with-deps', '--until', and '--from' arguments.
+// Unlike the other filters, the selection uses the edges between the steps, so it can only be done after the edges of the collection are built.
+func selectSteps(ctx context.Context, args *args.PipelineArgs, collection *pipeline.Collection) (*pipeline.Collection, error) {
+	// If the user supplies a --pipeline or -p argument, reduce the collection to those pipelines.
+	if len(args.PipelineName) != 0 {
+		c, err := collection.WithPipelines(ctx, args.PipelineName...)
+		if err != nil {
+			return nil, fmt.Errorf("could not find any pipelines that match '%v'. Error: %w", args.PipelineName, err)
 		}
 
-		return ef(ctx, collection)
+		return c, nil
+	}
+
+	if args.StepName != "" {
+		ids, err := stepIDsByName(ctx, collection, args.StepName)
+		if err != nil {
+			return nil, err
+		}
+
+		return collection.WithSteps(ids...)
+	}
+
+	if args.From != "" {
+		ids, err := stepIDsByName(ctx, collection, args.From)
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := collection.WithDownstream(ids...)
+		if err != nil {
+			return nil, fmt.Errorf("error selecting the steps after '%s': %w", args.From, err)
+		}
+		collection = c
+	}
+
+	var until []int64
+	if args.StepWithDeps != nil {
+		until = []int64{*args.StepWithDeps}
+	}
+
+	if args.Until != "" {
+		ids, err := stepIDsByName(ctx, collection, args.Until)
+		if err != nil {
+			return nil, err
+		}
+		until = ids
+	}
+
+	if len(until) == 0 {
+		return collection, nil
 	}
+
+	c, err := collection.WithUpstream(until...)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting the steps before '%v': %w", until, err)
+	}
+
+	return c, nil
+}
+
+// stepIDsByName returns the IDs of every step with a name that matches the glob 'pattern'.
+func stepIDsByName(ctx context.Context, collection *pipeline.Collection, pattern string) ([]int64, error) {
+	steps, err := collection.ByNamePattern(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("could not find a step with a name that matches '%s'. Error: %w", pattern, pipeline.ErrorStepNotFound)
+	}
+
+	ids := make([]int64, len(steps))
+	for i, v := range steps {
+		ids[i] = v.ID
+	}
+
+	return ids, nil
 }
 
 // executeWithEvent uses the provided '-event' argument which will populate the state with data that represents the event.
@@ -223,7 +283,7 @@ var LocalModes = []string{"dagger"}
 
 // Execute runs the provided executeFunc with the appropriate wrappers.
 // All of the arguments are for populating the wrappers.
-func execute(ctx context.Context, collection *pipeline.Collection, name string, opts clients.CommonOpts, n *counter, ef executeFunc) error {
+func execute(ctx context.Context, collection *pipeline.Collection, name string, opts clients.CommonOpts, n *counter, v validation, ef executeFunc) error {
 	logger := opts.Log.WithFields(plog.Combine(plog.TracingFields(ctx), plog.PipelineFields(opts)))
 
 	// Wrap with signals watching. If the user submits a SIGTERM/SIGINT/SIGKILL, this function will catch it and return an error.
@@ -238,8 +298,11 @@ func execute(ctx context.Context, collection *pipeline.Collection, name string,
 	//// If the user supplies a --step argument, reduce the collection
 	wrapped = executeWithSteps(opts.Args, name, n, wrapped)
 
-	//// If the user supplies a --pipeline or -p argument, reduce the collection
-	wrapped = executeWithPipelines(opts.Args, name, n, wrapped)
+	// If the user supplies a --list argument, print every pipeline and step instead of running them.
+	wrapped = executeWithList(opts.Args, opts.Output, opts.Log, wrapped)
+
+	// If the user supplies a --validate argument, report the problems in the pipeline instead of running it.
+	wrapped = executeWithValidate(opts, opts.Output, v, wrapped)
 
 	// Add a root tracing span to the context, and end the span when the executeFunc is done.
 	wrapped = executeWithTracing(opts.Tracer, wrapped)
@@ -1,17 +1,42 @@
 package fs
 
-import "github.com/grafana/scribe/pipeline"
+import (
+	"context"
+	"os"
 
-// FileHasChanged creates a checksum for the file "file" and stores it.
-// If the checksum does not exist in the scribe key store, then it will return false.
-func FileHasChanged(file string) pipeline.CacheCondition {
-	return func() bool {
-		return false
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/swfs"
+)
+
+// FileHasChanged creates a cache key from the checksum of the file "file".
+// If the checksum is different from the one in a previous run, then the cached step runs again.
+func FileHasChanged(file string) pipeline.CacheKey {
+	return GlobHasChanged(file)
+}
+
+// GlobHasChanged creates a cache key from the paths, modes, and checksums of every file that matches one of the glob patterns (see 'swfs.Match').
+// If a file is added, removed, or changed, then the cached step runs again. If a pattern matches a directory, then every file in the directory is used.
+func GlobHasChanged(patterns ...string) pipeline.CacheKey {
+	return ContentHasChanged(swfs.HashOpts{
+		Include: patterns,
+	})
+}
+
+// ContentHasChanged creates a cache key from the paths, modes, and checksums of the files in the source directory that are selected by the options.
+// For example, this can be used to re-run a step whenever any file that is tracked by git changes:
+//
+//	fs.ContentHasChanged(swfs.HashOpts{Include: []string{"src"}, GitIgnore: true})
+func ContentHasChanged(opts swfs.HashOpts) pipeline.CacheKey {
+	return func(ctx context.Context, o pipeline.CacheKeyOpts) ([]byte, error) {
+		return swfs.HashFSWithOpts(os.DirFS(o.Dir), opts)
 	}
 }
 
-// Cache will store the directory or file located at `path` if the conditions return true.
-// If all of the conditions return true, then the step is skipped and the directory is added to the local filesystem.
-func Cache(path string, conditions ...pipeline.CacheCondition) pipeline.Cacher {
-	return func(pipeline.Step) {}
+// Cache will store the directory or file located at `path` after the step runs.
+// The step's cache key is derived from the provided keys. If they have not changed since a previous run, then the step is skipped and the directory is added to the local filesystem.
+func Cache(path string, keys ...pipeline.CacheKey) pipeline.Cacher {
+	return func(c *pipeline.Cache) {
+		pipeline.CachePaths(path)(c)
+		pipeline.CacheKeys(keys...)(c)
+	}
 }
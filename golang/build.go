@@ -14,7 +14,7 @@ func BuildStep(pkg, output string, args, env []string) pipeline.Step {
 			Output: output,
 			Stdout: opts.Stdout,
 			Stderr: opts.Stderr,
-			Env:    env,
+			Env:    append(append([]string{}, opts.Env...), env...),
 			Args:   args,
 		})
 	})
@@ -28,7 +28,7 @@ func BuildAction(pkg, output string, args, env []string) pipeline.Action {
 			Output: output,
 			Stdout: opts.Stdout,
 			Stderr: opts.Stderr,
-			Env:    env,
+			Env:    append(append([]string{}, opts.Env...), env...),
 			Args:   args,
 		})
 	}
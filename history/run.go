@@ -0,0 +1,221 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/grafana/scribe/pipeline"
+)
+
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSuccess   Status = "success"
+	StatusFailure   Status = "failure"
+	StatusSkipped   Status = "skipped"
+	StatusTimeout   Status = "timeout"
+	StatusCancelled Status = "cancelled"
+)
+
+// StatusFromError returns the status of a step, pipeline, or run that returned the error 'err'.
+func StatusFromError(err error) Status {
+	switch {
+	case err == nil:
+		return StatusSuccess
+	case errors.Is(err, pipeline.ErrorTimeout), errors.Is(err, context.DeadlineExceeded):
+		return StatusTimeout
+	case errors.Is(err, context.Canceled):
+		return StatusCancelled
+	}
+
+	return StatusFailure
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+// Step is the record of a step in a run.
+type Step struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Pipeline    string    `json:"pipeline"`
+	Status      Status    `json:"status"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Pipeline is the record of a pipeline in a run.
+type Pipeline struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Status      Status    `json:"status"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Run is the record of a local run, identified by its build ID.
+// When a run is resumed, the same record is updated and 'Attempts' is incremented.
+type Run struct {
+	BuildID string `json:"build_id"`
+	// Name is the name of the pipeline program, provided to 'scribe.New' or 'scribe.NewMulti'.
+	Name string `json:"name"`
+	// State is the URL of the state that was used for the run. It is reused when the run is resumed.
+	State       string    `json:"state"`
+	Status      Status    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Error       string    `json:"error,omitempty"`
+
+	Pipelines map[int64]*Pipeline `json:"pipelines"`
+	Steps     map[int64]*Step     `json:"steps"`
+}
+
+// NewRun creates a new record for a run that has not started yet.
+func NewRun(buildID, name, state string) *Run {
+	return &Run{
+		BuildID:   buildID,
+		Name:      name,
+		State:     state,
+		Pipelines: map[int64]*Pipeline{},
+		Steps:     map[int64]*Step{},
+	}
+}
+
+// A Recorder updates a Run as the pipelines and steps in it start and complete, and saves it to the store after every update
+// so that the record is available even if the run is interrupted.
+// All of its methods are safe to use concurrently and can be called on a nil Recorder, in which case nothing is recorded.
+type Recorder struct {
+	store *Store
+	run   *Run
+	mtx   *sync.Mutex
+}
+
+func NewRecorder(store *Store, run *Run) *Recorder {
+	if run.Pipelines == nil {
+		run.Pipelines = map[int64]*Pipeline{}
+	}
+	if run.Steps == nil {
+		run.Steps = map[int64]*Step{}
+	}
+
+	return &Recorder{
+		store: store,
+		run:   run,
+		mtx:   &sync.Mutex{},
+	}
+}
+
+// update applies 'f' to the run and saves it.
+func (r *Recorder) update(f func(run *Run)) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	f(r.run)
+	return r.store.Save(r.run)
+}
+
+// Start records the start of an attempt of the run.
+func (r *Recorder) Start() error {
+	return r.update(func(run *Run) {
+		if run.StartedAt.IsZero() {
+			run.StartedAt = time.Now()
+		}
+		run.Attempts++
+		run.Status = StatusRunning
+		run.CompletedAt = time.Time{}
+		run.Error = ""
+	})
+}
+
+// Finish records the result of the run.
+func (r *Recorder) Finish(err error) error {
+	return r.update(func(run *Run) {
+		run.Status = StatusFromError(err)
+		run.CompletedAt = time.Now()
+		run.Error = errorString(err)
+	})
+}
+
+func (r *Recorder) PipelineStarted(p pipeline.Pipeline) error {
+	return r.update(func(run *Run) {
+		run.Pipelines[p.ID] = &Pipeline{
+			ID:        p.ID,
+			Name:      p.Name,
+			Status:    StatusRunning,
+			StartedAt: time.Now(),
+		}
+	})
+}
+
+func (r *Recorder) PipelineFinished(p pipeline.Pipeline, err error) error {
+	return r.update(func(run *Run) {
+		v, ok := run.Pipelines[p.ID]
+		if !ok {
+			v = &Pipeline{ID: p.ID, Name: p.Name}
+			run.Pipelines[p.ID] = v
+		}
+
+		v.Status = StatusFromError(err)
+		v.CompletedAt = time.Now()
+		v.Error = errorString(err)
+	})
+}
+
+func (r *Recorder) StepStarted(p pipeline.Pipeline, s pipeline.Step) error {
+	return r.update(func(run *Run) {
+		run.Steps[s.ID] = &Step{
+			ID:        s.ID,
+			Name:      s.Name,
+			Pipeline:  p.Name,
+			Status:    StatusRunning,
+			StartedAt: time.Now(),
+		}
+	})
+}
+
+// StepFinished records the result of a step. If 'status' is empty, then the status is derived from 'err'.
+func (r *Recorder) StepFinished(p pipeline.Pipeline, s pipeline.Step, status Status, err error) error {
+	return r.update(func(run *Run) {
+		v, ok := run.Steps[s.ID]
+		if !ok {
+			v = &Step{ID: s.ID, Name: s.Name, Pipeline: p.Name}
+			run.Steps[s.ID] = v
+		}
+
+		if status == "" {
+			status = StatusFromError(err)
+		}
+
+		v.Status = status
+		v.CompletedAt = time.Now()
+		v.Error = errorString(err)
+	})
+}
+
+// Succeeded returns true if the step succeeded in the run. Steps are matched by their ID and name, so a step that was renamed or moved since the run is not considered to have succeeded.
+func (r *Recorder) Succeeded(s pipeline.Step) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	v, ok := r.run.Steps[s.ID]
+	return ok && v.Name == s.Name && v.Status == StatusSuccess
+}
@@ -0,0 +1,106 @@
+package history_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/grafana/scribe/history"
+	"github.com/grafana/scribe/pipeline"
+)
+
+func TestStatusFromError(t *testing.T) {
+	cases := map[history.Status]error{
+		history.StatusSuccess:   nil,
+		history.StatusFailure:   errors.New("failed"),
+		history.StatusTimeout:   fmt.Errorf("step 'a': %w", &pipeline.TimeoutError{}),
+		history.StatusCancelled: fmt.Errorf("%w: interrupted", context.Canceled),
+	}
+
+	for expected, err := range cases {
+		if status := history.StatusFromError(err); status != expected {
+			t.Errorf("expected status '%s' for error '%v', but got '%s'", expected, err, status)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var (
+		store = history.NewStore(t.TempDir())
+		p     = pipeline.Pipeline{ID: 1, Name: "test"}
+		a     = pipeline.Step{ID: 2, Name: "a"}
+		b     = pipeline.Step{ID: 3, Name: "b"}
+	)
+
+	recorder := history.NewRecorder(store, history.NewRun("build-1", "example", "file:///tmp/state"))
+	if err := recorder.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := recorder.StepStarted(p, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.StepFinished(p, a, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.StepStarted(p, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.StepFinished(p, b, "", errors.New("failed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Finish(errors.New("failed")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("It should save the run after every update", func(t *testing.T) {
+		run, err := store.Get("build-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if run.Status != history.StatusFailure || run.Attempts != 1 {
+			t.Fatalf("unexpected run status '%s' after %d attempts", run.Status, run.Attempts)
+		}
+
+		if run.Steps[2].Status != history.StatusSuccess || run.Steps[3].Status != history.StatusFailure {
+			t.Fatalf("unexpected step statuses '%s' and '%s'", run.Steps[2].Status, run.Steps[3].Status)
+		}
+	})
+
+	t.Run("It should only report steps that succeeded when the run is resumed", func(t *testing.T) {
+		run, err := store.Get("build-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resumed := history.NewRecorder(store, run)
+		if err := resumed.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		if !resumed.Succeeded(a) {
+			t.Error("expected step 'a' to have succeeded")
+		}
+
+		if resumed.Succeeded(b) {
+			t.Error("expected step 'b' to not have succeeded")
+		}
+
+		if resumed.Succeeded(a.WithName("renamed")) {
+			t.Error("expected a renamed step to not have succeeded")
+		}
+	})
+
+	t.Run("It should do nothing if the recorder is nil", func(t *testing.T) {
+		var recorder *history.Recorder
+		if err := recorder.StepStarted(p, a); err != nil {
+			t.Fatal(err)
+		}
+
+		if recorder.Succeeded(a) {
+			t.Fatal("expected a nil recorder to report that no steps succeeded")
+		}
+	})
+}
@@ -0,0 +1,124 @@
+// Package history records the local runs of a pipeline so that they can be inspected and resumed with the '--resume' flag.
+// Only the dagger client records its runs; builds that are run with the cli client can not be resumed.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/grafana/scribe/args"
+)
+
+var ErrorRunNotFound = errors.New("run not found in history")
+
+// Dir returns the directory where the run history is stored for builds that store their state at the provided state URL.
+// If the state is stored on the filesystem, then the history is stored in a '.history' directory next to it, so that a build can be resumed
+// with the same '--state' flag that it was started with. The history of builds that store their state remotely is stored in the local data directory (see 'args.DataDir').
+func Dir(state string) string {
+	u, err := url.Parse(state)
+	if err != nil || (u.Scheme != "file" && u.Scheme != "fs") || u.Path == "" {
+		return filepath.Join(args.DataDir(), "history")
+	}
+
+	return filepath.Join(filepath.Dir(filepath.Clean(u.Path)), ".history")
+}
+
+// A Store stores every run as a JSON file named after its build ID.
+type Store struct {
+	Dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{
+		Dir: dir,
+	}
+}
+
+func (s *Store) path(buildID string) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("%s.json", buildID))
+}
+
+// Get returns the run with the provided build ID. If there is no run with that ID, then ErrorRunNotFound is returned.
+func (s *Store) Get(buildID string) (*Run, error) {
+	f, err := os.Open(s.path(buildID))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrorRunNotFound, buildID)
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	run := &Run{}
+	if err := json.NewDecoder(f).Decode(run); err != nil {
+		return nil, fmt.Errorf("error decoding run '%s': %w", buildID, err)
+	}
+
+	return run, nil
+}
+
+// Save writes the run to the store, replacing the previous record of the run.
+// The record is written to a temporary file first so that a run that is interrupted while saving is not corrupted.
+func (s *Store) Save(run *Run) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("error creating run history directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(s.Dir, "run-*.tmp")
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(run); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path(run.BuildID))
+}
+
+// List returns every run in the store, with the most recently started run first.
+func (s *Store) List() ([]*Run, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	runs := []*Run{}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+
+		run, err := s.Get(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+
+	return runs, nil
+}
@@ -0,0 +1,77 @@
+package history_test
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/history"
+)
+
+func TestStore(t *testing.T) {
+	store := history.NewStore(t.TempDir())
+
+	t.Run("It should return ErrorRunNotFound if the run does not exist", func(t *testing.T) {
+		if _, err := store.Get("missing"); !errors.Is(err, history.ErrorRunNotFound) {
+			t.Fatalf("expected error '%v', but got '%v'", history.ErrorRunNotFound, err)
+		}
+	})
+
+	t.Run("It should return the run that was saved", func(t *testing.T) {
+		run := history.NewRun("build-1", "example", "file:///tmp/state")
+		run.StartedAt = time.Now().Add(-time.Hour)
+		if err := store.Save(run); err != nil {
+			t.Fatal(err)
+		}
+
+		v, err := store.Get("build-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v.BuildID != "build-1" || v.Name != "example" || v.State != "file:///tmp/state" {
+			t.Fatalf("unexpected run: %+v", v)
+		}
+	})
+
+	t.Run("It should list runs with the most recently started run first", func(t *testing.T) {
+		run := history.NewRun("build-2", "example", "file:///tmp/state")
+		run.StartedAt = time.Now()
+		if err := store.Save(run); err != nil {
+			t.Fatal(err)
+		}
+
+		runs, err := store.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(runs) != 2 {
+			t.Fatalf("expected 2 runs, but found %d", len(runs))
+		}
+
+		if runs[0].BuildID != "build-2" || runs[1].BuildID != "build-1" {
+			t.Fatalf("unexpected order of runs: '%s', '%s'", runs[0].BuildID, runs[1].BuildID)
+		}
+	})
+}
+
+func TestDir(t *testing.T) {
+	t.Run("It should store the history next to a state on the filesystem", func(t *testing.T) {
+		dir := t.TempDir()
+		state := &url.URL{Scheme: "file", Path: filepath.Join(dir, "state", "build-1")}
+
+		if v, expect := history.Dir(state.String()), filepath.Join(dir, "state", ".history"); v != expect {
+			t.Fatalf("expected '%s', but got '%s'", expect, v)
+		}
+	})
+
+	t.Run("It should store the history in the data directory if the state is not on the filesystem", func(t *testing.T) {
+		if v, expect := history.Dir("s3://bucket/state"), filepath.Join(args.DataDir(), "history"); v != expect {
+			t.Fatalf("expected '%s', but got '%s'", expect, v)
+		}
+	})
+}
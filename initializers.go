@@ -8,7 +8,11 @@ import (
 	"github.com/grafana/scribe/pipeline/clients/cli"
 	"github.com/grafana/scribe/pipeline/clients/dagger"
 	"github.com/grafana/scribe/pipeline/clients/drone"
+	"github.com/grafana/scribe/pipeline/clients/github"
+	"github.com/grafana/scribe/pipeline/clients/gitlab"
 	"github.com/grafana/scribe/pipeline/clients/graphviz"
+	"github.com/grafana/scribe/pipeline/clients/mermaid"
+	"github.com/grafana/scribe/pipeline/clients/plan"
 )
 
 var (
@@ -16,6 +20,10 @@ var (
 	ClientDrone           = "drone"
 	ClientDagger          = "dagger"
 	ClientGraphviz        = "graphviz"
+	ClientGitHub          = "github"
+	ClientGitLab          = "gitlab"
+	ClientMermaid         = "mermaid"
+	ClientPlan            = "plan"
 )
 
 func NewDefaultCollection(opts clients.CommonOpts) *pipeline.Collection {
@@ -40,6 +48,10 @@ var ClientInitializers = map[string]InitializerFunc{
 	ClientDrone:    drone.New,
 	ClientDagger:   dagger.New,
 	ClientGraphviz: graphviz.New,
+	ClientGitHub:   github.New,
+	ClientGitLab:   gitlab.New,
+	ClientMermaid:  mermaid.New,
+	ClientPlan:     plan.New,
 }
 
 func RegisterClient(name string, initializer InitializerFunc) {
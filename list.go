@@ -0,0 +1,103 @@
+package scribe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients/plan"
+	"github.com/sirupsen/logrus"
+)
+
+// executeWithList writes every pipeline in the collection, its steps, and the events that trigger it to 'w' instead of running the pipeline if the '--list' argument was provided.
+// The list uses the same document as the plan client, so the JSON format is the same as the output of '--client plan'.
+func executeWithList(
+	args *args.PipelineArgs,
+	w io.Writer,
+	log logrus.FieldLogger,
+	ef executeFunc,
+) executeFunc {
+	return func(ctx context.Context, collection *pipeline.Collection) error {
+		if args.List == "" {
+			return ef(ctx, collection)
+		}
+
+		if err := collection.BuildEdges(log, pipeline.ClientProvidedArguments...); err != nil {
+			return err
+		}
+
+		p, err := plan.NewPlan(ctx, collection)
+		if err != nil {
+			return err
+		}
+
+		return writeList(w, args.List, p)
+	}
+}
+
+func writeList(w io.Writer, format string, p plan.Plan) error {
+	if format == args.ListFormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PIPELINE\tEVENTS\tREQUIRES\tPROVIDES")
+	for _, v := range p.Pipelines {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", v.Name, listEvents(v.Events), listArguments(v.RequiredArgs), listArguments(v.ProvidedArgs))
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "ID\tSTEP\tPIPELINE\tIMAGE\tREQUIRES\tPROVIDES")
+	for _, v := range p.Pipelines {
+		for _, s := range v.Steps {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", s.ID, s.Name, v.Name, listValue(s.Image), listArguments(s.RequiredArgs), listArguments(s.ProvidedArgs))
+		}
+	}
+
+	return tw.Flush()
+}
+
+// listValue returns '-' for empty values so that the columns of the table are not empty.
+func listValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+
+	return v
+}
+
+func listArguments(a []plan.Argument) string {
+	values := make([]string, len(a))
+	for i, v := range a {
+		values[i] = fmt.Sprintf("%s (%s)", v.Key, v.Type)
+	}
+
+	return listValue(strings.Join(values, ", "))
+}
+
+func listEvents(e []plan.Event) string {
+	values := make([]string, len(e))
+	for i, v := range e {
+		filters := make([]string, len(v.Filters))
+		for n, f := range v.Filters {
+			filters[n] = fmt.Sprintf("%s=%s", f.Key, f.Value)
+			if f.Exclude {
+				filters[n] = fmt.Sprintf("%s!=%s", f.Key, f.Value)
+			}
+		}
+
+		values[i] = v.Name
+		if len(filters) != 0 {
+			values[i] = fmt.Sprintf("%s[%s]", v.Name, strings.Join(filters, " "))
+		}
+	}
+
+	return listValue(strings.Join(values, ", "))
+}
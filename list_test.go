@@ -0,0 +1,73 @@
+package scribe_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/grafana/scribe"
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipeline/clients/plan"
+	"github.com/grafana/scribe/state"
+	"github.com/opentracing/opentracing-go"
+)
+
+func newListScribe(format string, out *bytes.Buffer) *scribe.Scribe {
+	opts := clients.CommonOpts{
+		Name:   "test",
+		Log:    logger(),
+		Tracer: &opentracing.NoopTracer{},
+		Output: out,
+		Args: &args.PipelineArgs{
+			List: format,
+		},
+	}
+
+	// The ensurer fails the test if the list runs any of the steps.
+	sw := scribe.NewWithClient(opts, newEnsurer())
+	arg := state.NewStringArgument("version")
+	sw.Add(pipeline.NoOpStep.WithName("get version").WithImage("alpine").Provides(arg))
+	sw.Add(pipeline.NoOpStep.WithName("build").Requires(arg))
+
+	return sw
+}
+
+func TestList(t *testing.T) {
+	t.Run("It should list the pipelines and steps as a table", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		newListScribe(args.ListFormatTable, out).Done()
+
+		lines := strings.Split(out.String(), "\n")
+		if !strings.HasPrefix(lines[0], "PIPELINE") || !strings.Contains(lines[1], "git-commit") {
+			t.Fatalf("Expected a table of pipelines with their events, but received:\n%s", out.String())
+		}
+
+		for _, v := range []string{"get version", "alpine", "version (string)", "build"} {
+			if !strings.Contains(out.String(), v) {
+				t.Fatalf("Expected the list to contain '%s', but received:\n%s", v, out.String())
+			}
+		}
+	})
+
+	t.Run("It should list the pipelines and steps as JSON", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		newListScribe(args.ListFormatJSON, out).Done()
+
+		p := plan.Plan{}
+		if err := json.Unmarshal(out.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(p.Pipelines) != 1 {
+			t.Fatalf("Expected 1 pipeline but received '%d'", len(p.Pipelines))
+		}
+
+		steps := p.Pipelines[0].Steps
+		if len(steps) != 2 || steps[0].Name != "get version" || steps[1].Name != "build" {
+			t.Fatalf("Unexpected steps: %+v", steps)
+		}
+	})
+}
@@ -1,6 +1,8 @@
 package scribe
 
 import (
+	"time"
+
 	"github.com/grafana/scribe/pipeline"
 	"github.com/grafana/scribe/state"
 )
@@ -13,6 +15,8 @@ type Pipeline struct {
 	Steps    []pipeline.Step
 	Provides []state.Argument
 	When     []pipeline.Event
+	// Timeout is the maximum amount of time that every step in the pipeline can run for. If it is 0, then there is no timeout.
+	Timeout time.Duration
 }
 
 // AddPipelines adds a list of pipelines into the DAG. The order in which they are defined or added is not important; the order in which
@@ -29,6 +33,7 @@ func (s *ScribeMulti) AddPipelines(pipelines ...Pipeline) {
 
 		p = p.Requires(v.Requires...)
 		p = p.Provides(v.Provides...)
+		p = p.WithTimeout(v.Timeout)
 
 		s.Add(p)
 	}
@@ -10,6 +10,8 @@ var (
 	ArgumentBranch    = state.NewStringArgument("git-branch")
 	ArgumentRemoteURL = state.NewStringArgument("remote-url")
 	ArgumentTagName   = state.NewStringArgument("git-tag")
+	// ArgumentTargetBranch is the branch that a pull request will be merged into.
+	ArgumentTargetBranch = state.NewStringArgument("git-target-branch")
 
 	ArgumentWorkingDir = state.NewStringArgument("workdir")
 	// ArgumentSourceFS is the path to the root of the source code for this project.
@@ -19,6 +21,10 @@ var (
 
 	// CI service arguments
 	ArgumentBuildID = state.NewStringArgument("build-id")
+	// ArgumentCronJob is the name of the scheduled job that triggered the build.
+	ArgumentCronJob = state.NewStringArgument("cron-job")
+	// ArgumentPromoteTarget is the environment that the build was promoted to.
+	ArgumentPromoteTarget = state.NewStringArgument("promote-target")
 )
 
 // ClientProvidedArguments are argumnets that must be provided by the Client and not another step.
@@ -0,0 +1,341 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/state"
+	"github.com/grafana/scribe/swfs"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrorCacheSecret = errors.New("secret arguments can not be cached")
+
+const (
+	cachePathFile      = "file"
+	cachePathDirectory = "directory"
+)
+
+// A CacheStore stores the outputs of cached steps so that they can be restored in a later run.
+// It uses a state.Handler for storage, so outputs can be stored anywhere that the state can, like the local filesystem, S3, or GCS.
+type CacheStore struct {
+	Handler state.Handler
+}
+
+func NewCacheStore(handler state.Handler) *CacheStore {
+	return &CacheStore{
+		Handler: handler,
+	}
+}
+
+// NewCacheStoreFromArgs creates a CacheStore using the URL provided with the '--cache' flag.
+// If the '--no-cache' flag was provided, then it returns nil, and clients should run every cached step.
+func NewCacheStoreFromArgs(ctx context.Context, pargs *args.PipelineArgs) (*CacheStore, error) {
+	if pargs.NoCache || pargs.Cache == "" {
+		return nil, nil
+	}
+
+	handler, err := state.NewHandler(ctx, pargs.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cache store: %w", err)
+	}
+
+	return NewCacheStore(handler), nil
+}
+
+// cacheArgument returns the argument that an output of a cached step is stored with.
+// Outputs are named using a checksum because paths and argument keys can contain characters that the state can not store files with, like '/'.
+func cacheArgument(key string, t state.ArgumentType, name string) state.Argument {
+	return state.Argument{
+		Type: t,
+		Key:  fmt.Sprintf("cache-%s-%x", key, sha256.Sum256([]byte(name))),
+	}
+}
+
+func cacheMarker(key string) state.Argument {
+	return state.NewStringArgument(fmt.Sprintf("cache-%s", key))
+}
+
+// resolvePath expands the environment variables in 'path' and makes it relative to 'dir' if it is not absolute.
+func resolvePath(dir, path string) string {
+	path = os.ExpandEnv(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
+
+// Save stores the outputs of a step that ran, using 'key' from 'Cache.Key'.
+// Arguments are read from 'r' and relative paths are resolved from 'dir'.
+// The outputs are only restored by 'Restore' if every one of them was saved.
+func (s *CacheStore) Save(ctx context.Context, key string, c *Cache, dir string, r state.Reader) error {
+	for _, arg := range c.Arguments {
+		if err := s.saveArgument(ctx, key, r, arg); err != nil {
+			return fmt.Errorf("error caching argument '%s': %w", arg.Key, err)
+		}
+	}
+
+	for _, path := range c.Paths {
+		if err := s.savePath(ctx, key, resolvePath(dir, path), path); err != nil {
+			return fmt.Errorf("error caching path '%s': %w", path, err)
+		}
+	}
+
+	return s.Handler.SetString(ctx, cacheMarker(key), time.Now().UTC().Format(time.RFC3339))
+}
+
+func (s *CacheStore) saveArgument(ctx context.Context, key string, r state.Reader, arg state.Argument) error {
+	to := cacheArgument(key, arg.Type, "arg-"+arg.Key)
+
+	switch arg.Type {
+	case state.ArgumentTypeString:
+		v, err := r.GetString(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return s.Handler.SetString(ctx, to, v)
+	case state.ArgumentTypeInt64:
+		v, err := r.GetInt64(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return s.Handler.SetInt64(ctx, to, v)
+	case state.ArgumentTypeFloat64:
+		v, err := r.GetFloat64(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return s.Handler.SetFloat64(ctx, to, v)
+	case state.ArgumentTypeBool:
+		v, err := r.GetBool(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return s.Handler.SetBool(ctx, to, v)
+	case state.ArgumentTypeFile:
+		f, err := r.GetFile(ctx, arg)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = s.Handler.SetFileReader(ctx, to, f)
+		return err
+	case state.ArgumentTypeFS, state.ArgumentTypeUnpackagedFS:
+		path, err := r.GetDirectoryString(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return s.savePath(ctx, key, path, "arg-"+arg.Key)
+	case state.ArgumentTypeSecret:
+		return ErrorCacheSecret
+	}
+
+	return fmt.Errorf("unsupported or unrecognized argument type: %s", arg.Type)
+}
+
+// savePath stores the file or directory at 'path' along with whether it is a file or a directory.
+func (s *CacheStore) savePath(ctx context.Context, key string, path string, name string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	kind := cacheArgument(key, state.ArgumentTypeString, name+"-kind")
+	if info.IsDir() {
+		if err := s.Handler.SetDirectory(ctx, cacheArgument(key, state.ArgumentTypeFS, name), path); err != nil {
+			return err
+		}
+
+		return s.Handler.SetString(ctx, kind, cachePathDirectory)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := s.Handler.SetFileReader(ctx, cacheArgument(key, state.ArgumentTypeFile, name), f); err != nil {
+		return err
+	}
+
+	return s.Handler.SetString(ctx, kind, cachePathFile)
+}
+
+// Restore restores the outputs of a step that were stored with the same 'key' by 'Save'.
+// Arguments are written to 'w' and paths are restored to the filesystem, overwriting the files that already exist.
+// If there are no outputs stored for the key, then it returns false and the step should run.
+func (s *CacheStore) Restore(ctx context.Context, key string, c *Cache, dir string, w state.Writer) (bool, error) {
+	ok, err := s.Handler.Exists(ctx, cacheMarker(key))
+	if err != nil {
+		// The cache store returns an error if it is empty, which means that nothing has been cached yet.
+		if errors.Is(err, state.ErrorEmptyState) || errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !ok {
+		return false, nil
+	}
+
+	for _, arg := range c.Arguments {
+		if err := s.restoreArgument(ctx, key, w, arg); err != nil {
+			return false, fmt.Errorf("error restoring argument '%s' from cache: %w", arg.Key, err)
+		}
+	}
+
+	for _, path := range c.Paths {
+		if err := s.restorePath(ctx, key, resolvePath(dir, path), path); err != nil {
+			return false, fmt.Errorf("error restoring path '%s' from cache: %w", path, err)
+		}
+	}
+
+	return true, nil
+}
+
+func (s *CacheStore) restoreArgument(ctx context.Context, key string, w state.Writer, arg state.Argument) error {
+	from := cacheArgument(key, arg.Type, "arg-"+arg.Key)
+
+	switch arg.Type {
+	case state.ArgumentTypeString:
+		v, err := s.Handler.GetString(ctx, from)
+		if err != nil {
+			return err
+		}
+		return w.SetString(ctx, arg, v)
+	case state.ArgumentTypeInt64:
+		v, err := s.Handler.GetInt64(ctx, from)
+		if err != nil {
+			return err
+		}
+		return w.SetInt64(ctx, arg, v)
+	case state.ArgumentTypeFloat64:
+		v, err := s.Handler.GetFloat64(ctx, from)
+		if err != nil {
+			return err
+		}
+		return w.SetFloat64(ctx, arg, v)
+	case state.ArgumentTypeBool:
+		v, err := s.Handler.GetBool(ctx, from)
+		if err != nil {
+			return err
+		}
+		return w.SetBool(ctx, arg, v)
+	case state.ArgumentTypeFile:
+		f, err := s.Handler.GetFile(ctx, from)
+		if err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		return w.SetFile(ctx, arg, f.Name())
+	case state.ArgumentTypeFS, state.ArgumentTypeUnpackagedFS:
+		// Directories are restored to a new temporary directory rather than to where they were when the step ran.
+		dir, err := os.MkdirTemp("", "scribe-cache-*")
+		if err != nil {
+			return err
+		}
+
+		if err := s.restorePath(ctx, key, dir, "arg-"+arg.Key); err != nil {
+			return err
+		}
+
+		return w.SetDirectory(ctx, arg, dir)
+	case state.ArgumentTypeSecret:
+		return ErrorCacheSecret
+	}
+
+	return fmt.Errorf("unsupported or unrecognized argument type: %s", arg.Type)
+}
+
+// restorePath restores the file or directory that was stored as 'name' to 'path'.
+// Files that already exist are overwritten, but nothing is removed, as 'path' can be a directory that other programs use too, like '$GOPATH/pkg'.
+func (s *CacheStore) restorePath(ctx context.Context, key string, path string, name string) error {
+	kind, err := s.Handler.GetString(ctx, cacheArgument(key, state.ArgumentTypeString, name+"-kind"))
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() != (kind == cachePathDirectory) {
+		return fmt.Errorf("'%s' already exists and is not a %s", path, kind)
+	}
+
+	if kind == cachePathDirectory {
+		dir, err := s.Handler.GetDirectory(ctx, cacheArgument(key, state.ArgumentTypeFS, name))
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return err
+		}
+
+		return swfs.CopyFS(dir, path)
+	}
+
+	f, err := s.Handler.GetFile(ctx, cacheArgument(key, state.ArgumentTypeFile, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Files from object storage are returned after they have been written, so read the file again from the start.
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+
+	return swfs.CopyFileReader(f, path)
+}
+
+// Wrap returns an Action that skips 'action' and restores its outputs if they were stored with the same key in a previous run.
+// Otherwise, 'action' runs and its outputs are stored once it succeeds. Errors from the cache are logged rather than returned, since the step can always run instead.
+// Relative paths are resolved from the current working directory.
+func (s *CacheStore) Wrap(c *Cache, action Action) Action {
+	if s == nil || c == nil || action == nil {
+		return action
+	}
+
+	return func(ctx context.Context, opts ActionOpts) error {
+		log := opts.Logger
+		if log == nil {
+			log = logrus.StandardLogger()
+		}
+
+		key, err := c.Key(ctx, CacheKeyOpts{Dir: ".", State: opts.State})
+		if err != nil {
+			log.WithError(err).Warnln("error calculating cache key; running step without the cache")
+			return action(ctx, opts)
+		}
+
+		log = log.WithField("cache_key", key)
+
+		ok, err := s.Restore(ctx, key, c, ".", opts.State)
+		if err != nil {
+			log.WithError(err).Warnln("error restoring step outputs from cache; running step")
+		}
+		if ok && err == nil {
+			log.Infoln("step outputs restored from cache; skipping step")
+			return nil
+		}
+
+		if err := action(ctx, opts); err != nil {
+			return err
+		}
+
+		if err := s.Save(ctx, key, c, ".", opts.State); err != nil {
+			log.WithError(err).Warnln("error storing step outputs in cache")
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,241 @@
+package pipeline_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/scribe/fs"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/state"
+)
+
+func newTestState(t *testing.T) state.Handler {
+	t.Helper()
+	s, err := state.NewFilesystemState(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestCacheKey(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		arg     = state.NewStringArgument("version")
+		content = []byte("a")
+		key     = func(context.Context, pipeline.CacheKeyOpts) ([]byte, error) {
+			return content, nil
+		}
+	)
+
+	cache := pipeline.NewCache(pipeline.CacheKeys(key, pipeline.ArgumentKey(arg)), pipeline.CachePaths("node_modules"))
+
+	st := newTestState(t)
+	if err := st.SetString(ctx, arg, "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := pipeline.CacheKeyOpts{Dir: t.TempDir(), State: st}
+	first, err := cache.Key(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("It should return the same key if the inputs have not changed", func(t *testing.T) {
+		second, err := cache.Key(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if first != second {
+			t.Fatalf("expected keys to be equal, but got '%s' and '%s'", first, second)
+		}
+	})
+
+	t.Run("It should return a different key if the outputs are different", func(t *testing.T) {
+		second, err := cache.With(pipeline.CachePaths(".yarn")).Key(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if first == second {
+			t.Fatal("expected keys to be different")
+		}
+	})
+
+	t.Run("It should return a different key if an argument has changed", func(t *testing.T) {
+		if err := st.SetString(ctx, arg, "v1.0.1"); err != nil {
+			t.Fatal(err)
+		}
+		defer st.SetString(ctx, arg, "v1.0.0")
+
+		second, err := cache.Key(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if first == second {
+			t.Fatal("expected keys to be different")
+		}
+	})
+
+	t.Run("It should return a different key if a key has changed", func(t *testing.T) {
+		content = []byte("b")
+		defer func() { content = []byte("a") }()
+
+		second, err := cache.Key(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if first == second {
+			t.Fatal("expected keys to be different")
+		}
+	})
+}
+
+func TestStepCacheKey(t *testing.T) {
+	var (
+		ctx = context.Background()
+		dir = t.TempDir()
+	)
+
+	if err := os.WriteFile(filepath.Join(dir, "go.sum"), []byte("a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := pipeline.CacheKeyOpts{Dir: dir, State: newTestState(t)}
+	key := func(t *testing.T, step pipeline.Step) string {
+		t.Helper()
+		k, err := step.CacheOutputs().Key(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return k
+	}
+
+	t.Run("It should return different keys for steps with the same inputs and no outputs", func(t *testing.T) {
+		var (
+			vet  = pipeline.NamedStep("vet", pipeline.DefaultAction).WithCache(pipeline.CacheKeys(fs.FileHasChanged("go.sum")))
+			test = pipeline.NamedStep("test", pipeline.DefaultAction).WithCache(pipeline.CacheKeys(fs.FileHasChanged("go.sum")))
+		)
+
+		if key(t, vet) == key(t, test) {
+			t.Fatal("expected keys to be different")
+		}
+	})
+
+	t.Run("It should use the name of the cache instead of the name of the step if it has one", func(t *testing.T) {
+		var (
+			vet  = pipeline.NamedStep("vet", pipeline.DefaultAction).WithCache(pipeline.CacheName("go"), pipeline.CacheKeys(fs.FileHasChanged("go.sum")))
+			test = pipeline.NamedStep("test", pipeline.DefaultAction).WithCache(pipeline.CacheName("go"), pipeline.CacheKeys(fs.FileHasChanged("go.sum")))
+		)
+
+		if key(t, vet) != key(t, test) {
+			t.Fatal("expected keys to be equal")
+		}
+	})
+}
+
+func TestCacheStoreWrap(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		arg    = state.NewStringArgument("version")
+		output = filepath.Join(t.TempDir(), "out")
+		store  = pipeline.NewCacheStore(newTestState(t))
+		cache  = pipeline.NewCache(pipeline.CacheArguments(arg), pipeline.CachePaths(output))
+		runs   = 0
+	)
+
+	action := store.Wrap(cache, func(ctx context.Context, opts pipeline.ActionOpts) error {
+		runs++
+		if err := os.MkdirAll(output, 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(output, "a.txt"), []byte("content"), 0644); err != nil {
+			return err
+		}
+
+		return opts.State.SetString(ctx, arg, "v1.0.0")
+	})
+
+	t.Run("It should run the action if nothing has been cached", func(t *testing.T) {
+		if err := action(ctx, pipeline.ActionOpts{State: newTestState(t)}); err != nil {
+			t.Fatal(err)
+		}
+
+		if runs != 1 {
+			t.Fatalf("expected action to run once, but it ran %d times", runs)
+		}
+	})
+
+	t.Run("It should skip the action and restore its outputs if they were cached", func(t *testing.T) {
+		if err := os.RemoveAll(output); err != nil {
+			t.Fatal(err)
+		}
+
+		st := newTestState(t)
+		if err := action(ctx, pipeline.ActionOpts{State: st}); err != nil {
+			t.Fatal(err)
+		}
+
+		if runs != 1 {
+			t.Fatalf("expected action to be skipped, but it ran %d times", runs)
+		}
+
+		b, err := os.ReadFile(filepath.Join(output, "a.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "content" {
+			t.Fatalf("expected restored file to contain 'content', but found '%s'", string(b))
+		}
+
+		v, err := st.GetString(ctx, arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != "v1.0.0" {
+			t.Fatalf("expected restored argument to be 'v1.0.0', but found '%s'", v)
+		}
+	})
+
+	t.Run("It should not remove the files that already exist when restoring a directory", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(output, "b.txt"), []byte("other"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := action(ctx, pipeline.ActionOpts{State: newTestState(t)}); err != nil {
+			t.Fatal(err)
+		}
+
+		if runs != 1 {
+			t.Fatalf("expected action to be skipped, but it ran %d times", runs)
+		}
+
+		if _, err := os.Stat(filepath.Join(output, "b.txt")); err != nil {
+			t.Fatalf("expected existing file to be kept, but received error '%v'", err)
+		}
+	})
+
+	t.Run("It should always run the action if there is no cache store", func(t *testing.T) {
+		var store *pipeline.CacheStore
+		ran := false
+		action := store.Wrap(cache, func(context.Context, pipeline.ActionOpts) error {
+			ran = true
+			return nil
+		})
+
+		if err := action(ctx, pipeline.ActionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if !ran {
+			t.Fatal("expected action to run")
+		}
+	})
+}
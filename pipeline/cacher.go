@@ -1,12 +1,167 @@
 package pipeline
 
-// a CacheCondition should return true if the cacher should cache the step.
-// In other words, returning false means that the step must run.
-type CacheCondition func() bool
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/grafana/scribe/state"
+)
+
+// CacheKeyOpts are provided to a CacheKey when the key of a cached step is calculated.
+type CacheKeyOpts struct {
+	// Dir is the directory that relative paths are resolved from. This is typically the source directory of the project.
+	Dir string
+
+	// State is the state of the pipeline before the step runs.
+	State state.Reader
+}
 
-// A Cacher defines behavior for caching data.
+// A CacheKey returns data that the cache key of a step is derived from, like the checksum of a file or the value of an argument.
+// If the data returned by every CacheKey is the same as in a previous run, then the step is skipped and its outputs are restored from the cache.
+type CacheKey func(ctx context.Context, opts CacheKeyOpts) ([]byte, error)
+
+// A Cache defines what a cached step depends on and what it produces.
 // Some behaviors that can happen when dealing with a cacheable step:
-// * The provided Step, using an expensive process, produces some consistent output, possibly on the filesystem. If nothing changes in between runs, then we can re-use the output in the current step and skip this one.
-//   * The most common example of this is `npm install` producing the `node_modules` folder, which can be re-used if `package-lock.json` is unchanged.
-// * The provided Step, using an expensive process, calculates a value. If nothing changes in between runs, then this value can be reused.
-type Cacher func(Step)
+//   - The provided Step, using an expensive process, produces some consistent output, possibly on the filesystem. If nothing changes in between runs, then we can re-use the output in the current step and skip this one.
+//     The most common example of this is `npm install` producing the `node_modules` folder, which can be re-used if `package-lock.json` is unchanged.
+//   - The provided Step, using an expensive process, calculates a value. If nothing changes in between runs, then this value can be reused.
+type Cache struct {
+	// Name identifies the step that the cache belongs to and is part of the cache key, so that steps with the same inputs do not restore each other's outputs.
+	// 'Step.CacheOutputs' uses the slugified name of the step if it is empty.
+	Name string
+
+	// Keys are the inputs of the step. The cache key is derived from all of them.
+	Keys []CacheKey
+
+	// Arguments are the state arguments that the step sets. They are stored after the step runs and restored into the state when the step is skipped.
+	Arguments state.Arguments
+
+	// Paths are the files and directories that the step creates. They are stored after the step runs and restored to the filesystem when the step is skipped.
+	// Relative paths are relative to the source directory. Environment variables (like '$GOPATH') are expanded where the step runs; the 'cli' client expands them on the host,
+	// and the 'dagger' client expands them inside of the step's container.
+	Paths []string
+}
+
+// A Cacher adds inputs or outputs to a Cache.
+type Cacher func(*Cache)
+
+// A CacheCondition returned true if the cacher should cache the step.
+//
+// Deprecated: CacheCondition was never used by any client. Whether a cached step runs is decided by its cache keys; use a CacheKey, like 'fs.FileHasChanged', with 'CacheKeys' instead.
+type CacheCondition func() bool
+
+// CacheName sets the name of a Cache (see 'Cache.Name').
+func CacheName(name string) Cacher {
+	return func(c *Cache) {
+		c.Name = name
+	}
+}
+
+// CacheKeys adds the provided keys to the inputs of a cached step.
+func CacheKeys(keys ...CacheKey) Cacher {
+	return func(c *Cache) {
+		c.Keys = append(c.Keys, keys...)
+	}
+}
+
+// CacheArguments adds the provided arguments to the outputs of a cached step.
+// The arguments that a step provides (see 'Step.Provides') are always cached, so they do not need to be added.
+func CacheArguments(args ...state.Argument) Cacher {
+	return func(c *Cache) {
+		for _, arg := range args {
+			if !state.ArgListContains(c.Arguments, arg) {
+				c.Arguments = append(c.Arguments, arg)
+			}
+		}
+	}
+}
+
+// CachePaths adds the provided files or directories to the outputs of a cached step.
+func CachePaths(paths ...string) Cacher {
+	return func(c *Cache) {
+		c.Paths = append(c.Paths, paths...)
+	}
+}
+
+// ArgumentKey is a CacheKey that uses the values of the provided arguments.
+// For file and directory arguments, the path is used rather than the contents.
+func ArgumentKey(args ...state.Argument) CacheKey {
+	return func(ctx context.Context, opts CacheKeyOpts) ([]byte, error) {
+		h := sha256.New()
+		for _, arg := range args {
+			value, err := state.GetValueAsString(ctx, opts.State, arg)
+			if err != nil {
+				return nil, fmt.Errorf("error getting value of argument '%s' for cache key: %w", arg.Key, err)
+			}
+
+			writeKeyPart(h, arg.Key)
+			writeKeyPart(h, value)
+		}
+
+		return h.Sum(nil), nil
+	}
+}
+
+// NewCache creates a new Cache with the inputs and outputs added by the provided cachers.
+func NewCache(cachers ...Cacher) *Cache {
+	return (&Cache{}).With(cachers...)
+}
+
+// With returns a copy of the cache with the inputs and outputs added by the provided cachers.
+// It is safe to call on a nil Cache.
+func (c *Cache) With(cachers ...Cacher) *Cache {
+	v := &Cache{}
+	if c != nil {
+		v.Name = c.Name
+		v.Keys = append(v.Keys, c.Keys...)
+		v.Arguments = append(v.Arguments, c.Arguments...)
+		v.Paths = append(v.Paths, c.Paths...)
+	}
+
+	for _, cacher := range cachers {
+		cacher(v)
+	}
+
+	return v
+}
+
+// Key calculates the cache key from the name, inputs and outputs of the cache.
+// Two caches with the same key are considered to produce the same outputs.
+func (c *Cache) Key(ctx context.Context, opts CacheKeyOpts) (string, error) {
+	h := sha256.New()
+	writeKeyPart(h, c.Name)
+
+	for i, key := range c.Keys {
+		b, err := key(ctx, opts)
+		if err != nil {
+			return "", fmt.Errorf("error calculating cache key %d: %w", i, err)
+		}
+
+		writeKeyPart(h, string(b))
+	}
+
+	args := make([]string, len(c.Arguments))
+	for i, arg := range c.Arguments {
+		args[i] = fmt.Sprintf("%s:%s", arg.Type, arg.Key)
+	}
+	sort.Strings(args)
+
+	paths := make([]string, len(c.Paths))
+	copy(paths, c.Paths)
+	sort.Strings(paths)
+
+	for _, v := range append(args, paths...) {
+		writeKeyPart(h, v)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeKeyPart writes the value prefixed by its length so that the boundaries between parts of the key are preserved.
+func writeKeyPart(w io.Writer, value string) {
+	fmt.Fprintf(w, "%d:%s", len(value), value)
+}
@@ -6,78 +6,210 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/grafana/scribe/pipeline"
 	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipeline/dag"
 	"github.com/grafana/scribe/state"
 	"github.com/grafana/scribe/syncutil"
 	"github.com/grafana/scribe/wrappers"
 	"github.com/sirupsen/logrus"
 )
 
-// The Client is used when interacting with a scribe pipeline using the scribe CLI. It is used to run only one step.
+// The Client is used when interacting with a scribe pipeline using the scribe CLI. It is used to run only one step ('--step'), or every step in the selected pipelines ('--pipeline').
 // The CLI client simply runs the anonymous function defined in the step.
+// The step's environment is given to the action in 'ActionOpts.Env'. It is only set in the environment of the process when a single step is ran with '--step', as the steps that are ran with '--pipeline' share the process.
 type Client struct {
 	Opts  clients.CommonOpts
 	Log   *logrus.Logger
 	State *StateWrapper
+
+	// Cache stores the outputs of cached steps. If it is nil, then cached steps always run.
+	Cache *pipeline.CacheStore
 }
 
 func New(ctx context.Context, opts clients.CommonOpts) (pipeline.Client, error) {
-	if opts.Args.Step == nil || *opts.Args.Step == 0 {
-		return nil, errors.New("--step argument can not be empty or 0 when using the CLI client")
+	if (opts.Args.Step == nil || *opts.Args.Step == 0) && len(opts.Args.PipelineName) == 0 {
+		return nil, errors.New("either the --step or the --pipeline argument must be provided when using the CLI client")
+	}
+
+	cache, err := pipeline.NewCacheStoreFromArgs(ctx, opts.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	// The state is read from and written to the '--state' URL, so that steps that are ran by separate CLI client processes, like the steps in a Drone pipeline, share their values.
+	// Values that are not in the state are read from the '--arg' flags.
+	// The CLI client runs steps in CI services and containers, where there is no one to prompt for the values that are missing.
+	pargs := *opts.Args
+	pargs.CanStdinPrompt = false
+
+	s, err := state.NewDefaultState(ctx, opts.Log, &pargs)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Client{
-		Opts: opts,
-		Log:  opts.Log,
-		State: NewStateWrapper(
-			state.ReaderWithLogs(opts.Log, state.NewArgMapReader(opts.Args.ArgMap)),
-			&StateHandler{},
-		),
+		Opts:  opts,
+		Log:   opts.Log,
+		State: NewStateWrapper(s, s),
+		Cache: cache,
 	}, nil
 }
 
-// PipelineWalkFunc walks through the pipelines that the collection provides. Each pipeline is a pipeline of steps, so each will walk through the list of steps using the StepWalkFunc.
+// HandlePipeline runs the steps in the pipeline. Each step is started as soon as the steps that it depends on have completed.
+// If the pipeline has a timeout, then every step that is still running is cancelled once it has passed.
 func (c *Client) HandlePipeline(ctx context.Context, p pipeline.Pipeline) error {
-	var (
-		wg = syncutil.NewStepWaitGroup()
-	)
+	scheduler := syncutil.NewStepScheduler(p, c.Opts.Args.MaxConcurrency, syncutil.ModeFromArgs(c.Opts.Args))
 
+	err := pipeline.RunWithTimeout(ctx, p.Timeout, func(ctx context.Context) error {
+		stop, err := c.startBackground(ctx, p)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		return scheduler.Run(ctx, func(ctx context.Context, node *dag.Node[pipeline.Step]) error {
+			// Skip the root step that's always present on every pipeline.
+			if node.ID == 0 {
+				return nil
+			}
+
+			// Background steps run next to the other steps, so the steps that depend on them do not wait for them to complete.
+			if node.Value.IsBackground() && c.Opts.Args.Step == nil {
+				return nil
+			}
+
+			return c.runStep(ctx, node.Value)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(c.State.data); err != nil {
+		return fmt.Errorf("error encoding JSON for CLI client state updates: %w", err)
+	}
+
+	return nil
+}
+
+// startBackground starts the background steps in the pipeline when it is ran with '--pipeline'. The steps keep running until the returned function is called, which stops them and waits for them to return.
+// When a step is selected with '--step', the client that runs the pipeline, like Drone, has already started the background steps, so nothing is started.
+// A background step that fails while the other steps are running does not stop the pipeline, like a detached step in Drone.
+func (c *Client) startBackground(ctx context.Context, p pipeline.Pipeline) (func(), error) {
+	if c.Opts.Args.Step != nil {
+		return func() {}, nil
+	}
+
+	steps := []pipeline.Step{}
 	for _, node := range p.Graph.Nodes {
-		// Skip the root step that's always present on every pipeline.
-		if node.ID == 0 {
+		if node.ID == 0 || !node.Value.IsBackground() {
 			continue
 		}
-		log := c.Opts.Log
-		logWrapper := &wrappers.LogWrapper{
-			Opts: c.Opts,
-			Log:  log.WithField("step", node.Value.Name),
+
+		// Background steps without an action run the default command of their image, which requires a container.
+		if node.Value.Action == nil {
+			return nil, fmt.Errorf("background step '%s' has no action; the cli client can not run the default command of its image", node.Value.Name)
 		}
-		traceWrapper := &wrappers.TraceWrapper{
+
+		steps = append(steps, node.Value)
+	}
+
+	var (
+		bctx, cancel = context.WithCancel(ctx)
+		wg           = &sync.WaitGroup{}
+	)
+
+	for _, step := range steps {
+		wg.Add(1)
+		go func(step pipeline.Step) {
+			defer wg.Done()
+			if err := c.runStep(bctx, step); err != nil && bctx.Err() == nil {
+				c.Opts.Log.WithField("step", step.Name).WithError(err).Warnln("background step failed")
+			}
+		}(step)
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}, nil
+}
+
+// setEnv sets the variables in 'env', which are in the 'KEY=value' format, in the environment of the process.
+func setEnv(env []string) error {
+	for _, v := range env {
+		key, value, _ := strings.Cut(v, "=")
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("error setting environment variable '%s': %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// runStep runs the action of the step if its conditions match.
+func (c *Client) runStep(ctx context.Context, step pipeline.Step) error {
+	var (
+		log          = c.Opts.Log
+		stepLog      = log.WithField("step", step.Name)
+		name         = step.Name
+		traceWrapper = &wrappers.TraceWrapper{
 			Opts:   c.Opts,
 			Tracer: c.Opts.Tracer,
 		}
+		logWrapper = &wrappers.LogWrapper{
+			Opts: c.Opts,
+			Log:  stepLog,
+		}
+	)
 
-		step := logWrapper.WrapStep(node.Value)
-		step = traceWrapper.WrapStep(step)
+	// Skipped steps return without an error so that the steps that depend on them still run.
+	ok, err := step.ShouldRun(ctx, c.State)
+	if err != nil {
+		return fmt.Errorf("step '%s': error evaluating conditions: %w", name, err)
+	}
+	if !ok {
+		stepLog.Infoln("skipping step because its conditions did not match")
+		return nil
+	}
 
-		// Otherwise, add this pipeline to the set that needs to complete before moving on to the next set of pipelines.
-		wg.Add(step, pipeline.ActionOpts{
-			Path:    c.Opts.Args.Path,
-			State:   c.State,
-			Tracer:  c.Opts.Tracer,
-			Version: c.Opts.Version,
-			Logger:  log,
-		})
+	env, err := step.Environment.Environ(ctx, c.State)
+	if err != nil {
+		return fmt.Errorf("step '%s': %w", name, err)
 	}
 
-	if err := wg.Wait(ctx); err != nil {
-		return err
+	// When only one step is ran, like in a Drone step or a Dagger container, the process belongs to the step, so its environment is set like it is in those containers.
+	// Steps that are ran with '--pipeline' share the process, so the environment is only given to the action.
+	if c.Opts.Args.Step != nil {
+		if err := setEnv(env); err != nil {
+			return fmt.Errorf("step '%s': %w", name, err)
+		}
 	}
 
-	if err := json.NewEncoder(os.Stdout).Encode(c.State.data); err != nil {
-		return fmt.Errorf("error encoding JSON for CLI client state updates: %w", err)
+	// Cached steps are skipped entirely (including retries) if their outputs were restored.
+	step.Action = c.Cache.Wrap(step.CacheOutputs(), pipeline.TimeoutAction(step.Timeout, step.Retry.Wrap(step.Action)))
+
+	step = logWrapper.WrapStep(step)
+	step = traceWrapper.WrapStep(step)
+
+	if err := step.Action(ctx, pipeline.ActionOpts{
+		Path:    c.Opts.Args.Path,
+		State:   c.State,
+		Tracer:  c.Opts.Tracer,
+		Version: c.Opts.Version,
+		Logger:  log,
+		Env:     env,
+	}); err != nil {
+		if step.AllowFailure {
+			stepLog.WithError(err).Warnln("step failed but is allowed to fail")
+			return nil
+		}
+
+		return fmt.Errorf("step '%s': %w", name, err)
 	}
 
 	return nil
@@ -98,7 +230,7 @@ func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
 			continue
 		}
 		pipeline := node.Value
-		// Not counting the root pipeline, there should really only be 1 pipeline here with 1 step since we've filtered by step ID.
+		// Not counting the root pipeline, these are the pipelines selected with '--pipeline', or the pipeline of the step selected with '--step'.
 		if err := c.HandlePipeline(ctx, pipeline); err != nil {
 			return err
 		}
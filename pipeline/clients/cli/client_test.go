@@ -0,0 +1,324 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/exec"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipeline/clients/cli"
+	"github.com/grafana/scribe/state"
+	"github.com/grafana/scribe/testutil"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+)
+
+// runEnvironmentStep runs a step with the environment 'GOOS=plan9' and 'VERSION=v1.0.0' using the provided arguments.
+// It returns what a command that the step ran printed, and the value of 'GOOS' in the environment of the process while the step ran.
+func runEnvironmentStep(t *testing.T, pargs *args.PipelineArgs) (string, string) {
+	t.Helper()
+
+	var (
+		log     = logrus.New()
+		tracer  = &opentracing.NoopTracer{}
+		ctx     = opentracing.ContextWithSpan(context.Background(), tracer.StartSpan("test"))
+		version = state.NewStringArgument("version")
+		stdout  = &bytes.Buffer{}
+		goos    string
+	)
+
+	client := &cli.Client{
+		Opts: clients.CommonOpts{
+			Log:    log,
+			Tracer: tracer,
+			Args:   pargs,
+		},
+		Log:   log,
+		State: cli.NewStateWrapper(state.NewArgMapReader(args.ArgMap{"version": "v1.0.0"}), &cli.StateHandler{}),
+	}
+
+	step := pipeline.NamedStep("test", func(ctx context.Context, opts pipeline.ActionOpts) error {
+		goos = os.Getenv("GOOS")
+		opts.Stdout = stdout
+		return exec.Run(ctx, opts, "sh", "-c", "echo $GOOS $VERSION")
+	}).WithEnvVar("GOOS", pipeline.NewEnvString("plan9")).WithEnvVar("VERSION", pipeline.NewEnvArgument(version))
+	step.ID = 1
+
+	col, err := pipeline.NewCollectionWithSteps("test", step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restores the environment of the process once the test is done.
+	t.Setenv("GOOS", "linux")
+	if err := client.Done(ctx, col); err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.TrimSpace(stdout.String()), goos
+}
+
+func TestClientEnvironment(t *testing.T) {
+	t.Run("It should set the step environment in the process when a single step is ran", func(t *testing.T) {
+		id := int64(1)
+		out, goos := runEnvironmentStep(t, &args.PipelineArgs{
+			Step: &id,
+		})
+
+		if out != "plan9 v1.0.0" {
+			t.Fatalf("Expected the command to print 'plan9 v1.0.0' but received '%s'", out)
+		}
+
+		if goos != "plan9" {
+			t.Fatalf("Expected the process environment to have 'GOOS=plan9' while the step runs but received '%s'", goos)
+		}
+	})
+
+	t.Run("It should add the step environment to the commands that the step runs without changing the process environment when a pipeline is ran", func(t *testing.T) {
+		out, goos := runEnvironmentStep(t, &args.PipelineArgs{
+			PipelineName: []string{"test"},
+		})
+
+		if out != "plan9 v1.0.0" {
+			t.Fatalf("Expected the command to print 'plan9 v1.0.0' but received '%s'", out)
+		}
+
+		if goos != "linux" {
+			t.Fatalf("Expected the process environment to keep 'GOOS=linux' while the step runs but received '%s'", goos)
+		}
+	})
+
+	t.Run("It should return an error if an argument in the environment is not in the state", func(t *testing.T) {
+		var (
+			log    = logrus.New()
+			tracer = &opentracing.NoopTracer{}
+			ctx    = opentracing.ContextWithSpan(context.Background(), tracer.StartSpan("test"))
+			id     = int64(1)
+		)
+
+		client := &cli.Client{
+			Opts: clients.CommonOpts{
+				Log:    log,
+				Tracer: tracer,
+				Args: &args.PipelineArgs{
+					Step: &id,
+				},
+			},
+			Log:   log,
+			State: cli.NewStateWrapper(state.NewArgMapReader(args.ArgMap{}), &cli.StateHandler{}),
+		}
+
+		step := pipeline.NamedStep("test", pipeline.NoOpStep.Action).WithEnvVar("VERSION", pipeline.NewEnvArgument(state.NewStringArgument("version")))
+		step.ID = id
+
+		col, err := pipeline.NewCollectionWithSteps("test", step)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.Done(ctx, col); err == nil {
+			t.Fatal("Expected an error but received none")
+		}
+	})
+}
+
+func TestClientState(t *testing.T) {
+	t.Run("It should share the values that a step provides with steps that are ran by another CLI client", func(t *testing.T) {
+		var (
+			log      = logrus.New()
+			tracer   = &opentracing.NoopTracer{}
+			ctx      = opentracing.ContextWithSpan(context.Background(), tracer.StartSpan("test"))
+			version  = state.NewStringArgument("version")
+			stateURL = &url.URL{
+				Scheme: "file",
+				Path:   t.TempDir(),
+			}
+			value string
+		)
+
+		steps := []pipeline.Step{
+			pipeline.NamedStep("version", func(ctx context.Context, opts pipeline.ActionOpts) error {
+				return opts.State.SetString(ctx, version, "v1.0.0")
+			}).Provides(version),
+			pipeline.NamedStep("publish", func(ctx context.Context, opts pipeline.ActionOpts) error {
+				v, err := opts.State.GetString(ctx, version)
+				value = v
+				return err
+			}).Requires(version),
+		}
+
+		// Every step is ran by a new client, like the 'scribe --step={id} --client cli' commands in every Drone step.
+		for i, step := range steps {
+			id := int64(i + 1)
+			step.ID = id
+
+			client, err := cli.New(ctx, clients.CommonOpts{
+				Log:    log,
+				Tracer: tracer,
+				Args: &args.PipelineArgs{
+					Step:  &id,
+					State: stateURL.String(),
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			col, err := pipeline.NewCollectionWithSteps("test", step)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := client.Done(ctx, col); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if value != "v1.0.0" {
+			t.Fatalf("Expected the 'publish' step to read 'v1.0.0' but received '%s'", value)
+		}
+	})
+}
+
+func TestClientBackground(t *testing.T) {
+	newClient := func() *cli.Client {
+		log := logrus.New()
+		return &cli.Client{
+			Opts: clients.CommonOpts{
+				Log:    log,
+				Tracer: &opentracing.NoopTracer{},
+				Args: &args.PipelineArgs{
+					PipelineName: []string{"test"},
+				},
+			},
+			Log:   log,
+			State: cli.NewStateWrapper(state.NewArgMapReader(args.ArgMap{}), &cli.StateHandler{}),
+		}
+	}
+
+	t.Run("It should run background steps next to the other steps and stop them once the other steps are done", func(t *testing.T) {
+		var (
+			tracer  = &opentracing.NoopTracer{}
+			ctx     = opentracing.ContextWithSpan(context.Background(), tracer.StartSpan("test"))
+			ready   = make(chan struct{})
+			stopped bool
+		)
+
+		service := pipeline.NamedStep("service", func(ctx context.Context, opts pipeline.ActionOpts) error {
+			close(ready)
+			<-ctx.Done()
+			stopped = true
+			return ctx.Err()
+		})
+		service.ID = 1
+		service.Type = pipeline.StepTypeBackground
+
+		test := pipeline.NamedStep("test", func(ctx context.Context, opts pipeline.ActionOpts) error {
+			select {
+			case <-ready:
+				return nil
+			case <-time.After(5 * time.Second):
+				return errors.New("the background step was not started")
+			}
+		})
+		test.ID = 2
+
+		col, err := pipeline.NewCollectionWithSteps("test", service, test)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := newClient().Done(ctx, col); err != nil {
+			t.Fatal(err)
+		}
+
+		if !stopped {
+			t.Fatal("Expected the background step to be stopped once the other steps were done")
+		}
+	})
+
+	t.Run("It should return an error if a background step has no action", func(t *testing.T) {
+		var (
+			tracer = &opentracing.NoopTracer{}
+			ctx    = opentracing.ContextWithSpan(context.Background(), tracer.StartSpan("test"))
+		)
+
+		service := pipeline.NamedStep("redis", nil).WithImage("redis:6")
+		service.ID = 1
+		service.Type = pipeline.StepTypeBackground
+
+		col, err := pipeline.NewCollectionWithSteps("test", service)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := newClient().Done(ctx, col); err == nil {
+			t.Fatal("Expected an error but received none")
+		}
+	})
+}
+
+func TestClientTimeout(t *testing.T) {
+	t.Run("It should complete the steps that always run after the pipeline times out", testutil.WithTimeout(time.Second*5, func(t *testing.T) {
+		var (
+			log       = logrus.New()
+			tracer    = &opentracing.NoopTracer{}
+			ctx       = opentracing.ContextWithSpan(context.Background(), tracer.StartSpan("test"))
+			built     = state.NewBoolArgument("built")
+			completed bool
+		)
+
+		client := &cli.Client{
+			Opts: clients.CommonOpts{
+				Log:    log,
+				Tracer: tracer,
+				Args: &args.PipelineArgs{
+					PipelineName: []string{"test"},
+				},
+			},
+			Log:   log,
+			State: cli.NewStateWrapper(state.NewArgMapReader(args.ArgMap{}), &cli.StateHandler{}),
+		}
+
+		build := pipeline.NamedStep("build", func(ctx context.Context, opts pipeline.ActionOpts) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}).Provides(built)
+		build.ID = 2
+
+		cleanup := pipeline.NamedStep("cleanup", func(ctx context.Context, opts pipeline.ActionOpts) error {
+			time.Sleep(time.Millisecond * 50)
+			completed = true
+			return nil
+		}).Requires(built).WithAlwaysRun()
+		cleanup.ID = 3
+
+		col, err := pipeline.NewCollectionWithSteps("test", build, cleanup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := col.BuildEdges(log, pipeline.ClientProvidedArguments...); err != nil {
+			t.Fatal(err)
+		}
+
+		node, err := col.Graph.Node(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = client.HandlePipeline(ctx, node.Value.WithTimeout(time.Millisecond*50))
+		testutil.EnsureError(t, err, pipeline.ErrorTimeout)
+
+		if !completed {
+			t.Fatal("Expected the step that always runs to complete before the pipeline returned")
+		}
+	}))
+}
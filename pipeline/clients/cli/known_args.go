@@ -37,14 +37,14 @@ func setCurrentCommit(ctx context.Context, s state.Writer) error {
 	return s.SetString(ctx, pipeline.ArgumentCommitRef, string(v))
 }
 
-// This function effectively runs 'git rev-parse --abrev-ref HEAD'
+// This function effectively runs 'git rev-parse --abbrev-ref HEAD'
 func setCurrentBranch(ctx context.Context, s state.Writer) error {
-	v, err := exec.Command("git", "rev-parse", "--abrev-ref", "HEAD").CombinedOutput()
+	v, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("%w. output: %s", err, string(v))
 	}
 
-	return s.SetString(ctx, pipeline.ArgumentBranch, string(v))
+	return s.SetString(ctx, pipeline.ArgumentBranch, strings.TrimSpace(string(v)))
 }
 
 func setWorkingDir(ctx context.Context, s state.Writer) error {
@@ -5,6 +5,7 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"sync"
 
 	"github.com/grafana/scribe/state"
 )
@@ -13,94 +14,122 @@ type StateWrapper struct {
 	state.Reader
 	state.Writer
 	data map[string]state.StateValueJSON
+
+	// mtx guards 'data', because the steps in a pipeline can run at the same time when the client runs a whole pipeline.
+	mtx sync.Mutex
 }
 
-func (w *StateWrapper) SetString(ctx context.Context, key state.Argument, val string) error {
+// record stores the value that was set for the argument so that it can be read by the steps that run after it, and so that it is included in the state updates.
+func (w *StateWrapper) record(key state.Argument, val any) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
 	w.data[key.Key] = state.StateValueJSON{
 		Argument: key,
 		Value:    val,
 	}
+}
+
+func (w *StateWrapper) SetString(ctx context.Context, key state.Argument, val string) error {
+	w.record(key, val)
 	return w.Writer.SetString(ctx, key, val)
 }
 
 func (w *StateWrapper) SetInt64(ctx context.Context, key state.Argument, val int64) error {
-	w.data[key.Key] = state.StateValueJSON{
-		Argument: key,
-		Value:    val,
-	}
+	w.record(key, val)
 	return w.Writer.SetInt64(ctx, key, val)
 }
 
 func (w *StateWrapper) SetFloat64(ctx context.Context, key state.Argument, val float64) error {
-	w.data[key.Key] = state.StateValueJSON{
-		Argument: key,
-		Value:    val,
-	}
+	w.record(key, val)
 	return w.Writer.SetFloat64(ctx, key, val)
 }
 
 func (w *StateWrapper) SetBool(ctx context.Context, key state.Argument, val bool) error {
-	w.data[key.Key] = state.StateValueJSON{
-		Argument: key,
-		Value:    val,
-	}
+	w.record(key, val)
 	return w.Writer.SetBool(ctx, key, val)
 }
 
 func (w *StateWrapper) SetFile(ctx context.Context, key state.Argument, val string) error {
-	w.data[key.Key] = state.StateValueJSON{
-		Argument: key,
-		Value:    val,
-	}
+	w.record(key, val)
 	return w.Writer.SetFile(ctx, key, val)
 }
 
 func (w *StateWrapper) SetFileReader(ctx context.Context, key state.Argument, r io.Reader) (string, error) {
 	path, err := w.Writer.SetFileReader(ctx, key, r)
-	w.data[key.Key] = state.StateValueJSON{
-		Argument: key,
-		Value:    path,
-	}
+	w.record(key, path)
 	return path, err
 }
 
 func (w *StateWrapper) SetDirectory(ctx context.Context, key state.Argument, val string) error {
-	w.data[key.Key] = state.StateValueJSON{
-		Argument: key,
-		Value:    val,
-	}
+	w.record(key, val)
 	return w.Writer.SetDirectory(ctx, key, val)
 }
 
+// updated returns the value that was set for the argument while running the step, if there is one and it has the type 'T'.
+// This allows a step to read the arguments that it or a previous step in the same process provided.
+// If the value has a different type (like when an argument is set and read with different methods), then it is read from the wrapped Reader instead.
+func updated[T any](w *StateWrapper, arg state.Argument) (T, bool) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	v, ok := w.data[arg.Key].Value.(T)
+	return v, ok
+}
+
 func (w *StateWrapper) Exists(ctx context.Context, arg state.Argument) (bool, error) {
+	if _, ok := updated[any](w, arg); ok {
+		return true, nil
+	}
 	return w.Reader.Exists(ctx, arg)
 }
 
 func (w *StateWrapper) GetString(ctx context.Context, arg state.Argument) (string, error) {
+	if v, ok := updated[string](w, arg); ok {
+		return v, nil
+	}
 	return w.Reader.GetString(ctx, arg)
 }
 
 func (w *StateWrapper) GetInt64(ctx context.Context, arg state.Argument) (int64, error) {
+	if v, ok := updated[int64](w, arg); ok {
+		return v, nil
+	}
 	return w.Reader.GetInt64(ctx, arg)
 }
 
 func (w *StateWrapper) GetFloat64(ctx context.Context, arg state.Argument) (float64, error) {
+	if v, ok := updated[float64](w, arg); ok {
+		return v, nil
+	}
 	return w.Reader.GetFloat64(ctx, arg)
 }
 
 func (w *StateWrapper) GetBool(ctx context.Context, arg state.Argument) (bool, error) {
+	if v, ok := updated[bool](w, arg); ok {
+		return v, nil
+	}
 	return w.Reader.GetBool(ctx, arg)
 }
 
 func (w *StateWrapper) GetFile(ctx context.Context, arg state.Argument) (*os.File, error) {
+	if v, ok := updated[string](w, arg); ok {
+		return os.Open(v)
+	}
 	return w.Reader.GetFile(ctx, arg)
 }
 
 func (w *StateWrapper) GetDirectory(ctx context.Context, arg state.Argument) (fs.FS, error) {
+	if v, ok := updated[string](w, arg); ok {
+		return os.DirFS(v), nil
+	}
 	return w.Reader.GetDirectory(ctx, arg)
 }
 
 func (w *StateWrapper) GetDirectoryString(ctx context.Context, arg state.Argument) (string, error) {
+	if v, ok := updated[string](w, arg); ok {
+		return v, nil
+	}
 	return w.Reader.GetDirectoryString(ctx, arg)
 }
 
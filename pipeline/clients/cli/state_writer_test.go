@@ -0,0 +1,42 @@
+package cli_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/pipeline/clients/cli"
+	"github.com/grafana/scribe/state"
+)
+
+func TestStateWrapper(t *testing.T) {
+	var (
+		ctx = context.Background()
+		arg = state.NewStringArgument("count")
+	)
+
+	w := cli.NewStateWrapper(state.NewArgMapReader(args.ArgMap{"count": "2"}), &cli.StateHandler{})
+	if err := w.SetString(ctx, arg, "two"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("It should return the value that was set", func(t *testing.T) {
+		v, err := w.GetString(ctx, arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != "two" {
+			t.Fatalf("Expected 'two' but received '%s'", v)
+		}
+	})
+
+	t.Run("It should read from the wrapped reader if the value that was set has a different type", func(t *testing.T) {
+		v, err := w.GetInt64(ctx, arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != 2 {
+			t.Fatalf("Expected 2 but received %d", v)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package common
+
+import (
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/dag"
+	"github.com/grafana/scribe/state"
+)
+
+type edgeKey struct {
+	from int64
+	to   int64
+}
+
+// UniqueEdges returns the edges of the graph in the order that their nodes were added.
+// A node can have more than one edge to the same node if it provides more than one of its arguments, so only the first of those edges is returned.
+func UniqueEdges[T any](g *dag.Graph[T]) []dag.Edge[T] {
+	var (
+		edges = []dag.Edge[T]{}
+		seen  = map[edgeKey]bool{}
+	)
+
+	for _, node := range g.Nodes {
+		for _, e := range g.Edges[node.ID] {
+			key := edgeKey{from: e.From.ID, to: e.To.ID}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			edges = append(edges, e)
+		}
+	}
+
+	return edges
+}
+
+// LinkedEdge is an edge between two steps or pipelines with the arguments that link them.
+type LinkedEdge[T any] struct {
+	dag.Edge[T]
+	Args state.Arguments
+}
+
+// linkedEdges returns the unique edges of the graph (see 'UniqueEdges') with the arguments that link their nodes. 'provided' and 'required' return the arguments of a node.
+func linkedEdges[T any](g *dag.Graph[T], provided, required func(T) state.Arguments) []LinkedEdge[T] {
+	edges := []LinkedEdge[T]{}
+	for _, e := range UniqueEdges(g) {
+		edges = append(edges, LinkedEdge[T]{
+			Edge: e,
+			Args: LinkingArgs(provided(e.From.Value), required(e.To.Value)),
+		})
+	}
+
+	return edges
+}
+
+// StepEdges returns the unique edges between the steps in a pipeline's graph with the arguments that link them.
+func StepEdges(g *dag.Graph[pipeline.Step]) []LinkedEdge[pipeline.Step] {
+	return linkedEdges(g,
+		func(s pipeline.Step) state.Arguments { return s.ProvidedArgs },
+		func(s pipeline.Step) state.Arguments { return s.RequiredArgs },
+	)
+}
+
+// PipelineEdges returns the unique edges between the pipelines in a collection's graph with the arguments that link them.
+func PipelineEdges(g *dag.Graph[pipeline.Pipeline]) []LinkedEdge[pipeline.Pipeline] {
+	return linkedEdges(g,
+		func(p pipeline.Pipeline) state.Arguments { return p.ProvidedArgs },
+		func(p pipeline.Pipeline) state.Arguments { return p.RequiredArgs },
+	)
+}
+
+// WithoutRoot returns the edges that do not start at the root node, which has no step or pipeline.
+func WithoutRoot[T any](edges []LinkedEdge[T]) []LinkedEdge[T] {
+	e := []LinkedEdge[T]{}
+	for _, v := range edges {
+		if v.From.ID == 0 {
+			continue
+		}
+		e = append(e, v)
+	}
+
+	return e
+}
+
+// LinkingArgs returns the arguments in 'required' that are also in 'provided'. These are the arguments that create an edge between two steps or pipelines.
+func LinkingArgs(provided, required state.Arguments) state.Arguments {
+	args := state.Arguments{}
+	for _, arg := range required {
+		if state.ArgListContains(provided, arg) {
+			args = append(args, arg)
+		}
+	}
+
+	return args
+}
+
+// ArgKeys returns the keys of every argument in the list.
+func ArgKeys(args state.Arguments) []string {
+	keys := make([]string, len(args))
+	for i, v := range args {
+		keys[i] = v.Key
+	}
+
+	return keys
+}
@@ -0,0 +1,304 @@
+package dagger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"dagger.io/dagger"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/sirupsen/logrus"
+)
+
+// sourceDir returns the directory on the host that is mounted into every container. Relative cache keys and paths are resolved from it.
+func (c *Client) sourceDir(ctx context.Context) (string, error) {
+	return c.State.GetDirectoryString(ctx, pipeline.ArgumentSourceFS)
+}
+
+// restoreCache restores the outputs of a cached step from the cache store into the source directory on the host and into the state. The paths must have already been resolved with resolveCache.
+// It returns the cache key, which is empty if the step is not cached, and true if the outputs were restored and the step can be skipped.
+func (c *Client) restoreCache(ctx context.Context, log logrus.FieldLogger, cache *pipeline.Cache) (string, bool) {
+	if c.Cache == nil || cache == nil {
+		return "", false
+	}
+
+	dir, err := c.sourceDir(ctx)
+	if err != nil {
+		log.WithError(err).Warnln("error getting source directory; running step without the cache")
+		return "", false
+	}
+
+	key, err := cache.Key(ctx, pipeline.CacheKeyOpts{Dir: dir, State: c.State})
+	if err != nil {
+		log.WithError(err).Warnln("error calculating cache key; running step without the cache")
+		return "", false
+	}
+
+	ok, err := c.Cache.Restore(ctx, key, cache, dir, c.State)
+	if err != nil {
+		log.WithError(err).Warnln("error restoring step outputs from cache; running step")
+		return key, false
+	}
+
+	return key, ok
+}
+
+// saveCache stores the outputs of a cached step that ran. The paths must have already been exported from the container with exportCachePaths.
+func (c *Client) saveCache(ctx context.Context, log logrus.FieldLogger, key string, cache *pipeline.Cache) {
+	if key == "" {
+		return
+	}
+
+	dir, err := c.sourceDir(ctx)
+	if err != nil {
+		log.WithError(err).Warnln("error getting source directory; step outputs will not be cached")
+		return
+	}
+
+	if err := c.Cache.Save(ctx, key, cache, dir, c.State); err != nil {
+		log.WithError(err).Warnln("error storing step outputs in cache")
+	}
+}
+
+const (
+	// Workdir is the directory in every step's container that the source directory is mounted to.
+	Workdir = "/var/scribe"
+
+	// PipelineDir is the directory in every step's container that the compiled pipeline is mounted to.
+	PipelineDir = "/opt/scribe"
+)
+
+// CachePath returns the path of a cached file or directory after expanding the environment variables in it with 'getenv'.
+// Paths in the working directory of the container ('Workdir'), which the source directory is mounted to, are returned relative to it.
+// Other absolute paths (like '$GOPATH/pkg') are returned as they are; they are stored on the host separately and mounted into the containers of later steps (see 'Mounts').
+// Relative paths that are not in the working directory (like '../node_modules') and absolute paths that overlap a directory that scribe mounts into the container return an error.
+func CachePath(p string, getenv func(string) string) (string, error) {
+	expanded := path.Clean(filepath.ToSlash(os.Expand(p, getenv)))
+	if path.IsAbs(expanded) {
+		if expanded == Workdir {
+			return ".", nil
+		}
+		if rel := strings.TrimPrefix(expanded, Workdir+"/"); rel != expanded {
+			return rel, nil
+		}
+		for _, dir := range []string{Workdir, PipelineDir} {
+			if inDir(expanded, dir) || inDir(dir, expanded) {
+				return "", fmt.Errorf("cached path '%s' (%s) overlaps the directory '%s', which is mounted into every container", p, expanded, dir)
+			}
+		}
+
+		return expanded, nil
+	}
+
+	if expanded == ".." || strings.HasPrefix(expanded, "../") {
+		return "", fmt.Errorf("cached path '%s' (%s) is not in the working directory '%s'", p, expanded, Workdir)
+	}
+
+	return expanded, nil
+}
+
+// inDir returns true if the absolute path 'p' is 'dir' or a file or directory in it.
+func inDir(dir, p string) bool {
+	return dir == "/" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// validateCachePaths returns an error if a cached path can not be exported from the step's container.
+// Paths with environment variables are only known once they are expanded in the container, so they are checked when the step runs.
+func validateCachePaths(cache *pipeline.Cache) error {
+	if cache == nil {
+		return nil
+	}
+
+	for _, p := range cache.Paths {
+		if strings.Contains(p, "$") {
+			continue
+		}
+
+		// The path does not have environment variables, so nothing is expanded.
+		if _, err := CachePath(p, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveCache returns a copy of the cache with every path resolved for the cache store. The environment variables in the paths are expanded using the environment of 'container'.
+// Paths in the source directory are relative to it, and other paths are where they are stored on the host by 'c.Mounts'.
+func (c *Client) resolveCache(ctx context.Context, container *dagger.Container, cache *pipeline.Cache) (*pipeline.Cache, error) {
+	var (
+		resolved = cache.With()
+		envErr   error
+	)
+
+	getenv := func(key string) string {
+		v, err := container.EnvVariable(ctx, key)
+		if err != nil && envErr == nil {
+			envErr = fmt.Errorf("error reading environment variable '%s' from container: %w", key, err)
+		}
+		return v
+	}
+
+	for i, p := range cache.Paths {
+		v, err := CachePath(p, getenv)
+		if envErr != nil {
+			return nil, envErr
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if path.IsAbs(v) {
+			host, err := c.Mounts.HostPath(v)
+			if err != nil {
+				return nil, err
+			}
+			v = host
+		}
+
+		resolved.Paths[i] = v
+	}
+
+	return resolved, nil
+}
+
+// mountCachePaths adds the cached paths that are not in the source directory to 'c.Mounts' once they exist on the host, so that the steps that run after this one can use them.
+// The paths must have already been resolved with resolveCache.
+func (c *Client) mountCachePaths(cache *pipeline.Cache) {
+	for _, p := range cache.Paths {
+		if v, ok := c.Mounts.ContainerPath(p); ok {
+			c.Mounts.Add(v)
+		}
+	}
+}
+
+// exportCachePaths exports the cached paths that the step created in its container to the host.
+// The paths must have already been resolved with resolveCache, so they are either relative to the source directory or stored by 'c.Mounts'.
+func (c *Client) exportCachePaths(ctx context.Context, runner *dagger.Container, cache *pipeline.Cache) error {
+	dir, err := c.sourceDir(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range cache.Paths {
+		var (
+			containerPath = path.Join(Workdir, p)
+			hostPath      = filepath.Join(dir, filepath.FromSlash(p))
+		)
+
+		if v, ok := c.Mounts.ContainerPath(p); ok {
+			containerPath = v
+			hostPath = p
+		}
+
+		// The path could be either a file or a directory, and the container does not tell us which without exporting it.
+		if _, err := runner.Directory(containerPath).Export(ctx, hostPath); err != nil {
+			if _, err := runner.File(containerPath).Export(ctx, hostPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.mountCachePaths(cache)
+
+	return nil
+}
+
+// Mounts stores the cached paths that are not in the source directory, like '$GOPATH/pkg', in a temporary directory on the host.
+// Once a step created one of these paths or it was restored from the cache, it is mounted into the containers of the steps that start after that, like the source directory is.
+type Mounts struct {
+	mutex sync.Mutex
+	dir   string
+	paths map[string]bool
+}
+
+// HostPath returns the path on the host that the absolute path 'p' in the container is stored at.
+func (m *Mounts) HostPath(p string) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.dir == "" {
+		dir, err := os.MkdirTemp("", "scribe-cache-")
+		if err != nil {
+			return "", fmt.Errorf("error creating directory for cached paths: %w", err)
+		}
+		m.dir = dir
+	}
+
+	return filepath.Join(m.dir, filepath.FromSlash(p)), nil
+}
+
+// ContainerPath returns the absolute path in the container that is stored at 'hostPath' and true, or false if 'hostPath' was not returned by 'HostPath'.
+func (m *Mounts) ContainerPath(hostPath string) (string, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.dir == "" || !filepath.IsAbs(hostPath) {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(m.dir, hostPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return "/" + filepath.ToSlash(rel), true
+}
+
+// Add mounts the absolute path 'p' into the containers that are created with 'Mount' from now on. It must already exist on the host at 'HostPath(p)'.
+func (m *Mounts) Add(p string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.paths == nil {
+		m.paths = map[string]bool{}
+	}
+
+	m.paths[p] = true
+}
+
+// Mount returns the container with every path that was added mounted into it. Parent directories are mounted before the paths in them.
+func (m *Mounts) Mount(d *dagger.Client, container *dagger.Container) *dagger.Container {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	paths := make([]string, 0, len(m.paths))
+	for p := range m.paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		hostPath := filepath.Join(m.dir, filepath.FromSlash(p))
+		info, err := os.Stat(hostPath)
+		if err != nil {
+			continue
+		}
+
+		if info.IsDir() {
+			container = container.WithMountedDirectory(p, d.Host().Directory(hostPath))
+			continue
+		}
+
+		container = container.WithMountedFile(p, d.Host().Directory(filepath.Dir(hostPath)).File(filepath.Base(hostPath)))
+	}
+
+	return container
+}
+
+// Close removes the cached paths that were stored on the host. They are still in the cache store.
+func (m *Mounts) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.dir == "" {
+		return nil
+	}
+
+	return os.RemoveAll(m.dir)
+}
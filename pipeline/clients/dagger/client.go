@@ -9,23 +9,39 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
-	"time"
 
 	"dagger.io/dagger"
 	"github.com/grafana/scribe/args"
 	"github.com/grafana/scribe/cmdutil"
+	"github.com/grafana/scribe/errors"
+	"github.com/grafana/scribe/history"
 	"github.com/grafana/scribe/pipeline"
 	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipeline/dag"
 	"github.com/grafana/scribe/state"
 	"github.com/grafana/scribe/stringutil"
 	"github.com/grafana/scribe/syncutil"
 	"github.com/sirupsen/logrus"
 )
 
+// ErrorBackground is returned by Validate for background steps. It wraps ErrorSkipValidation, so background steps still run.
+// Background steps are started with their pipeline and stopped once the other steps are done, but the version of the Dagger SDK that is used does not support services,
+// so the other steps can not reach them over the network.
+var ErrorBackground = fmt.Errorf("%w: the dagger client runs background steps next to the other steps, but can not bind them to a hostname; use the 'cli' client or generate a Drone configuration to reach them", errors.ErrorSkipValidation)
+
 type Client struct {
 	Opts  clients.CommonOpts
 	Log   *logrus.Logger
 	State *state.Observer
+
+	// Cache stores the outputs of cached steps. If it is nil, then cached steps always run.
+	Cache *pipeline.CacheStore
+
+	// Mounts stores the cached paths that are not in the source directory and mounts them into the containers of later steps.
+	Mounts *Mounts
+
+	// History records the run so that it can be resumed with the '--resume' flag.
+	History *history.Recorder
 }
 
 // getArgMap builds an argument map to supply to the step.
@@ -58,75 +74,30 @@ func getArgMap(ctx context.Context, r state.Reader, extra map[string]string, req
 }
 
 func New(ctx context.Context, opts clients.CommonOpts) (pipeline.Client, error) {
-	s, err := state.NewDefaultState(ctx, opts.Log, opts.Args)
+	// This must happen before the state is created because a resumed run reuses the state of the previous attempt.
+	recorder, err := newRecorder(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{
-		Opts:  opts,
-		Log:   opts.Log,
-		State: state.NewObserver(s),
-	}, nil
-}
-
-func (c *Client) WaitForArgs(ctx context.Context, log logrus.FieldLogger, args state.Arguments) {
-	log = log.WithField("arguments", args.String())
-	log.Infoln("Checking for argument existence in state before waiting...")
-
-	wait := state.Arguments{}
-	for _, arg := range args {
-		exists, err := c.State.Exists(ctx, arg)
-		// If it does exist and there was no error returned, then we don't need to wait for it.
-		if exists && err == nil {
-			log.Infof("We do not need to wait for '%s'", arg.Key)
-			continue
-		}
-
-		if err != nil {
-			log.WithError(err).Infoln("Got error from state when checking for existence")
-		}
-
-		wait = append(wait, arg)
+	s, err := state.NewDefaultState(ctx, opts.Log, opts.Args)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(wait) == 0 {
-		return
+	cache, err := pipeline.NewCacheStoreFromArgs(ctx, opts.Args)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Infof("Waiting for '%d' arguments...", len(wait))
-	defer log.Infoln("Done waiting for arguments")
-	wg := &sync.WaitGroup{}
-	for _, arg := range wait {
-		wg.Add(1)
-		done := make(chan bool)
-		go func(arg state.Argument, done chan bool) {
-			c.Log.Infof("Waiting for argument '%s'...", arg.Key)
-			ticker := time.NewTicker(time.Second * 5)
-
-			for {
-				select {
-				case <-ticker.C:
-					log.WithField("argument", arg.Key).Infoln("Waiting for argument in state...")
-					// Hack: checking if it exists in state yet if we were somehow not notified.
-					if exists, err := c.State.Exists(ctx, arg); exists && err == nil {
-						log.WithField("argument", arg.Key).Infoln("Argument exists in state now")
-						c.State.CondFor(ctx, arg).Broadcast()
-					}
-				case <-done:
-					log.WithField("argument", arg.Key).Infoln("Argument value received")
-					wg.Done()
-					return
-				}
-			}
-		}(arg, done)
-		cond := c.State.CondFor(ctx, arg)
-		cond.L.Lock()
-		cond.Wait()
-		cond.L.Unlock()
-		done <- true
-	}
-	wg.Wait()
+	return &Client{
+		Opts:    opts,
+		Log:     opts.Log,
+		State:   state.NewObserver(s),
+		Cache:   cache,
+		Mounts:  &Mounts{},
+		History: recorder,
+	}, nil
 }
 
 // HandleRequiredArgs modifies the provided container to account for the arguments provided by and required by the provided step, then returns the modified container.
@@ -178,123 +149,279 @@ func (c *Client) HandleRequiredArgs(ctx context.Context, d *dagger.Client, conta
 	return container, m, nil
 }
 
-func (c *Client) HandleStep(ctx context.Context, step pipeline.Step, d *dagger.Client, wg *syncutil.WaitGroup, bin *dagger.Directory, src *dagger.Directory, path string) error {
-	wg.Add(func(ctx context.Context) error {
-		log := c.Log.WithFields(logrus.Fields{
-			"step": step.Name,
-		})
+// HandleEnvironment adds the step's environment variables to the provided container, then returns the modified container.
+// The values of arguments are read from the state, which already has every argument that the step requires when the step runs.
+func (c *Client) HandleEnvironment(ctx context.Context, container *dagger.Container, step pipeline.Step) (*dagger.Container, error) {
+	env, err := step.Environment.Values(ctx, c.State)
+	if err != nil {
+		return nil, err
+	}
 
-		log.Infoln("Waiting for pipeline arguments before registering step...")
-		c.WaitForArgs(ctx, log, state.Without(step.RequiredArgs, pipeline.ClientProvidedArguments))
-		log.Infoln("Done waiting for pipeline arguments")
+	for _, k := range step.Environment.Keys() {
+		container = container.WithEnvVariable(k, env[k])
+	}
 
-		binPath := "/opt/scribe/pipeline"
-		runner := d.Container().From(step.Image).
-			WithMountedDirectory("/opt/scribe", bin).
-			WithMountedDirectory("/var/scribe", src).
-			WithEntrypoint([]string{}).
-			WithWorkdir("/var/scribe")
+	return container, nil
+}
 
-		r, m, err := c.HandleRequiredArgs(ctx, d, runner, step)
-		if err != nil {
-			return err
+// HandleStep runs the step in a container using the CLI client and stores the state updates that it returns.
+// The scheduler only starts a step after the steps that provide its arguments have completed, so every argument it requires is already in the state.
+func (c *Client) HandleStep(ctx context.Context, step pipeline.Step, d *dagger.Client, bin *dagger.Directory, src *dagger.Directory, path string) error {
+	log := c.Log.WithFields(logrus.Fields{
+		"step": step.Name,
+	})
+
+	binPath := PipelineDir + "/pipeline"
+	runner := d.Container().From(step.Image).
+		WithMountedDirectory(PipelineDir, bin).
+		WithMountedDirectory(Workdir, src).
+		WithEntrypoint([]string{}).
+		WithWorkdir(Workdir)
+
+	runner = c.Mounts.Mount(d, runner)
+
+	r, m, err := c.HandleRequiredArgs(ctx, d, runner, step)
+	if err != nil {
+		return err
+	}
+	runner = r
+
+	runner, err = c.HandleEnvironment(ctx, runner, step)
+	if err != nil {
+		return err
+	}
+
+	argmap, err := getArgMap(ctx, c.State, m, step.RequiredArgs)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range argmap {
+		log.Infoln("ArgMap", k, v)
+	}
+
+	cmd, err := cmdutil.StepCommand(cmdutil.CommandOpts{
+		CompiledPipeline: binPath,
+		Step:             step,
+		PipelineArgs: args.PipelineArgs{
+			Path:   path,
+			ArgMap: argmap,
+			// Cached steps are checked and stored on the host, so the cache is not used again inside of the container.
+			NoCache: true,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Some containers have entrypoints that can make `Exec` inconsistent. This attempts to disable / override that behavior.
+	//runner = runner.WithEntrypoint([]string{})
+	log.WithField("command", strings.Join(cmd, " ")).Infoln("Registering container with command...")
+	runner = runner.WithExec(cmd)
+
+	if stderr, err := runner.Stderr(ctx); err == nil {
+		log.WithField("stream", "stderr").Infoln(stderr)
+	} else {
+		log.Errorln("Failed to get stderr from container. Dagger currently doesn't support streaming stdout/stderr directly; try re-running with `--log-level=debug` for more information")
+	}
+
+	stdout, err := runner.Stdout(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stdout from container. The container is likely stopped due to an error. Consider re-running the pipeline with `--log-level=debug` for more information")
+	}
+
+	if _, err := runner.ExitCode(ctx); err != nil {
+		return err
+	}
+
+	updates := map[string]state.StateValueJSON{}
+
+	if err := json.Unmarshal([]byte(stdout), &updates); err != nil {
+		return fmt.Errorf("error unmarshaling state JSON from CLI client: %w", err)
+	}
+
+	for _, v := range updates {
+		if v.Argument.Type == state.ArgumentTypeFile {
+			containerPath := v.Value.(string)
+			hostPath := filepath.Join(os.TempDir(), filepath.Base(containerPath))
+			dir := runner.Directory(filepath.Dir(containerPath))
+			_, err := dir.File(filepath.Base(containerPath)).Export(ctx, hostPath)
+			if err != nil {
+				return err
+			}
+
+			v.Value = hostPath
 		}
-		runner = r
 
-		argmap, err := getArgMap(ctx, c.State, m, step.RequiredArgs)
-		if err != nil {
+		// If the container gives us a Filesystem argument, we must mount it in a temporary location in order to create the tar.gz so the state
+		// can properly handle it.
+		if v.Argument.Type == state.ArgumentTypeFS {
+			var (
+				hostPath      = filepath.Join(os.TempDir(), stringutil.Random(8))
+				containerPath = v.Value.(string)
+				dir           = runner.Directory(containerPath)
+			)
+			if _, err := dir.Export(ctx, hostPath); err != nil {
+				return err
+			}
+			v.Value = hostPath
+		}
+
+		if err := state.SetValueFromJSON(ctx, c.State, v); err != nil {
 			return err
 		}
+	}
 
-		for k, v := range argmap {
-			log.Infoln("ArgMap", k, v)
+	// The paths in the step's cache have been resolved by runStep.
+	if step.Cache != nil && c.Cache != nil {
+		if err := c.exportCachePaths(ctx, runner, step.Cache); err != nil {
+			log.WithError(err).Warnln("error exporting cached paths from container")
 		}
+	}
 
-		cmd, err := cmdutil.StepCommand(cmdutil.CommandOpts{
-			CompiledPipeline: binPath,
-			Step:             step,
-			PipelineArgs: args.PipelineArgs{
-				Path:   path,
-				ArgMap: argmap,
-			},
-		})
+	return nil
+}
+
+// runStep runs a single step and returns its status for the run history. If the status is empty, then it is derived from the returned error.
+func (c *Client) runStep(ctx context.Context, log logrus.FieldLogger, step pipeline.Step, d *dagger.Client, bin *dagger.Directory, src *dagger.Directory, path string) (history.Status, error) {
+	// Skipped steps return without an error so that the steps that depend on them still run.
+	ok, err := step.ShouldRun(ctx, c.State)
+	if err != nil {
+		return "", fmt.Errorf("error evaluating conditions: %w", err)
+	}
+	if !ok {
+		log.Infoln("skipping step because its conditions did not match")
+		return history.StatusSkipped, nil
+	}
+
+	cache := step.CacheOutputs()
+	if cache != nil && c.Cache != nil {
+		// The environment variables in the cached paths are expanded with the environment of the step's container, not the host's.
+		container, err := c.HandleEnvironment(ctx, d.Container().From(step.Image).WithWorkdir(Workdir), step)
 		if err != nil {
-			return err
+			return "", err
+		}
+
+		resolved, err := c.resolveCache(ctx, container, cache)
+		if err != nil {
+			log.WithError(err).Warnln("error resolving cached paths; running step without the cache")
 		}
 
-		// Some containers have entrypoints that can make `Exec` inconsistent. This attempts to disable / override that behavior.
-		//runner = runner.WithEntrypoint([]string{})
-		log.WithField("command", strings.Join(cmd, " ")).Infoln("Registering container with command...")
-		runner = runner.WithExec(cmd)
+		cache = resolved
+		step.Cache = resolved
+	}
+
+	key, ok := c.restoreCache(ctx, log, cache)
+	if ok {
+		c.mountCachePaths(cache)
+		log.Infoln("step outputs restored from cache; skipping step")
+		return "", nil
+	}
 
-		if stderr, err := runner.Stderr(ctx); err == nil {
-			log.WithField("stream", "stderr").Infoln(stderr)
-		} else {
-			log.Errorln("Failed to get stderr from container. Dagger currently doesn't support streaming stdout/stderr directly; try re-running with `--log-level=debug` for more information")
+	// The step's timeout is also enforced by the 'cli' client inside of the container, but cancelling the context here stops the container exec itself.
+	err = pipeline.RunWithTimeout(ctx, step.Timeout, func(ctx context.Context) error {
+		return c.HandleStep(ctx, step, d, bin, src, path)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	c.saveCache(ctx, log, key, cache)
+
+	return "", nil
+}
+
+// runBackground runs a background step until it completes or the context is cancelled.
+// Background steps without an action run the default command of their image.
+func (c *Client) runBackground(ctx context.Context, log logrus.FieldLogger, step pipeline.Step, d *dagger.Client, bin *dagger.Directory, src *dagger.Directory, path string) error {
+	if step.Action != nil {
+		_, err := c.runStep(ctx, log, step, d, bin, src, path)
+		return err
+	}
+
+	container, err := c.HandleEnvironment(ctx, d.Container().From(step.Image), step)
+	if err != nil {
+		return err
+	}
+
+	_, err = container.Exec().ExitCode(ctx)
+	return err
+}
+
+// startBackground starts the background steps in the pipeline. They keep running until the returned function is called, which stops them and waits for them to return.
+// A background step that fails does not fail the pipeline; the error is logged instead.
+func (c *Client) startBackground(ctx context.Context, p pipeline.Pipeline, d *dagger.Client, bin *dagger.Directory, src *dagger.Directory, path string) func() {
+	var (
+		bctx, cancel = context.WithCancel(ctx)
+		wg           = &sync.WaitGroup{}
+	)
+
+	for _, node := range p.Graph.Nodes {
+		if node.ID == 0 || !node.Value.IsBackground() {
+			continue
 		}
 
-		stdout, err := runner.Stdout(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to get stdout from container. The container is likely stopped due to an error. Consider re-running the pipeline with `--log-level=debug` for more information")
+		wg.Add(1)
+		go func(step pipeline.Step) {
+			defer wg.Done()
+
+			log := c.Log.WithField("step", step.Name)
+			if err := c.runBackground(bctx, log, step, d, bin, src, path); err != nil && bctx.Err() == nil {
+				log.WithError(err).Warnln("background step failed")
+			}
+		}(node.Value)
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// StepNodeFunc executes the contents of the step using the CLI client and is called once per step by the scheduler.
+func (c *Client) StepNodeFunc(p pipeline.Pipeline, d *dagger.Client, bin *dagger.Directory, src *dagger.Directory, path string) syncutil.NodeFunc[pipeline.Step] {
+	return func(ctx context.Context, n *dag.Node[pipeline.Step]) error {
+		// Skip the root step that's always present on every pipeline.
+		if n.ID == 0 {
+			return nil
 		}
 
-		if _, err := runner.ExitCode(ctx); err != nil {
-			return err
+		// Background steps are started with the pipeline (see 'startBackground'), so the steps that depend on them do not wait for them to complete.
+		if n.Value.IsBackground() {
+			return nil
 		}
 
-		updates := map[string]state.StateValueJSON{}
+		var (
+			step = n.Value
+			log  = c.Log.WithField("step", step.Name)
+		)
 
-		if err := json.Unmarshal([]byte(stdout), &updates); err != nil {
-			return fmt.Errorf("error unmarshaling state JSON from CLI client: %w", err)
+		if c.Opts.Args.Resume != "" && c.History.Succeeded(step) {
+			log.Infoln("skipping step because it succeeded in a previous attempt of this build")
+			return nil
 		}
 
-		for _, v := range updates {
-			if v.Argument.Type == state.ArgumentTypeFile {
-				containerPath := v.Value.(string)
-				hostPath := filepath.Join(os.TempDir(), filepath.Base(containerPath))
-				dir := runner.Directory(filepath.Dir(containerPath))
-				_, err := dir.File(filepath.Base(containerPath)).Export(ctx, hostPath)
-				if err != nil {
-					return err
-				}
-
-				v.Value = hostPath
-			}
+		c.recordHistory(log, c.History.StepStarted(p, step))
+		status, err := c.runStep(ctx, log, step, d, bin, src, path)
+		c.recordHistory(log, c.History.StepFinished(p, step, status, err))
 
-			// If the container gives us a Filesystem argument, we must mount it in a temporary location in order to create the tar.gz so the state
-			// can properly handle it.
-			if v.Argument.Type == state.ArgumentTypeFS {
-				var (
-					hostPath      = filepath.Join(os.TempDir(), stringutil.Random(8))
-					containerPath = v.Value.(string)
-					dir           = runner.Directory(containerPath)
-				)
-				if _, err := dir.Export(ctx, hostPath); err != nil {
-					return err
-				}
-				v.Value = hostPath
+		if err != nil {
+			if step.AllowFailure {
+				log.WithError(err).Warnln("step failed but is allowed to fail")
+				return nil
 			}
 
-			if err := state.SetValueFromJSON(ctx, c.State, v); err != nil {
-				return err
-			}
+			return fmt.Errorf("step '%s': %w", step.Name, err)
 		}
 
 		return nil
-	})
-	return nil
-}
-
-// StepWalkFunc executes the contents of the step using the CLI client and is called once per step.
-func (c *Client) StepWalkFunc(d *dagger.Client, wg *syncutil.WaitGroup, bin *dagger.Directory, src *dagger.Directory, path string) pipeline.StepWalkFunc {
-	return func(ctx context.Context, step pipeline.Step) error {
-		return c.HandleStep(ctx, step, d, wg, bin, src, path)
 	}
 }
 
-// PipelineWalkFunc is executed once for every set of parallel functions.
-func (c *Client) PipelineWalkFunc(w *pipeline.Collection, wg *syncutil.WaitGroup, bin, src *dagger.Directory, d *dagger.Client) pipeline.PipelineWalkFunc {
-	return func(ctx context.Context, p pipeline.Pipeline) error {
+// PipelineNodeFunc is called once per pipeline by the scheduler. It runs the steps in the pipeline as soon as the steps they depend on have completed.
+func (c *Client) PipelineNodeFunc(bin, src *dagger.Directory, d *dagger.Client) syncutil.NodeFunc[pipeline.Pipeline] {
+	return func(ctx context.Context, n *dag.Node[pipeline.Pipeline]) error {
+		p := n.Value
 		if p.ID == 0 {
 			return nil
 		}
@@ -303,49 +430,44 @@ func (c *Client) PipelineWalkFunc(w *pipeline.Collection, wg *syncutil.WaitGroup
 			"pipeline": p.Name,
 		})
 
-		// notFoundArgs := []state.Argument{}
-		// for _, v := range p.RequiredArgs {
-		// 	ok, err := c.State.Exists(ctx, v)
-		// 	if err != nil {
-		// 		return err
-		// 	}
-
-		// 	// If this required arg doesn't exist in the state, then wait for it...
-		// 	if !ok {
-		// 		notFoundArgs = append(notFoundArgs, v)
-		// 	}
-		// }
-
-		// // Wait for not found arguments
-		// //c.WaitForArgs(ctx, c.Log, notFoundArgs)
 		log.Infoln("Processing pipeline with Dagger")
-		wg.Add(func(ctx context.Context) error {
-			swg := syncutil.NewWaitGroup()
-			// Before running the steps in the pipeline, wait for the arguments to be in the state that this pipeline is requesting
-
-			log.Infoln("Waiting for arguments to be ready before registering containers...")
-			c.WaitForArgs(ctx, log, p.RequiredArgs)
-			log.Infoln("Done waiting for arguments")
-
-			wf := c.StepWalkFunc(d, swg, bin, src, c.Opts.Args.Path)
-			log.Infoln("Walking through steps and registering containers...")
-			// Walk through each step, add it to the waitgroup for this set of steps
-			if err := w.WalkSteps(ctx, p.ID, wf); err != nil {
-				return err
-			}
-			log.Infoln("Done walking through")
+		defer log.Infoln("Done processing pipeline")
+
+		c.recordHistory(log, c.History.PipelineStarted(p))
 
-			log.Infoln("Waiting for steps to complete")
-			return swg.Wait(ctx)
+		scheduler := syncutil.NewStepScheduler(p, c.Opts.Args.MaxConcurrency, syncutil.ModeFromArgs(c.Opts.Args))
+		err := pipeline.RunWithTimeout(ctx, p.Timeout, func(ctx context.Context) error {
+			stop := c.startBackground(ctx, p, d, bin, src, c.Opts.Args.Path)
+			defer stop()
+
+			return scheduler.Run(ctx, c.StepNodeFunc(p, d, bin, src, c.Opts.Args.Path))
 		})
 
+		c.recordHistory(log, c.History.PipelineFinished(p, err))
+
+		if err != nil {
+			return fmt.Errorf("pipeline '%s': %w", p.Name, err)
+		}
+
 		return nil
 	}
 }
 
 // Done must be ran at the end of the pipeline.
 // This is typically what takes the defined pipeline steps, runs them in the order defined, and produces some kind of output.
+// The run and the result of every pipeline and step in it are recorded in the run history so that the run can be resumed with '--resume'.
 func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
+	c.recordHistory(c.Log, c.History.Start())
+	err := c.run(ctx, w)
+	if err := c.Mounts.Close(); err != nil {
+		c.Log.WithError(err).Warnln("error removing cached paths from the host")
+	}
+	c.recordHistory(c.Log, c.History.Finish(err))
+
+	return err
+}
+
+func (c *Client) run(ctx context.Context, w *pipeline.Collection) error {
 	d, err := dagger.Connect(
 		ctx,
 		// Until dagger has the ability to provide log streams per-container for stdout/stderr, we have to include the whole thing
@@ -381,20 +503,24 @@ func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
 		return err
 	}
 
-	wg := syncutil.NewWaitGroup()
-	wf := c.PipelineWalkFunc(w, wg, bin, d.Host().Directory(src), d)
-
-	if err := w.WalkPipelines(ctx, wf); err != nil {
-		return err
+	// Pipelines are not limited by MaxConcurrency; it is applied to the steps within each pipeline.
+	scheduler := syncutil.NewScheduler(w.Graph, 0, syncutil.ModeFromArgs(c.Opts.Args))
+	scheduler.AlwaysRun = func(n *dag.Node[pipeline.Pipeline]) bool {
+		return n.Value.AlwaysRun()
 	}
 
-	return wg.Wait(ctx)
+	return scheduler.Run(ctx, c.PipelineNodeFunc(bin, d.Host().Directory(src), d))
 }
 
 // Validate is ran internally before calling Run or Parallel and allows the client to effectively configure per-step requirements
 // For example, Drone steps MUST have an image so the Drone client returns an error in this function when the provided step does not have an image.
 // If the error encountered is not critical but should still be logged, then return a plumbing.ErrorSkipValidation.
 // The error is checked with `errors.Is` so the error can be wrapped with fmt.Errorf.
+// Background steps return ErrorBackground, which is only logged. Cached paths that can not be exported from the step's container return an error too.
 func (c *Client) Validate(step pipeline.Step) error {
-	return nil
+	if step.IsBackground() {
+		return ErrorBackground
+	}
+
+	return validateCachePaths(step.Cache)
 }
@@ -0,0 +1,172 @@
+package dagger_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/scribe/errors"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients/dagger"
+	"github.com/grafana/scribe/state"
+	"github.com/sirupsen/logrus"
+)
+
+func newClient(t *testing.T) *dagger.Client {
+	t.Helper()
+
+	s, err := state.NewFilesystemState(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &dagger.Client{
+		Log:   logrus.New(),
+		State: state.NewObserver(s),
+	}
+}
+
+// The containers are only created by a running Dagger engine, so these tests only cover the environment values that are read before the container is modified.
+func TestHandleEnvironment(t *testing.T) {
+	t.Run("It should not modify the container if the step has no environment", func(t *testing.T) {
+		container, err := newClient(t).HandleEnvironment(context.Background(), nil, pipeline.NamedStep("test", pipeline.DefaultAction))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if container != nil {
+			t.Fatal("Expected the container to not be modified")
+		}
+	})
+
+	t.Run("It should return an error if an argument in the environment is not in the state", func(t *testing.T) {
+		step := pipeline.NamedStep("test", pipeline.DefaultAction).WithEnvVar("VERSION", pipeline.NewEnvArgument(state.NewStringArgument("version")))
+
+		if _, err := newClient(t).HandleEnvironment(context.Background(), nil, step); err == nil {
+			t.Fatal("Expected an error but received none")
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("It should only warn about background steps so that they still run", func(t *testing.T) {
+		step := pipeline.NamedStep("redis", nil).WithImage("redis:6")
+		step.Type = pipeline.StepTypeBackground
+
+		err := newClient(t).Validate(step)
+		if !errors.Is(err, dagger.ErrorBackground) {
+			t.Fatalf("Expected error '%v' but received '%v'", dagger.ErrorBackground, err)
+		}
+		if !errors.Is(err, errors.ErrorSkipValidation) {
+			t.Fatalf("Expected error '%v' to wrap '%v'", err, errors.ErrorSkipValidation)
+		}
+	})
+
+	t.Run("It should return an error for cached paths that can not be exported from the container", func(t *testing.T) {
+		for _, p := range []string{"../node_modules", "/", "/var", "/opt/scribe/pipeline"} {
+			step := pipeline.NamedStep("test", pipeline.DefaultAction).WithCache(pipeline.CachePaths(p))
+			if err := newClient(t).Validate(step); err == nil {
+				t.Fatalf("Expected an error for '%s' but received none", p)
+			}
+		}
+	})
+
+	t.Run("It should not return an error for absolute cached paths that are not in the source directory", func(t *testing.T) {
+		step := pipeline.NamedStep("test", pipeline.DefaultAction).WithCache(pipeline.CachePaths("/root/.cache", "/go/pkg"))
+		if err := newClient(t).Validate(step); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("It should not return an error for cached paths with environment variables, as they are resolved in the container", func(t *testing.T) {
+		step := pipeline.NamedStep("test", pipeline.DefaultAction).WithCache(pipeline.CachePaths("$GOPATH/pkg", "node_modules"))
+		if err := newClient(t).Validate(step); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("It should not return an error for other steps", func(t *testing.T) {
+		if err := newClient(t).Validate(pipeline.NamedStep("test", pipeline.DefaultAction)); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestCachePath(t *testing.T) {
+	getenv := func(key string) string {
+		return map[string]string{
+			"GOPATH":  "/go",
+			"WORKDIR": "/var/scribe",
+		}[key]
+	}
+
+	t.Run("It should expand environment variables and return paths in the working directory relative to it", func(t *testing.T) {
+		cases := map[string]string{
+			"node_modules":         "node_modules",
+			"./.yarn/cache":        ".yarn/cache",
+			"$WORKDIR/vendor":      "vendor",
+			"/var/scribe/.gocache": ".gocache",
+			"$WORKDIR":             ".",
+			"$GOPATH/pkg":          "/go/pkg",
+			"/root/.cache/":        "/root/.cache",
+			"/var/scribe-state":    "/var/scribe-state",
+		}
+
+		for p, expected := range cases {
+			v, err := dagger.CachePath(p, getenv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != expected {
+				t.Fatalf("Expected '%s' to resolve to '%s' but received '%s'", p, expected, v)
+			}
+		}
+	})
+
+	t.Run("It should return an error for relative paths that are not in the working directory and paths that overlap a mounted directory", func(t *testing.T) {
+		for _, p := range []string{"../node_modules", "vendor/../../node_modules", "/", "/var", "$GOPATH/..", "/opt/scribe", "/opt/scribe/pipeline"} {
+			if _, err := dagger.CachePath(p, getenv); err == nil {
+				t.Fatalf("Expected an error for '%s' but received none", p)
+			}
+		}
+	})
+}
+
+func TestMounts(t *testing.T) {
+	t.Run("It should store absolute container paths on the host and map them back", func(t *testing.T) {
+		m := &dagger.Mounts{}
+		defer m.Close()
+
+		host, err := m.HostPath("/go/pkg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !filepath.IsAbs(host) {
+			t.Fatalf("Expected an absolute path on the host but received '%s'", host)
+		}
+
+		v, ok := m.ContainerPath(host)
+		if !ok {
+			t.Fatalf("Expected '%s' to be stored by the mounts", host)
+		}
+		if v != "/go/pkg" {
+			t.Fatalf("Expected '%s' to map to '/go/pkg' but received '%s'", host, v)
+		}
+	})
+
+	t.Run("It should not map paths that it does not store", func(t *testing.T) {
+		m := &dagger.Mounts{}
+		defer m.Close()
+
+		if _, err := m.HostPath("/go/pkg"); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, p := range []string{"node_modules", filepath.Join(os.TempDir(), "node_modules")} {
+			if _, ok := m.ContainerPath(p); ok {
+				t.Fatalf("Expected '%s' to not be stored by the mounts", p)
+			}
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package dagger
+
+import (
+	"fmt"
+
+	"github.com/grafana/scribe/history"
+	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/sirupsen/logrus"
+)
+
+// newRecorder creates the recorder for the run history.
+// The history is stored next to the state (see 'history.Dir'), so a build is resumed with the '--state' flag that it was started with.
+// If the '--resume' flag was provided, then the record of the previous attempt is updated, and its state URL replaces the one in the arguments so that the state is reused.
+func newRecorder(opts clients.CommonOpts) (*history.Recorder, error) {
+	store := history.NewStore(history.Dir(opts.Args.State))
+
+	if id := opts.Args.Resume; id != "" {
+		run, err := store.Get(id)
+		if err != nil {
+			return nil, fmt.Errorf("error resuming build '%s': %w", id, err)
+		}
+
+		opts.Args.State = run.State
+		return history.NewRecorder(store, run), nil
+	}
+
+	return history.NewRecorder(store, history.NewRun(opts.Args.BuildID, opts.Name, opts.Args.State)), nil
+}
+
+// recordHistory logs errors from the run history. Failing to record the run should not fail the run itself.
+func (c *Client) recordHistory(log logrus.FieldLogger, err error) {
+	if err != nil {
+		log.WithError(err).Warnln("error recording run history")
+	}
+}
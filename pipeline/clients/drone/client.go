@@ -24,9 +24,13 @@ var (
 // Client is the Drone implementation of the pipeline Client interface.
 // It will create a `.drone.yml` file that will run your pipeline the same way that it would run locally.
 // It uses the dagger client to run an entire pipeline as a single step. While not ideal for visualization purposes, this does behavior is what enables consistency.
+// If the '--drone-steps' argument is provided, then every step runs in its own Drone step instead, in the step's image, so that every step has its own logs and timings in Drone.
 type Client struct {
 	Opts clients.CommonOpts
 
+	// Language is the language of the generated config. By default, a '.drone.yml' config is generated.
+	Language DroneLanguage
+
 	Log *logrus.Logger
 }
 
@@ -61,6 +65,31 @@ func pipelinesToNames(steps []pipeline.Pipeline) []string {
 	return s
 }
 
+// pipelineSteps returns every step in the pipeline except for the root step and the background steps.
+func pipelineSteps(p pipeline.Pipeline) []pipeline.Step {
+	steps := []pipeline.Step{}
+	for _, node := range p.Graph.Nodes {
+		if node.ID == 0 || node.Value.IsBackground() {
+			continue
+		}
+		steps = append(steps, node.Value)
+	}
+
+	return steps
+}
+
+// backgroundSteps returns the background steps in the pipeline.
+func backgroundSteps(p pipeline.Pipeline) []pipeline.Step {
+	steps := []pipeline.Step{}
+	for _, node := range p.Graph.Nodes {
+		if node.ID != 0 && node.Value.IsBackground() {
+			steps = append(steps, node.Value)
+		}
+	}
+
+	return steps
+}
+
 type stepList struct {
 	steps    []*yaml.Container
 	services []*yaml.Container
@@ -82,6 +111,122 @@ func (c *Client) Step(v pipeline.Pipeline, state string) (*yaml.Container, error
 	return step, nil
 }
 
+// Steps creates one Drone step for every step in the pipeline, so that every step has its own logs and timings in Drone.
+// Each Drone step depends on the Drone steps of the steps that it depends on in the pipeline.
+func (c *Client) Steps(v pipeline.Pipeline, state string) ([]*yaml.Container, error) {
+	// The root node is not a step, so the steps that only depend on it will depend on the step that compiles the pipeline instead.
+	// Background steps run for the whole pipeline, so the other steps do not wait for them.
+	// A step can provide more than one argument to the same step, so the edges between two steps are only added once.
+	var (
+		dependencies = map[int64][]string{}
+		added        = map[[2]int64]bool{}
+	)
+
+	for _, node := range v.Graph.Nodes {
+		if node.ID == 0 || node.Value.IsBackground() {
+			continue
+		}
+
+		for _, edge := range v.Graph.Edges[node.ID] {
+			if added[[2]int64{node.ID, edge.To.ID}] {
+				continue
+			}
+
+			added[[2]int64{node.ID, edge.To.ID}] = true
+			dependencies[edge.To.ID] = append(dependencies[edge.To.ID], stringutil.Slugify(node.Value.Name))
+		}
+	}
+
+	steps := []*yaml.Container{}
+	for _, s := range pipelineSteps(v) {
+		step, err := NewStep(c, c.Opts.Args.Path, state, c.Opts.Version, s)
+		if err != nil {
+			return nil, err
+		}
+
+		step.DependsOn = dependencies[s.ID]
+		StepModes(step, s)
+		if err := StepEvents(step, s); err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// background creates the Drone services and detached steps for the background steps in the pipeline. They are started with the pipeline and stopped once the other steps are done.
+// The other steps can reach them using their name as the hostname.
+// Background steps without an action use the default command of their image, so they are Drone services. Background steps with an action run the compiled pipeline,
+// which is only available once it is compiled, so they are detached Drone steps instead.
+func (c *Client) background(v pipeline.Pipeline, state string) (*stepList, error) {
+	list := &stepList{}
+	for _, s := range backgroundSteps(v) {
+		if s.Action == nil {
+			list.AddService(NewService(s))
+			continue
+		}
+
+		step, err := NewStep(c, c.Opts.Args.Path, state, c.Opts.Version, s)
+		if err != nil {
+			return nil, err
+		}
+
+		step.Detach = true
+		list.AddStep(step)
+	}
+
+	return list, nil
+}
+
+// hasMatrix returns true if the pipeline has steps that were created with 'pipeline.Matrix'.
+func hasMatrix(p pipeline.Pipeline) bool {
+	for _, v := range pipelineSteps(p) {
+		if v.Matrix != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containers returns the Drone steps and services that run the pipeline. By default the whole pipeline runs in a single Drone step, unless the '--drone-steps' argument was provided.
+// Pipelines with steps that were created with 'pipeline.Matrix' always have a Drone step for every step, so that every combination of the matrix is its own Drone step with its own environment.
+func (c *Client) containers(v pipeline.Pipeline, state string) (*stepList, error) {
+	list, err := c.background(v, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Opts.Args.DroneSteps || hasMatrix(v) {
+		steps, err := c.Steps(v, state)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range steps {
+			list.AddStep(s)
+		}
+
+		return list, nil
+	}
+
+	s, err := c.Step(v, state)
+	if err != nil {
+		return nil, err
+	}
+
+	StepModes(s, pipelineSteps(v)...)
+	if err := StepEvents(s, pipelineSteps(v)...); err != nil {
+		return nil, err
+	}
+
+	list.AddStep(s)
+
+	return list, nil
+}
+
 var (
 	PipelinePath = "/var/scribe/pipeline"
 	StatePath    = "/var/scribe-state"
@@ -167,7 +312,7 @@ func (c *Client) newPipeline(opts newPipelineOpts, pipelineOpts clients.CommonOp
 
 // Done traverses through the tree and writes a .drone.yml file to the provided writer
 func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
-	cfg := []yaml.Resource{}
+	cfg := []*yaml.Pipeline{}
 	log := c.Log.WithField("client", "drone")
 
 	// StatePath is an aboslute path and already has a '/'.
@@ -191,7 +336,7 @@ func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
 		}
 		log.Debugf("Processing pipeline '%s'...", v.Name)
 
-		s, err := c.Step(v, stateArg.String())
+		list, err := c.containers(v, stateArg.String())
 		if err != nil {
 			return err
 		}
@@ -210,7 +355,8 @@ func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
 
 		pipeline := c.newPipeline(newPipelineOpts{
 			Name:      stringutil.Slugify(v.Name),
-			Steps:     []*yaml.Container{s},
+			Steps:     list.steps,
+			Services:  list.services,
 			DependsOn: dependencies,
 		}, c.Opts)
 		if len(v.Events) == 0 {
@@ -229,12 +375,22 @@ func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
 			pipeline.Trigger = cond
 		}
 
+		// Pipelines that always run should still run when a pipeline that they depend on fails.
+		if v.AlwaysRun() {
+			pipeline.Trigger.Status = StatusAlways
+		}
+
 		log.Debugf("Done processing pipeline '%s'", v.Name)
 		cfg = append(cfg, pipeline)
 	}
 
-	manifest := &yaml.Manifest{
-		Resources: cfg,
+	if c.Language == LanguageStarlark {
+		return WriteStarlark(c.Opts.Output, cfg)
+	}
+
+	manifest := &yaml.Manifest{}
+	for _, v := range cfg {
+		manifest.Resources = append(manifest.Resources, v)
 	}
 	pretty.Print(c.Opts.Output, manifest)
 
@@ -3,16 +3,23 @@ package drone_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/drone/drone-yaml/yaml"
 	"github.com/google/go-cmp/cmp"
 	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
 	"github.com/grafana/scribe/pipeline/clients/drone"
+	"github.com/grafana/scribe/state"
 	"github.com/grafana/scribe/testutil"
 	"github.com/sirupsen/logrus"
 )
@@ -22,6 +29,13 @@ import (
 // Some standard arguments will be provided, like "-mode=drone", '-build-id="test"', "-path={path}", -log-level="debug".
 func testDemoPipeline(t *testing.T, path string) {
 	t.Helper()
+	testDemoPipelineFile(t, path, "gen_drone.yml", args.PipelineArgs{})
+}
+
+// testDemoPipelineFile is the same as testDemoPipeline, but compares the generated config with the given file.
+// The Drone options in 'opts', like 'DroneSteps' and 'DroneLanguage', are provided to the pipeline along with the standard arguments.
+func testDemoPipelineFile(t *testing.T, path, file string, opts args.PipelineArgs) {
+	t.Helper()
 
 	var (
 		buf          = bytes.NewBuffer(nil)
@@ -31,15 +45,17 @@ func testDemoPipeline(t *testing.T, path string) {
 	)
 
 	testutil.RunPipeline(ctx, t, pipelinePath, io.MultiWriter(buf, os.Stdout), stderr, &args.PipelineArgs{
-		BuildID:  "test",
-		Client:   "drone",
-		Path:     fmt.Sprintf("./demo/%s", path), // Note that we're intentionally using ./demo/ instead of filepath because this path is used in a Go command.
-		LogLevel: logrus.DebugLevel,
+		BuildID:       "test",
+		Client:        "drone",
+		Path:          fmt.Sprintf("./demo/%s", path), // Note that we're intentionally using ./demo/ instead of filepath because this path is used in a Go command.
+		LogLevel:      logrus.DebugLevel,
+		DroneSteps:    opts.DroneSteps,
+		DroneLanguage: opts.DroneLanguage,
 	})
 
 	t.Log(stderr.String())
 
-	expected, err := os.Open(filepath.Join(pipelinePath, "gen_drone.yml"))
+	expected, err := os.Open(filepath.Join(pipelinePath, file))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -63,6 +79,31 @@ func TestDroneClient(t *testing.T) {
 			testDemoPipeline(t, "multi-sub")
 		}),
 	)
+	t.Run("It should generate Drone services for background steps",
+		testutil.WithTimeout(time.Second*10, func(t *testing.T) {
+			testDemoPipeline(t, "complex")
+		}),
+	)
+	t.Run("It should generate a Drone step for every step in a simple pipeline",
+		testutil.WithTimeout(time.Second*10, func(t *testing.T) {
+			testDemoPipelineFile(t, "basic", "gen_drone_steps.yml", args.PipelineArgs{DroneSteps: true})
+		}),
+	)
+	t.Run("It should generate a Drone step for every step in a multi pipeline",
+		testutil.WithTimeout(time.Second*10, func(t *testing.T) {
+			testDemoPipelineFile(t, "multi", "gen_drone_steps.yml", args.PipelineArgs{DroneSteps: true})
+		}),
+	)
+	t.Run("It should generate a simple Drone pipeline in Starlark",
+		testutil.WithTimeout(time.Second*10, func(t *testing.T) {
+			testDemoPipelineFile(t, "basic", "gen_drone.star", args.PipelineArgs{DroneLanguage: args.DroneLanguageStarlark})
+		}),
+	)
+	t.Run("It should generate a more complex multi Drone pipeline in Starlark",
+		testutil.WithTimeout(time.Second*10, func(t *testing.T) {
+			testDemoPipelineFile(t, "multi", "gen_drone.star", args.PipelineArgs{DroneLanguage: args.DroneLanguageStarlark})
+		}),
+	)
 }
 
 func TestDroneRun(t *testing.T) {
@@ -117,3 +158,550 @@ func TestDroneRun(t *testing.T) {
 			}
 		}))
 }
+
+func TestStepModes(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Steps         []pipeline.Step
+		ExpectFailure string
+		ExpectStatus  []string
+	}{
+		{
+			Name:  "A default step should not have a failure or status condition",
+			Steps: []pipeline.Step{pipeline.NoOpStep},
+		},
+		{
+			Name:          "A step that is allowed to fail should ignore failures",
+			Steps:         []pipeline.Step{pipeline.NoOpStep.WithAllowFailure()},
+			ExpectFailure: "ignore",
+		},
+		{
+			Name:         "A step that always runs should run on success and failure",
+			Steps:        []pipeline.Step{pipeline.NoOpStep.WithAlwaysRun()},
+			ExpectStatus: []string{"success", "failure"},
+		},
+		{
+			Name:  "Modes should not be mapped if any step does not have them",
+			Steps: []pipeline.Step{pipeline.NoOpStep.WithAllowFailure().WithAlwaysRun(), pipeline.NoOpStep},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			container := &yaml.Container{}
+			drone.StepModes(container, c.Steps...)
+
+			if container.Failure != c.ExpectFailure {
+				t.Errorf("expected failure to be '%s' but got '%s'", c.ExpectFailure, container.Failure)
+			}
+			if !cmp.Equal(container.When.Status.Include, c.ExpectStatus) {
+				t.Error(cmp.Diff(container.When.Status.Include, c.ExpectStatus))
+			}
+		})
+	}
+}
+
+func TestStepEvents(t *testing.T) {
+	var (
+		release = pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.StringFilter("release")})
+		tag     = pipeline.GitTagEvent(pipeline.GitTagFilters{Name: pipeline.GlobFilter("v*")})
+	)
+
+	t.Run("It should add the events of the steps to the 'when' conditions", func(t *testing.T) {
+		container := &yaml.Container{}
+		if err := drone.StepEvents(container, pipeline.NoOpStep.When(release, tag)); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := yaml.Conditions{
+			Event:  yaml.Condition{Include: []string{"push", "tag"}},
+			Branch: yaml.Condition{Include: []string{"release"}},
+			Ref:    yaml.Condition{Include: []string{"refs/tags/v*"}},
+		}
+		if !cmp.Equal(container.When, expected) {
+			t.Fatal(cmp.Diff(container.When, expected))
+		}
+	})
+
+	t.Run("It should not add conditions if the steps have different events", func(t *testing.T) {
+		container := &yaml.Container{}
+		if err := drone.StepEvents(container, pipeline.NoOpStep.When(release), pipeline.NoOpStep.When(tag)); err != nil {
+			t.Fatal(err)
+		}
+
+		if !cmp.Equal(container.When, yaml.Conditions{}) {
+			t.Fatal(cmp.Diff(container.When, yaml.Conditions{}))
+		}
+	})
+}
+
+func TestWriteStarlark(t *testing.T) {
+	t.Run("It should return every pipeline from a function with a unique name", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		pipelines := []*yaml.Pipeline{
+			{Kind: "pipeline", Name: "main"},
+			{Kind: "pipeline", Name: "build-frontend"},
+			{Kind: "pipeline", Name: "build_frontend"},
+		}
+
+		if err := drone.WriteStarlark(buf, pipelines); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, v := range []string{
+			"def main(ctx):\n    return [\n        pipeline_main(),\n        build_frontend(),\n        build_frontend_2(),\n    ]\n",
+			"def pipeline_main():\n    return {\n        \"kind\": \"pipeline\",\n        \"name\": \"main\",\n",
+			"def build_frontend_2():\n",
+		} {
+			if !strings.Contains(buf.String(), v) {
+				t.Fatalf("Expected the config to contain '%s', but received:\n%s", v, buf.String())
+			}
+		}
+	})
+
+	t.Run("It should keep strings that look like numbers or booleans as strings", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		pipelines := []*yaml.Pipeline{
+			{
+				Kind: "pipeline",
+				Name: "main",
+				Steps: []*yaml.Container{{
+					Name:     "build",
+					Detach:   true,
+					Commands: []string{"true"},
+					Environment: map[string]*yaml.Variable{
+						"CGO_ENABLED": {Value: "0"},
+						"ENABLED":     {Value: "yes"},
+						"MODE":        {Value: "on"},
+					},
+				}},
+			},
+		}
+
+		if err := drone.WriteStarlark(buf, pipelines); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, v := range []string{
+			`"detach": True,`,
+			`"true",`,
+			`"CGO_ENABLED": "0",`,
+			`"ENABLED": "yes",`,
+			`"MODE": "on",`,
+		} {
+			if !strings.Contains(buf.String(), v) {
+				t.Fatalf("Expected the config to contain '%s', but received:\n%s", v, buf.String())
+			}
+		}
+	})
+}
+
+func TestEvents(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Events   []pipeline.Event
+		Expected yaml.Conditions
+		Error    error
+	}{
+		{
+			Name:     "A pipeline without events should not have any conditions",
+			Events:   []pipeline.Event{pipeline.GitCommitEvent(pipeline.GitCommitFilters{})},
+			Expected: yaml.Conditions{},
+		},
+		{
+			Name:   "A commit event should run on pushes to the branch",
+			Events: []pipeline.Event{pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.StringFilter("main")})},
+			Expected: yaml.Conditions{
+				Event:  yaml.Condition{Include: []string{"push"}},
+				Branch: yaml.Condition{Include: []string{"main"}},
+			},
+		},
+		{
+			Name: "A commit event should exclude branches",
+			Events: []pipeline.Event{pipeline.GitCommitEvent(pipeline.GitCommitFilters{
+				Branch:        pipeline.GlobFilter("release-*"),
+				ExcludeBranch: pipeline.StringFilter("release-1.0"),
+			})},
+			Expected: yaml.Conditions{
+				Event:  yaml.Condition{Include: []string{"push"}},
+				Branch: yaml.Condition{Include: []string{"release-*"}, Exclude: []string{"release-1.0"}},
+			},
+		},
+		{
+			Name:   "A string filter should be escaped so that it only matches the exact value",
+			Events: []pipeline.Event{pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.StringFilter("feature-*")})},
+			Expected: yaml.Conditions{
+				Event:  yaml.Condition{Include: []string{"push"}},
+				Branch: yaml.Condition{Include: []string{`feature-\*`}},
+			},
+		},
+		{
+			Name: "A tag event should include and exclude refs",
+			Events: []pipeline.Event{pipeline.GitTagEvent(pipeline.GitTagFilters{
+				Name:        pipeline.GlobFilter("v*"),
+				ExcludeName: pipeline.GlobFilter("v*-beta*"),
+			})},
+			Expected: yaml.Conditions{
+				Event: yaml.Condition{Include: []string{"tag"}},
+				Ref:   yaml.Condition{Include: []string{"refs/tags/v*"}, Exclude: []string{"refs/tags/v*-beta*"}},
+			},
+		},
+		{
+			Name:   "A tag event without a filter should run on every tag",
+			Events: []pipeline.Event{pipeline.GitTagEvent(pipeline.GitTagFilters{})},
+			Expected: yaml.Conditions{
+				Event: yaml.Condition{Include: []string{"tag"}},
+			},
+		},
+		{
+			Name:   "A regular expression with character classes should not be supported",
+			Events: []pipeline.Event{pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.RegexpFilter(regexp.MustCompile(`^release-\d?.*$`))})},
+			Error:  drone.ErrorUnsupportedFilter,
+		},
+		{
+			Name:   "A regular expression made of literals and wildcards should be converted to a glob pattern",
+			Events: []pipeline.Event{pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.RegexpFilter(regexp.MustCompile(`^release-v1\..*$`))})},
+			Expected: yaml.Conditions{
+				Event:  yaml.Condition{Include: []string{"push"}},
+				Branch: yaml.Condition{Include: []string{"release-v1.*"}},
+			},
+		},
+		{
+			Name:   "A regular expression that is not anchored should match anywhere in the value",
+			Events: []pipeline.Event{pipeline.GitTagEvent(pipeline.GitTagFilters{Name: pipeline.RegexpFilter(regexp.MustCompile(`rc.`))})},
+			Expected: yaml.Conditions{
+				Event: yaml.Condition{Include: []string{"tag"}},
+				Ref:   yaml.Condition{Include: []string{"refs/tags/*rc?*"}},
+			},
+		},
+		{
+			Name:   "A regular expression with alternatives should not be supported",
+			Events: []pipeline.Event{pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.RegexpFilter(regexp.MustCompile(`^(main|dev)$`))})},
+			Error:  drone.ErrorUnsupportedFilter,
+		},
+		{
+			Name: "A pull request event should filter the target branch",
+			Events: []pipeline.Event{pipeline.PullRequestEvent(pipeline.PullRequestFilters{
+				TargetBranch:        pipeline.GlobFilter("release-*"),
+				ExcludeTargetBranch: pipeline.StringFilter("release-old"),
+			})},
+			Expected: yaml.Conditions{
+				Event:  yaml.Condition{Include: []string{"pull_request"}},
+				Branch: yaml.Condition{Include: []string{"release-*"}, Exclude: []string{"release-old"}},
+			},
+		},
+		{
+			Name:   "A pull request event without filters should run on every pull request",
+			Events: []pipeline.Event{pipeline.PullRequestEvent(pipeline.PullRequestFilters{})},
+			Expected: yaml.Conditions{
+				Event: yaml.Condition{Include: []string{"pull_request"}},
+			},
+		},
+		{
+			Name:   "A cron event should filter the job name",
+			Events: []pipeline.Event{pipeline.CronEvent(pipeline.CronFilters{Job: pipeline.StringFilter("nightly")})},
+			Expected: yaml.Conditions{
+				Event: yaml.Condition{Include: []string{"cron"}},
+				Cron:  yaml.Condition{Include: []string{"nightly"}},
+			},
+		},
+		{
+			Name:   "A promote event should filter the target environment",
+			Events: []pipeline.Event{pipeline.PromoteEvent(pipeline.PromoteFilters{Target: pipeline.StringFilter("production")})},
+			Expected: yaml.Conditions{
+				Event:  yaml.Condition{Include: []string{"promote"}},
+				Target: yaml.Condition{Include: []string{"production"}},
+			},
+		},
+		{
+			Name: "Multiple events should be combined",
+			Events: []pipeline.Event{
+				pipeline.GitCommitEvent(pipeline.GitCommitFilters{}),
+				pipeline.PullRequestEvent(pipeline.PullRequestFilters{TargetBranch: pipeline.StringFilter("main")}),
+				pipeline.CronEvent(pipeline.CronFilters{}),
+			},
+			Expected: yaml.Conditions{
+				Event:  yaml.Condition{Include: []string{"push", "pull_request", "cron"}},
+				Branch: yaml.Condition{Include: []string{"main"}},
+			},
+		},
+		{
+			Name:   "An unknown event should not be supported",
+			Events: []pipeline.Event{{Name: "deployment"}},
+			Error:  drone.ErrorUnsupportedEvent,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			cond, err := drone.Events(c.Events)
+			if !errors.Is(err, c.Error) {
+				t.Fatalf("expected error '%v' but got '%v'", c.Error, err)
+			}
+
+			if !cmp.Equal(cond, c.Expected) {
+				t.Fatal(cmp.Diff(cond, c.Expected))
+			}
+		})
+	}
+}
+
+func TestDroneBackground(t *testing.T) {
+	t.Run("It should run background steps as services or detached steps", func(t *testing.T) {
+		var (
+			buf = &bytes.Buffer{}
+			log = logrus.New()
+			arg = state.NewStringArgument("version")
+		)
+
+		client := &drone.Client{
+			Opts: clients.CommonOpts{
+				Output: buf,
+				Log:    log,
+				Args: &args.PipelineArgs{
+					Path:       "./ci",
+					DroneSteps: true,
+				},
+			},
+			Log: log,
+		}
+
+		worker := pipeline.NamedStep("worker", pipeline.NoOpStep.Action).WithImage("alpine:3.16")
+		worker.Type = pipeline.StepTypeBackground
+
+		postgres := pipeline.NamedStep("postgres", pipeline.DefaultAction).WithImage("postgres:15")
+		postgres.Type = pipeline.StepTypeBackground
+
+		steps := []pipeline.Step{
+			postgres,
+			worker,
+			pipeline.NamedStep("version", pipeline.NoOpStep.Action).WithImage("alpine:3.16").Provides(arg),
+			pipeline.NamedStep("test", pipeline.NoOpStep.Action).WithImage("alpine:3.16").Requires(arg),
+		}
+		for i := range steps {
+			steps[i].ID = int64(i + 1)
+		}
+
+		col, err := pipeline.NewCollectionWithSteps("test", steps...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := col.BuildEdges(log, pipeline.ClientProvidedArguments...); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.Done(context.Background(), col); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, v := range []string{
+			"services:\n- name: postgres\n  image: postgres:15\n",
+			"- name: worker\n  image: alpine:3.16\n  detach: true\n",
+			"- name: test\n",
+		} {
+			if !strings.Contains(buf.String(), v) {
+				t.Fatalf("Expected the config to contain '%s', but received:\n%s", v, buf.String())
+			}
+		}
+	})
+}
+
+func TestDroneEnvironment(t *testing.T) {
+	t.Run("It should add static values and secrets to the step environment", func(t *testing.T) {
+		var (
+			buf     = &bytes.Buffer{}
+			log     = logrus.New()
+			version = state.NewStringArgument("version")
+			token   = state.NewSecretArgument("github-token")
+		)
+
+		client := &drone.Client{
+			Opts: clients.CommonOpts{
+				Output: buf,
+				Log:    log,
+				Args: &args.PipelineArgs{
+					Path:       "./ci",
+					DroneSteps: true,
+				},
+			},
+			Log: log,
+		}
+
+		redis := pipeline.NamedStep("redis", pipeline.DefaultAction).WithImage("redis:7").
+			WithEnvVar("REDIS_PASSWORD", pipeline.NewEnvArgument(token))
+		redis.Type = pipeline.StepTypeBackground
+
+		steps := []pipeline.Step{
+			redis,
+			pipeline.NamedStep("version", pipeline.NoOpStep.Action).WithImage("alpine:3.16").Provides(version),
+			pipeline.NamedStep("publish", pipeline.NoOpStep.Action).WithImage("alpine:3.16").
+				WithEnvVar("GOOS", pipeline.NewEnvString("linux")).
+				WithEnvVar("GITHUB_TOKEN", pipeline.NewEnvArgument(token)).
+				WithEnvVar("VERSION", pipeline.NewEnvArgument(version)),
+		}
+		for i := range steps {
+			steps[i].ID = int64(i + 1)
+		}
+
+		col, err := pipeline.NewCollectionWithSteps("test", steps...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := col.BuildEdges(log, pipeline.ClientProvidedArguments...); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.Done(context.Background(), col); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, v := range []string{
+			"    GITHUB_TOKEN:\n      from_secret: github-token\n",
+			"    GOOS: linux\n",
+			"    REDIS_PASSWORD:\n      from_secret: github-token\n",
+		} {
+			if !strings.Contains(buf.String(), v) {
+				t.Fatalf("Expected the config to contain '%s', but received:\n%s", v, buf.String())
+			}
+		}
+
+		// The value of the 'version' argument is only known when the step runs, so it is added by the CLI client instead.
+		if strings.Contains(buf.String(), "VERSION") {
+			t.Fatalf("Expected the config to not contain 'VERSION', but received:\n%s", buf.String())
+		}
+	})
+	t.Run("It should add the environment of every step to the Drone step of the pipeline", func(t *testing.T) {
+		var (
+			buf   = &bytes.Buffer{}
+			log   = logrus.New()
+			token = state.NewSecretArgument("github-token")
+		)
+
+		client := &drone.Client{
+			Opts: clients.CommonOpts{
+				Output: buf,
+				Log:    log,
+				Args: &args.PipelineArgs{
+					Path: "./ci",
+				},
+			},
+			Log: log,
+		}
+
+		steps := []pipeline.Step{
+			pipeline.NamedStep("build", pipeline.NoOpStep.Action).WithEnvVar("GOOS", pipeline.NewEnvString("linux")),
+			pipeline.NamedStep("publish", pipeline.NoOpStep.Action).WithEnvVar("GITHUB_TOKEN", pipeline.NewEnvArgument(token)),
+		}
+		for i := range steps {
+			steps[i].ID = int64(i + 1)
+		}
+
+		col, err := pipeline.NewCollectionWithSteps("test", steps...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := col.BuildEdges(log, pipeline.ClientProvidedArguments...); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.Done(context.Background(), col); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, v := range []string{
+			"    GITHUB_TOKEN:\n      from_secret: github-token\n",
+			"    GOOS: linux\n",
+			"--arg=github-token=$secret_github_token",
+		} {
+			if !strings.Contains(buf.String(), v) {
+				t.Fatalf("Expected the config to contain '%s', but received:\n%s", v, buf.String())
+			}
+		}
+	})
+
+	t.Run("It should return an error if two steps in the same Drone step set an environment variable to different values", func(t *testing.T) {
+		log := logrus.New()
+		client := &drone.Client{
+			Opts: clients.CommonOpts{
+				Output: &bytes.Buffer{},
+				Log:    log,
+				Args: &args.PipelineArgs{
+					Path: "./ci",
+				},
+			},
+			Log: log,
+		}
+
+		steps := []pipeline.Step{
+			pipeline.NamedStep("build linux", pipeline.NoOpStep.Action).WithEnvVar("GOOS", pipeline.NewEnvString("linux")),
+			pipeline.NamedStep("build darwin", pipeline.NoOpStep.Action).WithEnvVar("GOOS", pipeline.NewEnvString("darwin")),
+		}
+		for i := range steps {
+			steps[i].ID = int64(i + 1)
+		}
+
+		col, err := pipeline.NewCollectionWithSteps("test", steps...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := col.BuildEdges(log, pipeline.ClientProvidedArguments...); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.Done(context.Background(), col); err == nil {
+			t.Fatal("Expected an error but received none")
+		}
+	})
+}
+
+func TestDroneMatrix(t *testing.T) {
+	t.Run("It should generate a Drone step with its own environment for every combination of a matrix", func(t *testing.T) {
+		var (
+			buf = &bytes.Buffer{}
+			log = logrus.New()
+		)
+
+		client := &drone.Client{
+			Opts: clients.CommonOpts{
+				Output: buf,
+				Log:    log,
+				Args: &args.PipelineArgs{
+					Path: "./ci",
+				},
+			},
+			Log: log,
+		}
+
+		steps := pipeline.Matrix(map[string][]string{"goos": {"linux", "darwin"}}, func(v pipeline.MatrixValues) pipeline.Step {
+			return pipeline.NamedStep("build", pipeline.NoOpStep.Action).WithImage("golang:1.19")
+		})
+		for i := range steps {
+			steps[i].ID = int64(i + 1)
+		}
+
+		col, err := pipeline.NewCollectionWithSteps("test", steps...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := col.BuildEdges(log, pipeline.ClientProvidedArguments...); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.Done(context.Background(), col); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, v := range []string{
+			"- name: build_linux\n",
+			"- name: build_darwin\n",
+			"--step=1",
+			"--step=2",
+			"    MATRIX_GOOS: linux\n",
+			"    MATRIX_GOOS: darwin\n",
+		} {
+			if !strings.Contains(buf.String(), v) {
+				t.Fatalf("Expected the config to contain '%s', but received:\n%s", v, buf.String())
+			}
+		}
+	})
+}
@@ -25,6 +25,10 @@ var argEnvMap = map[state.Argument]string{
 	pipeline.ArgumentCommitRef:  "$DRONE_COMMIT_REF",
 	pipeline.ArgumentRemoteURL:  "$DRONE_GIT_SSH_URL",
 	pipeline.ArgumentWorkingDir: "$DRONE_REPO_NAME",
+
+	pipeline.ArgumentTargetBranch:  "$DRONE_TARGET_BRANCH",
+	pipeline.ArgumentCronJob:       "$DRONE_CRON",
+	pipeline.ArgumentPromoteTarget: "$DRONE_DEPLOY_TO",
 }
 
 // The configurer for the Drone client returns equivalent environment variables for different arguments.
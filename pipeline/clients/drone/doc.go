@@ -1,2 +1,9 @@
 // Package drone contians the drone client implementation for generating a Drone pipeline config.
+//
+// Drone does not support timeouts in the pipeline config; they can only be set in the repository settings.
+// Step and pipeline timeouts are still enforced because every generated step runs its pipeline with the 'cli' client.
+//
+// By default, every pipeline is generated as one Drone step. Pipelines with steps that were created with 'pipeline.Matrix' have a Drone step for every step instead, like with the '--drone-steps' flag. The allow-failure and always-run modes of the steps ('pipeline.Step.AllowFailure' and 'pipeline.Step.AlwaysRun')
+// are only set on that Drone step, and on the Drone pipeline, when every step in the pipeline has them. A step that always runs in a pipeline with other steps still runs
+// when a step before it in the same pipeline fails, but not when a pipeline that it depends on fails. Move the steps that should run after any failure into their own pipeline.
 package drone
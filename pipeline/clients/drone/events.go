@@ -1,33 +1,170 @@
 package drone
 
 import (
+	"errors"
 	"fmt"
+	"regexp/syntax"
+	"strings"
 
 	"github.com/drone/drone-yaml/yaml"
 	"github.com/grafana/scribe/pipeline"
 )
 
-// TODO: I'm lazy at the moment and haven't implemented reverse filters (exlude).
-func addEvent(c yaml.Conditions, e pipeline.Event) (yaml.Conditions, error) {
-	if branch, ok := e.Filters["branch"]; ok {
-		c.Event.Include = append(c.Event.Include, "branch")
-		c.Branch.Include = append(c.Branch.Include, branch.String())
+var (
+	ErrorUnsupportedEvent  = errors.New("event is not supported by Drone")
+	ErrorUnsupportedFilter = errors.New("filter is not supported by Drone")
+)
+
+// globEscaper escapes the characters that have a special meaning in Drone's glob patterns.
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`, `{`, `\{`, `}`, `\}`)
+
+// regexpGlob converts a regular expression into a Drone glob pattern. Only regular expressions that are made of literal text, '.', and '.*' can be converted.
+// Regular expressions match anywhere in the value unless they are anchored with '^' or '$', so a '*' is added to the ends that are not anchored.
+func regexpGlob(expr string) (string, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid regular expression '%s': %s", ErrorUnsupportedFilter, expr, err)
+	}
+
+	re = re.Simplify()
+
+	parts := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		parts = re.Sub
 	}
 
-	if tag, ok := e.Filters["tag"]; ok {
-		c.Event.Include = append(c.Event.Include, "tag")
-		if tag != nil {
-			c.Ref.Include = append(c.Ref.Include, fmt.Sprintf("refs/tags/%s", tag.String()))
+	var (
+		b     strings.Builder
+		start = true
+		end   = true
+	)
+
+	for i, v := range parts {
+		switch v.Op {
+		case syntax.OpBeginText, syntax.OpBeginLine:
+			if i != 0 {
+				return "", fmt.Errorf("%w: regular expression '%s' can not be converted to a glob pattern", ErrorUnsupportedFilter, expr)
+			}
+			start = false
+		case syntax.OpEndText, syntax.OpEndLine:
+			if i != len(parts)-1 {
+				return "", fmt.Errorf("%w: regular expression '%s' can not be converted to a glob pattern", ErrorUnsupportedFilter, expr)
+			}
+			end = false
+		case syntax.OpLiteral:
+			if v.Flags&syntax.FoldCase != 0 {
+				return "", fmt.Errorf("%w: case-insensitive regular expression '%s' can not be converted to a glob pattern", ErrorUnsupportedFilter, expr)
+			}
+			b.WriteString(globEscaper.Replace(string(v.Rune)))
+		case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			b.WriteString("?")
+		case syntax.OpStar:
+			if op := v.Sub[0].Op; op != syntax.OpAnyChar && op != syntax.OpAnyCharNotNL {
+				return "", fmt.Errorf("%w: regular expression '%s' can not be converted to a glob pattern", ErrorUnsupportedFilter, expr)
+			}
+			b.WriteString("*")
+		case syntax.OpEmptyMatch:
+		default:
+			return "", fmt.Errorf("%w: regular expression '%s' can not be converted to a glob pattern", ErrorUnsupportedFilter, expr)
 		}
 	}
 
+	pattern := b.String()
+	if start && !strings.HasPrefix(pattern, "*") {
+		pattern = "*" + pattern
+	}
+	if end && !strings.HasSuffix(pattern, "*") {
+		pattern = pattern + "*"
+	}
+
+	return pattern, nil
+}
+
+// filterPattern converts a FilterValue into a pattern in a Drone condition. Drone matches conditions with glob patterns, so glob filters are used as they are,
+// string filters are escaped so that they only match the exact value, and regular expressions are converted into an equivalent glob pattern if possible (see 'regexpGlob').
+func filterPattern(f *pipeline.FilterValue) (string, error) {
+	switch f.Type {
+	case pipeline.FilterValueGlob:
+		return f.String(), nil
+	case pipeline.FilterValueRegex:
+		return regexpGlob(f.String())
+	}
+
+	return globEscaper.Replace(f.String()), nil
+}
+
+// addFilters adds the include and exclude filters of the event with the key 'key' to the condition. 'format' is used to format each pattern, like 'refs/tags/%s'.
+// A nil filter matches every value, so it does not add a pattern.
+func addFilters(c yaml.Condition, e pipeline.Event, key, format string) (yaml.Condition, error) {
+	if f := e.Filters[key]; f != nil {
+		pattern, err := filterPattern(f)
+		if err != nil {
+			return c, err
+		}
+		c.Include = appendUnique(c.Include, fmt.Sprintf(format, pattern))
+	}
+
+	if f := e.Exclude[key]; f != nil {
+		pattern, err := filterPattern(f)
+		if err != nil {
+			return c, err
+		}
+		c.Exclude = appendUnique(c.Exclude, fmt.Sprintf(format, pattern))
+	}
+
 	return c, nil
 }
 
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+
+	return append(list, value)
+}
+
+func addEvent(c yaml.Conditions, e pipeline.Event) (yaml.Conditions, error) {
+	var err error
+
+	switch e.Name {
+	case "git-commit":
+		c.Event.Include = appendUnique(c.Event.Include, "push")
+		c.Branch, err = addFilters(c.Branch, e, "branch", "%s")
+	case "git-tag":
+		c.Event.Include = appendUnique(c.Event.Include, "tag")
+		c.Ref, err = addFilters(c.Ref, e, "tag", "refs/tags/%s")
+	case "pull-request":
+		// In pull request events, Drone matches the 'branch' condition against the branch that the pull request will be merged into.
+		c.Event.Include = appendUnique(c.Event.Include, "pull_request")
+		c.Branch, err = addFilters(c.Branch, e, "target-branch", "%s")
+	case "cron":
+		c.Event.Include = appendUnique(c.Event.Include, "cron")
+		c.Cron, err = addFilters(c.Cron, e, "cron", "%s")
+	case "promote":
+		c.Event.Include = appendUnique(c.Event.Include, "promote")
+		c.Target, err = addFilters(c.Target, e, "target", "%s")
+	default:
+		return c, fmt.Errorf("%w: '%s'", ErrorUnsupportedEvent, e.Name)
+	}
+
+	return c, err
+}
+
 // Events converts the list of pipeline.Events to a list of drone 'Conditions'.
 // Drone conditions are what prevents pipelines from running whenever certain certain conditions are met, or what runs pipelines only when certain conditions are met.
+// Drone only has one set of conditions for each pipeline or step, so the filters of every event are combined. For example, the branch filters of a 'git-commit' event
+// and the target branch filters of a 'pull-request' event are both added to the 'branch' condition, and apply to both commits and pull requests.
 func Events(events []pipeline.Event) (yaml.Conditions, error) {
 	conditions := yaml.Conditions{}
+
+	// Pipelines that do not define their events have a single 'git-commit' event without filters (see 'pipeline.New').
+	// They do not get any conditions so that they run on every Drone event.
+	if len(events) == 1 && events[0].Name == "git-commit" && events[0].Filters["branch"] == nil && events[0].Exclude["branch"] == nil {
+		return conditions, nil
+	}
+
 	for _, event := range events {
 		c, err := addEvent(conditions, event)
 		if err != nil {
@@ -3,13 +3,20 @@ package drone
 import (
 	"context"
 
+	"github.com/grafana/scribe/args"
 	"github.com/grafana/scribe/pipeline"
 	"github.com/grafana/scribe/pipeline/clients"
 )
 
 func New(ctx context.Context, opts clients.CommonOpts) (pipeline.Client, error) {
+	language := LanguageYAML
+	if opts.Args != nil && opts.Args.DroneLanguage == args.DroneLanguageStarlark {
+		language = LanguageStarlark
+	}
+
 	return &Client{
-		Opts: opts,
-		Log:  opts.Log,
+		Opts:     opts,
+		Log:      opts.Log,
+		Language: language,
 	}, nil
 }
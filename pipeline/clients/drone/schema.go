@@ -2,6 +2,7 @@ package drone
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/drone/drone-yaml/yaml"
@@ -50,6 +51,26 @@ func HandleSecrets(c pipeline.Configurer, step pipeline.Step) (map[string]*yaml.
 	return env, args
 }
 
+// StepEnvironment returns the Drone environment for the step's environment variables. Static values are added as they are, and secrets are added using 'from_secret'.
+// The values of other arguments are not known until the step runs, so they are left out and are added by the CLI client when it runs the step.
+func StepEnvironment(step pipeline.Step) map[string]*yaml.Variable {
+	env := make(map[string]*yaml.Variable)
+	for k, v := range step.Environment {
+		switch {
+		case v.Type == pipeline.EnvVarString:
+			env[k] = &yaml.Variable{
+				Value: v.String(),
+			}
+		case v.Argument().Type == state.ArgumentTypeSecret:
+			env[k] = &yaml.Variable{
+				Secret: v.Argument().Key,
+			}
+		}
+	}
+
+	return env
+}
+
 func stepVolumes(c pipeline.Configurer, step pipeline.Step) []*yaml.VolumeMount {
 	volumes := []*yaml.VolumeMount{}
 	// TODO: It's unlikely that we want to actually associate volume mounts with "FS" type arguments.
@@ -65,11 +86,12 @@ func stepVolumes(c pipeline.Configurer, step pipeline.Step) []*yaml.VolumeMount
 		}
 
 		// If it's a known argument...
-		value, _ := c.Value(v)
-		//if err != nil {
-		// Skip this then because it's not known. It should be provided by a different step ran previously.
-		// TODO: handle FS type arguments here?
-		//}
+		value, err := c.Value(v)
+		if err != nil {
+			// Skip this then because it's not known. It should be provided by a different step ran previously.
+			// TODO: handle FS type arguments here?
+			continue
+		}
 
 		volumes = append(volumes, &yaml.VolumeMount{
 			Name:      stringutil.Slugify(v.Key),
@@ -80,27 +102,158 @@ func stepVolumes(c pipeline.Configurer, step pipeline.Step) []*yaml.VolumeMount
 	return volumes
 }
 
+// StatusAlways is the Drone status condition that matches both successful and failed builds.
+var StatusAlways = yaml.Condition{
+	Include: []string{"success", "failure"},
+}
+
+// StepModes maps the allow-failure and always-run modes of the provided steps onto the Drone step that runs them.
+// A Drone step can run more than one Scribe step, so a mode is only mapped when every one of those steps has it.
+// The 'cli' client still applies the modes of the steps inside the Drone step, but a Drone step that has only some steps that always run does not start after an earlier Drone step failed.
+func StepModes(container *yaml.Container, steps ...pipeline.Step) {
+	if len(steps) == 0 {
+		return
+	}
+
+	allowFailure, alwaysRun := true, true
+	for _, v := range steps {
+		allowFailure = allowFailure && v.AllowFailure
+		alwaysRun = alwaysRun && v.AlwaysRun
+	}
+
+	if allowFailure {
+		container.Failure = "ignore"
+	}
+
+	if alwaysRun {
+		container.When.Status = StatusAlways
+	}
+}
+
+// StepEvents maps the events of the provided steps onto the 'when' conditions of the Drone step that runs them.
+// A Drone step can run more than one Scribe step, so the events are only mapped when every one of those steps has the same events.
+// Step conditions ('pipeline.Step.Condition') can not be represented in Drone; they are evaluated when the step runs.
+func StepEvents(container *yaml.Container, steps ...pipeline.Step) error {
+	if len(steps) == 0 || len(steps[0].Events) == 0 {
+		return nil
+	}
+
+	for _, v := range steps[1:] {
+		if !reflect.DeepEqual(v.Events, steps[0].Events) {
+			return nil
+		}
+	}
+
+	cond, err := Events(steps[0].Events)
+	if err != nil {
+		return err
+	}
+
+	// Only replace the event conditions so that the status set by 'StepModes' is kept.
+	container.When.Event = cond.Event
+	container.When.Branch = cond.Branch
+	container.When.Ref = cond.Ref
+	container.When.Cron = cond.Cron
+	container.When.Target = cond.Target
+
+	return nil
+}
+
+// NewService creates a Drone service for a background step that does not have an action, which runs the default command of the step's image.
+// The CLI client does not run in services, so only the static values and secrets in the step's environment are available to them (see 'StepEnvironment').
+func NewService(step pipeline.Step) *yaml.Container {
+	container := &yaml.Container{
+		Name:  stringutil.Slugify(step.Name),
+		Image: step.Image,
+	}
+
+	if env := StepEnvironment(step); len(env) != 0 {
+		container.Environment = env
+	}
+
+	return container
+}
+
+// NewStep creates a Drone step that runs a single Scribe step in the step's own image, using the compiled pipeline and the CLI client.
+// The secrets that the step requires and the step's environment variables are provided in its environment, and its state is shared with the other steps through the 'scribe-state' volume.
+func NewStep(c pipeline.Configurer, path, state, version string, step pipeline.Step) (*yaml.Container, error) {
+	var (
+		name    = stringutil.Slugify(step.Name)
+		volumes = stepVolumes(c, step)
+	)
+
+	env, argMap := HandleSecrets(c, step)
+	env = combineVariables(env, StepEnvironment(step))
+
+	cmd, err := cmdutil.StepCommand(cmdutil.CommandOpts{
+		Step:             step,
+		CompiledPipeline: PipelinePath,
+		PipelineArgs: args.PipelineArgs{
+			Path:     path,
+			BuildID:  "$DRONE_BUILD_NUMBER",
+			State:    state,
+			ArgMap:   argMap,
+			LogLevel: logrus.DebugLevel,
+			Version:  version,
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	container := &yaml.Container{
+		Name:     name,
+		Image:    step.Image,
+		Commands: []string{strings.Join(cmd, " ")},
+		Volumes:  volumes,
+	}
+
+	if len(env) != 0 {
+		container.Environment = env
+	}
+
+	return container, nil
+}
+
+// NewDaggerStep creates a Drone step that runs every step in the pipeline using the compiled pipeline and the CLI client.
+// The secrets that the steps require and the environment variables of the steps are provided in its environment. Because the steps share one Drone step, two steps can not set the same environment variable to different values.
 func NewDaggerStep(c pipeline.Configurer, path, state, version string, p pipeline.Pipeline) (*yaml.Container, error) {
 	var (
-		name  = stringutil.Slugify(p.Name)
-		image = "golang:1.19"
-		//volumes = stepVolumes(c, step)
+		name   = stringutil.Slugify(p.Name)
+		image  = "golang:1.19"
+		env    = map[string]*yaml.Variable{}
+		argMap = map[string]string{}
+		// setBy is the name of the step that added each environment variable.
+		setBy = map[string]string{}
 	)
-	//env, args := HandleSecrets(c, p)
 
-	//for i, v := range step.Dependencies {
-	//	deps[i] = stringutil.Slugify(v.Name)
-	//}
+	for _, step := range pipelineSteps(p) {
+		secrets, secretArgs := HandleSecrets(c, step)
+		env = combineVariables(env, secrets)
+		for k, v := range secretArgs {
+			argMap[k] = v
+		}
+
+		for k, v := range StepEnvironment(step) {
+			if e, ok := env[k]; ok && *e != *v {
+				return nil, fmt.Errorf("steps '%s' and '%s' set the environment variable '%s' to different values; use the '--drone-steps' flag to run them in separate Drone steps", setBy[k], step.Name, k)
+			}
+
+			env[k] = v
+			setBy[k] = step.Name
+		}
+	}
 
 	cmd, err := cmdutil.PipelineCommand(cmdutil.PipelineCommandOpts{
 		Pipeline: p,
 		CommandOpts: cmdutil.CommandOpts{
 			CompiledPipeline: PipelinePath,
 			PipelineArgs: args.PipelineArgs{
-				Path:    path,
-				BuildID: "$DRONE_BUILD_NUMBER",
-				State:   state,
-				//ArgMap:        args,
+				Path:     path,
+				BuildID:  "$DRONE_BUILD_NUMBER",
+				State:    state,
+				ArgMap:   argMap,
 				Client:   "cli",
 				LogLevel: logrus.DebugLevel,
 				Version:  version,
@@ -112,9 +265,15 @@ func NewDaggerStep(c pipeline.Configurer, path, state, version string, p pipelin
 		return nil, err
 	}
 
-	return &yaml.Container{
+	container := &yaml.Container{
 		Name:     name,
 		Image:    image,
 		Commands: []string{strings.Join(cmd, " ")},
-	}, nil
+	}
+
+	if len(env) != 0 {
+		container.Environment = env
+	}
+
+	return container, nil
 }
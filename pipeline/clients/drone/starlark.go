@@ -0,0 +1,278 @@
+package drone
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/drone/drone-yaml/yaml"
+	yamlv2 "gopkg.in/yaml.v2"
+)
+
+// starlarkIndent is the indentation of every level of a Starlark config, which is the same as in Python.
+const starlarkIndent = "    "
+
+// WriteStarlark writes the pipelines as a Starlark ('.drone.star') config. Every pipeline is returned by its own function, and the 'main' function returns all of them.
+// The pipelines are rendered with the same fields and order as the YAML config, so both configs always describe the same pipelines.
+func WriteStarlark(w io.Writer, pipelines []*yaml.Pipeline) error {
+	var (
+		names = make([]string, len(pipelines))
+		funcs = make([]string, len(pipelines))
+	)
+
+	for i, v := range pipelines {
+		names[i] = starlarkFunctionName(v.Name, names[:i])
+		funcs[i] = fmt.Sprintf("def %s():\n%sreturn %s\n", names[i], starlarkIndent, starlarkValue(pipelineValue(v), 1))
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("def main(ctx):\n")
+	buf.WriteString(starlarkIndent + "return [\n")
+	for _, v := range names {
+		fmt.Fprintf(buf, "%s%s(),\n", strings.Repeat(starlarkIndent, 2), v)
+	}
+	buf.WriteString(starlarkIndent + "]\n")
+
+	for _, v := range funcs {
+		buf.WriteString("\n")
+		buf.WriteString(v)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pipelineValue returns the pipeline as it is written in the YAML config by the 'pretty' package, with the keys of every map in the same order.
+// It is built from the pipeline itself rather than by reading the YAML config, because the YAML config does not quote strings like "0" or "yes", which would be read back as numbers or booleans.
+// Only the fields that this client sets are included.
+func pipelineValue(p *yaml.Pipeline) yamlv2.MapSlice {
+	platform := p.Platform
+	if platform.OS == "" && platform.Arch == "" {
+		platform = yaml.Platform{OS: "linux", Arch: "amd64"}
+	}
+
+	value := yamlv2.MapSlice{}
+	value = setValue(value, "version", p.Version)
+	value = setValue(value, "kind", p.Kind)
+	value = setValue(value, "type", p.Type)
+	value = setValue(value, "name", p.Name)
+	value = setValue(value, "platform", setValue(setValue(yamlv2.MapSlice{}, "os", platform.OS), "arch", platform.Arch))
+	value = setValue(value, "steps", containersValue(p.Steps))
+	value = setValue(value, "services", containersValue(p.Services))
+	value = setValue(value, "volumes", volumesValue(p.Volumes))
+	value = setValue(value, "trigger", conditionsValue(p.Trigger))
+	value = setValue(value, "depends_on", p.DependsOn)
+
+	return value
+}
+
+func containersValue(containers []*yaml.Container) []any {
+	value := []any{}
+	for _, v := range containers {
+		if v == nil {
+			continue
+		}
+
+		c := yamlv2.MapSlice{}
+		c = setValue(c, "name", v.Name)
+		c = setValue(c, "pull", v.Pull)
+		c = setValue(c, "image", v.Image)
+		c = setValue(c, "detach", v.Detach)
+		c = setValue(c, "entrypoint", v.Entrypoint)
+		c = setValue(c, "command", v.Command)
+		c = setValue(c, "commands", v.Commands)
+		c = setValue(c, "environment", environmentValue(v.Environment))
+		c = setValue(c, "failure", v.Failure)
+		c = setValue(c, "privileged", v.Privileged)
+		c = setValue(c, "working_dir", v.WorkingDir)
+
+		mounts := []any{}
+		for _, m := range v.Volumes {
+			mounts = append(mounts, setValue(setValue(yamlv2.MapSlice{}, "name", m.Name), "path", m.MountPath))
+		}
+		c = setValue(c, "volumes", mounts)
+		c = setValue(c, "when", conditionsValue(v.When))
+		c = setValue(c, "depends_on", v.DependsOn)
+
+		value = append(value, c)
+	}
+
+	return value
+}
+
+func environmentValue(env map[string]*yaml.Variable) yamlv2.MapSlice {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	value := yamlv2.MapSlice{}
+	for _, k := range keys {
+		v := env[k]
+		if v.Secret != "" {
+			value = append(value, yamlv2.MapItem{Key: k, Value: yamlv2.MapSlice{{Key: "from_secret", Value: v.Secret}}})
+			continue
+		}
+
+		value = setValue(value, k, v.Value)
+	}
+
+	return value
+}
+
+func volumesValue(volumes []*yaml.Volume) []any {
+	value := []any{}
+	for _, v := range volumes {
+		volume := setValue(yamlv2.MapSlice{}, "name", v.Name)
+		if v.EmptyDir != nil {
+			volume = append(volume, yamlv2.MapItem{Key: "temp", Value: setValue(yamlv2.MapSlice{}, "medium", v.EmptyDir.Medium)})
+		}
+		if v.HostPath != nil {
+			volume = append(volume, yamlv2.MapItem{Key: "host", Value: setValue(yamlv2.MapSlice{}, "path", v.HostPath.Path)})
+		}
+
+		value = append(value, volume)
+	}
+
+	return value
+}
+
+func conditionsValue(c yaml.Conditions) yamlv2.MapSlice {
+	value := yamlv2.MapSlice{}
+	for _, v := range []struct {
+		key       string
+		condition yaml.Condition
+	}{
+		{"action", c.Action},
+		{"branch", c.Branch},
+		{"cron", c.Cron},
+		{"event", c.Event},
+		{"instance", c.Instance},
+		{"paths", c.Paths},
+		{"ref", c.Ref},
+		{"repo", c.Repo},
+		{"status", c.Status},
+		{"target", c.Target},
+	} {
+		// A condition that only includes values is written as a list of them.
+		if len(v.condition.Exclude) == 0 {
+			value = setValue(value, v.key, v.condition.Include)
+			continue
+		}
+
+		condition := setValue(yamlv2.MapSlice{}, "include", v.condition.Include)
+		value = setValue(value, v.key, setValue(condition, "exclude", v.condition.Exclude))
+	}
+
+	return value
+}
+
+// setValue adds the key and value to the map, unless the value is empty. Like in the YAML config, empty values are left out.
+func setValue(m yamlv2.MapSlice, key string, value any) yamlv2.MapSlice {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return m
+		}
+	case bool:
+		if !v {
+			return m
+		}
+	case []string:
+		if len(v) == 0 {
+			return m
+		}
+
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = item
+		}
+		value = list
+	case []any:
+		if len(v) == 0 {
+			return m
+		}
+	case yamlv2.MapSlice:
+		if len(v) == 0 {
+			return m
+		}
+	}
+
+	return append(m, yamlv2.MapItem{Key: key, Value: value})
+}
+
+// starlarkFunctionName returns a name for the function that returns the pipeline with the name 'name', which is a valid identifier that is not 'main' or in 'names'.
+func starlarkFunctionName(name string, names []string) string {
+	id := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+
+		return '_'
+	}, strings.ToLower(name))
+
+	if id == "" || id == "main" || id[0] >= '0' && id[0] <= '9' {
+		id = "pipeline_" + id
+	}
+
+	used := map[string]bool{}
+	for _, v := range names {
+		used[v] = true
+	}
+
+	unique := id
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", id, i)
+	}
+
+	return unique
+}
+
+// starlarkValue formats a value that was built by pipelineValue as a Starlark expression. 'level' is the indentation level of the line that the value is on.
+func starlarkValue(value any, level int) string {
+	var (
+		indent = strings.Repeat(starlarkIndent, level)
+		inner  = indent + starlarkIndent
+	)
+
+	switch v := value.(type) {
+	case yamlv2.MapSlice:
+		if len(v) == 0 {
+			return "{}"
+		}
+
+		lines := make([]string, len(v))
+		for i, item := range v {
+			lines[i] = fmt.Sprintf("%s%s: %s,\n", inner, starlarkValue(fmt.Sprint(item.Key), level+1), starlarkValue(item.Value, level+1))
+		}
+
+		return "{\n" + strings.Join(lines, "") + indent + "}"
+	case []any:
+		if len(v) == 0 {
+			return "[]"
+		}
+
+		lines := make([]string, len(v))
+		for i, item := range v {
+			lines[i] = fmt.Sprintf("%s%s,\n", inner, starlarkValue(item, level+1))
+		}
+
+		return "[\n" + strings.Join(lines, "") + indent + "]"
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		if v {
+			return "True"
+		}
+
+		return "False"
+	case nil:
+		return "None"
+	}
+
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,366 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/cmdutil"
+	"github.com/grafana/scribe/errors"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipelineutil"
+	"github.com/grafana/scribe/state"
+	"github.com/grafana/scribe/stringutil"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	ErrorNoImage = errors.NewPipelineError("no image provided", "An image is required for all steps in GitHub Actions. You can specify one with the '.WithImage(\"name\")' function.")
+	ErrorNoName  = errors.NewPipelineError("no name provided", "A name is required for all steps in GitHub Actions. You can specify one with the '.WithName(\"name\")' function.")
+)
+
+var (
+	PipelinePath = "/var/scribe/pipeline"
+	StatePath    = "/var/scribe-state"
+	Image        = "golang:1.19"
+	RunsOn       = "ubuntu-latest"
+)
+
+// Client is the GitHub Actions implementation of the pipeline Client interface.
+// It will create a workflow, typically placed in `.github/workflows/`, that will run your pipeline the same way that it would run locally.
+// Like the Drone client, every pipeline becomes a single job that compiles the pipeline and runs it with the 'cli' client.
+// Jobs run on separate runners, so every job stores its state in its own directory and uploads it as an artifact. Jobs download the states of the jobs that they need and read them as fallback states.
+type Client struct {
+	Opts clients.CommonOpts
+
+	Log *logrus.Logger
+}
+
+// Validate ensures that your step has a name and a docker image.
+func (c *Client) Validate(step pipeline.Step) error {
+	if step.Image == "" {
+		return ErrorNoImage
+	}
+
+	if step.Name == "" {
+		return ErrorNoName
+	}
+
+	return nil
+}
+
+func secretEnv(key string) string {
+	return stringutil.Slugify(fmt.Sprintf("secret_%s", key))
+}
+
+// secretName converts the argument key into a valid GitHub secret name, which can only contain alphanumeric characters and underscores.
+// The argument 'gcs-publish-key' is read from the secret 'GCS_PUBLISH_KEY'.
+func secretName(key string) string {
+	return strings.ToUpper(stringutil.Slugify(key))
+}
+
+// pipelineSecrets returns every secret argument required by the pipeline or any of its steps, sorted by key.
+func pipelineSecrets(p pipeline.Pipeline) []state.Argument {
+	secrets := []state.Argument{}
+	add := func(args state.Arguments) {
+		for _, arg := range args {
+			if arg.Type != state.ArgumentTypeSecret || state.ArgListContains(secrets, arg) {
+				continue
+			}
+			secrets = append(secrets, arg)
+		}
+	}
+
+	add(p.RequiredArgs)
+	for _, node := range p.Graph.Nodes {
+		add(node.Value.RequiredArgs)
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Key < secrets[j].Key
+	})
+
+	return secrets
+}
+
+// HandleSecrets maps the secret arguments of the pipeline to '${{ secrets.X }}' expressions in the environment of the step.
+// The values are then provided to the pipeline using '--arg' flags that reference the environment variables.
+func HandleSecrets(p pipeline.Pipeline) (map[string]string, map[string]string) {
+	var (
+		env  = make(map[string]string)
+		args = make(map[string]string)
+	)
+
+	for _, arg := range pipelineSecrets(p) {
+		name := secretEnv(arg.Key)
+		env[name] = fmt.Sprintf("${{ secrets.%s }}", secretName(arg.Key))
+		args[arg.Key] = "$" + name
+	}
+
+	return env, args
+}
+
+// stateDir returns the directory of the state of the job. Every job has its own state, so that the states of the jobs that a job needs can be downloaded without overwriting each other.
+// The directory is the same in every job, so the paths of the files in the state are still valid after the state is downloaded.
+func stateDir(job string) string {
+	return path.Join(StatePath, job)
+}
+
+func stateURL(job string) string {
+	u := &url.URL{
+		Scheme: "file",
+		Path:   stateDir(job),
+	}
+
+	return u.String()
+}
+
+func stateArtifact(job string) string {
+	return fmt.Sprintf("scribe-state-%s", job)
+}
+
+// PipelineStep returns the step that runs the pipeline using the compiled pipeline binary.
+// The values that the pipeline requires from other pipelines are read from the 'fallback' states.
+func (c *Client) PipelineStep(p pipeline.Pipeline, state string, fallback []string) (*Step, error) {
+	env, argMap := HandleSecrets(p)
+
+	cmd, err := cmdutil.PipelineCommand(cmdutil.PipelineCommandOpts{
+		Pipeline: p,
+		CommandOpts: cmdutil.CommandOpts{
+			CompiledPipeline: PipelinePath,
+			PipelineArgs: args.PipelineArgs{
+				Path:          c.Opts.Args.Path,
+				BuildID:       "$GITHUB_RUN_NUMBER",
+				State:         state,
+				StateFallback: fallback,
+				ArgMap:        argMap,
+				Client:        "cli",
+				LogLevel:      logrus.DebugLevel,
+				Version:       c.Opts.Version,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	step := &Step{
+		Name: p.Name,
+		Run:  strings.Join(cmd, " "),
+	}
+
+	if len(env) != 0 {
+		step.Env = env
+	}
+
+	return step, nil
+}
+
+// newJob creates the job that runs the pipeline. The job downloads the states of the jobs that it needs before running the pipeline, and uploads its own state if 'upload' is true.
+func (c *Client) newJob(name, id string, run *Step, needs []string, upload bool) *Job {
+	command := pipelineutil.GoBuild(context.Background(), pipelineutil.GoBuildOpts{
+		Pipeline: c.Opts.Args.Path,
+		Output:   PipelinePath,
+	})
+
+	steps := []*Step{
+		{
+			Uses: "actions/checkout@v3",
+		},
+		{
+			Name: "builtin-compile-pipeline",
+			Run:  strings.Join(command.Args, " "),
+			Env: map[string]string{
+				"GOOS":        "linux",
+				"GOARCH":      "amd64",
+				"CGO_ENABLED": "0",
+			},
+		},
+	}
+
+	for _, v := range needs {
+		steps = append(steps, &Step{
+			Name: fmt.Sprintf("builtin-download-state-%s", v),
+			Uses: "actions/download-artifact@v3",
+			With: map[string]string{
+				"name": stateArtifact(v),
+				"path": stateDir(v),
+			},
+		})
+	}
+
+	steps = append(steps, run)
+
+	if upload {
+		steps = append(steps, &Step{
+			Name: "builtin-upload-state",
+			Uses: "actions/upload-artifact@v3",
+			With: map[string]string{
+				"name": stateArtifact(id),
+				"path": stateDir(id),
+			},
+		})
+	}
+
+	return &Job{
+		Name:   name,
+		RunsOn: RunsOn,
+		Needs:  needs,
+		Container: &Container{
+			Image: Image,
+		},
+		Steps: steps,
+	}
+}
+
+// jobNeeds returns the IDs of the jobs of the pipelines that provide the arguments that the pipeline requires.
+// The collection type has already verified that everything we are expecting exists somewhere in the graph.
+func jobNeeds(p pipeline.Pipeline, pipelines []pipeline.Pipeline) []string {
+	needs := []string{}
+	for _, arg := range p.RequiredArgs {
+		for _, v := range pipelines {
+			if !state.ArgListContains(v.ProvidedArgs, arg) {
+				continue
+			}
+
+			// A pipeline can provide more than one of the arguments, but the job only needs it once.
+			if id := stringutil.Slugify(v.Name); !slices.Contains(needs, id) {
+				needs = append(needs, id)
+			}
+			break
+		}
+	}
+
+	return needs
+}
+
+// sameEvents returns true if every pipeline is triggered by the same events. When they are, the jobs don't need their own conditions.
+func sameEvents(pipelines []pipeline.Pipeline) bool {
+	for i := 1; i < len(pipelines); i++ {
+		if !reflect.DeepEqual(pipelines[0].Events, pipelines[i].Events) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// needsConditions returns true if the jobs need their own conditions, because the pipelines are triggered by different events or because the events have exclude filters.
+func needsConditions(pipelines []pipeline.Pipeline) bool {
+	if !sameEvents(pipelines) {
+		return true
+	}
+
+	for _, v := range pipelines {
+		if hasExclusions(v.Events) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Done traverses through the tree and writes a GitHub Actions workflow to the provided writer
+func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
+	log := c.Log.WithField("client", "github")
+
+	pipelines := []pipeline.Pipeline{}
+
+	if err := w.WalkPipelines(ctx, func(ctx context.Context, p pipeline.Pipeline) error {
+		pipelines = append(pipelines, p)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var (
+		workflow = Workflow{
+			Name: c.Opts.Name,
+			Jobs: yaml.MapSlice{},
+		}
+		conditions = needsConditions(pipelines)
+	)
+
+	if workflow.Name == "" {
+		workflow.Name = "scribe"
+	}
+
+	// A job only uploads its state if another job needs it.
+	var (
+		needs  = map[int64][]string{}
+		needed = map[string]bool{}
+	)
+
+	for _, v := range pipelines {
+		if v.ID == 0 {
+			continue
+		}
+
+		needs[v.ID] = jobNeeds(v, pipelines)
+		for _, id := range needs[v.ID] {
+			needed[id] = true
+		}
+	}
+
+	for _, v := range pipelines {
+		if v.ID == 0 {
+			continue
+		}
+		log.Debugf("Processing pipeline '%s'...", v.Name)
+
+		var (
+			id       = stringutil.Slugify(v.Name)
+			fallback = make([]string, len(needs[v.ID]))
+		)
+
+		for i, need := range needs[v.ID] {
+			fallback[i] = stateURL(need)
+		}
+
+		s, err := c.PipelineStep(v, stateURL(id), fallback)
+		if err != nil {
+			return err
+		}
+
+		job := c.newJob(v.Name, id, s, needs[v.ID], needed[id])
+
+		if len(v.Events) != 0 {
+			log.Debugf("Generating with %d event filters...", len(v.Events))
+			triggers, err := AddTriggers(workflow.On, v.Events)
+			if err != nil {
+				return err
+			}
+			workflow.On = triggers
+
+			if conditions {
+				cond, err := Condition(v.Events)
+				if err != nil {
+					return err
+				}
+				job.If = cond
+			}
+		}
+
+		log.Debugf("Done processing pipeline '%s'", v.Name)
+		workflow.Jobs = append(workflow.Jobs, yaml.MapItem{
+			Key:   id,
+			Value: job,
+		})
+	}
+
+	b, err := yaml.Marshal(workflow)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Opts.Output.Write(b)
+	return err
+}
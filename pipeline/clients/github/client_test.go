@@ -0,0 +1,207 @@
+package github_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients/github"
+	"github.com/grafana/scribe/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+// testDemoPipeline tests a pipeline located in "demo" folder. the "path" argument should be relative to the demo folder in the root of the project.
+// This function will do a basic equivalency check on what is generated by running the pipeline with the github client and what is in the "gen_github.yml" file in the provided folder.
+func testDemoPipeline(t *testing.T, path string) {
+	t.Helper()
+
+	var (
+		buf          = bytes.NewBuffer(nil)
+		stderr       = bytes.NewBuffer(nil)
+		ctx          = context.Background()
+		pipelinePath = filepath.Join("../../../demo", path)
+	)
+
+	testutil.RunPipeline(ctx, t, pipelinePath, io.MultiWriter(buf, os.Stdout), stderr, &args.PipelineArgs{
+		BuildID:  "test",
+		Client:   "github",
+		Path:     fmt.Sprintf("./demo/%s", path), // Note that we're intentionally using ./demo/ instead of filepath because this path is used in a Go command.
+		LogLevel: logrus.DebugLevel,
+	})
+
+	t.Log(stderr.String())
+
+	expected, err := os.Open(filepath.Join(pipelinePath, "gen_github.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.ReadersEqual(t, buf, expected)
+}
+
+func TestGitHubClient(t *testing.T) {
+	t.Run("It should generate a simple GitHub Actions workflow",
+		testutil.WithTimeout(time.Second*10, func(t *testing.T) {
+			testDemoPipeline(t, "basic")
+		}),
+	)
+	t.Run("It should generate a workflow with multiple jobs and a sub-pipeline",
+		testutil.WithTimeout(time.Second*10, func(t *testing.T) {
+			testDemoPipeline(t, "multi-sub")
+		}),
+	)
+	t.Run("It should generate jobs that share state through artifacts",
+		testutil.WithTimeout(time.Second*10, func(t *testing.T) {
+			testDemoPipeline(t, "multi")
+		}),
+	)
+}
+
+func TestAddTriggers(t *testing.T) {
+	t.Run("It should combine branch and tag filters into the push trigger", func(t *testing.T) {
+		triggers, err := github.AddTriggers(github.Triggers{}, []pipeline.Event{
+			pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.StringFilter("main")}),
+			pipeline.GitTagEvent(pipeline.GitTagFilters{Name: pipeline.GlobFilter("v*")}),
+			pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.StringFilter("main")}),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if triggers.PullRequest != nil {
+			t.Fatal("expected no pull_request trigger")
+		}
+		if fmt.Sprint(triggers.Push.Branches) != "[main]" {
+			t.Fatal("unexpected branches:", triggers.Push.Branches)
+		}
+		if fmt.Sprint(triggers.Push.Tags) != "[v*]" {
+			t.Fatal("unexpected tags:", triggers.Push.Tags)
+		}
+	})
+	t.Run("It should match every branch if no branch filter is provided", func(t *testing.T) {
+		triggers, err := github.AddTriggers(github.Triggers{}, []pipeline.Event{
+			pipeline.GitCommitEvent(pipeline.GitCommitFilters{}),
+			pipeline.PullRequestEvent(pipeline.PullRequestFilters{}),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if triggers.PullRequest == nil {
+			t.Fatal("expected a pull_request trigger")
+		}
+		if fmt.Sprint(triggers.Push.Branches) != "[**]" {
+			t.Fatal("unexpected branches:", triggers.Push.Branches)
+		}
+	})
+	t.Run("It should add the target branches of pull requests to the pull_request trigger", func(t *testing.T) {
+		triggers, err := github.AddTriggers(github.Triggers{}, []pipeline.Event{
+			pipeline.PullRequestEvent(pipeline.PullRequestFilters{TargetBranch: pipeline.StringFilter("main")}),
+			pipeline.PullRequestEvent(pipeline.PullRequestFilters{TargetBranch: pipeline.GlobFilter("release-*")}),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fmt.Sprint(triggers.PullRequest.Branches) != "[main release-*]" {
+			t.Fatal("unexpected branches:", triggers.PullRequest.Branches)
+		}
+	})
+	t.Run("It should match pull requests into every branch if one of them has no target branch filter", func(t *testing.T) {
+		triggers, err := github.AddTriggers(github.Triggers{}, []pipeline.Event{
+			pipeline.PullRequestEvent(pipeline.PullRequestFilters{TargetBranch: pipeline.StringFilter("main")}),
+			pipeline.PullRequestEvent(pipeline.PullRequestFilters{}),
+			pipeline.PullRequestEvent(pipeline.PullRequestFilters{TargetBranch: pipeline.StringFilter("release")}),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(triggers.PullRequest.Branches) != 0 {
+			t.Fatal("unexpected branches:", triggers.PullRequest.Branches)
+		}
+	})
+	t.Run("It should return an error for unsupported events", func(t *testing.T) {
+		_, err := github.AddTriggers(github.Triggers{}, []pipeline.Event{{Name: "unknown"}})
+		testutil.EnsureError(t, err, github.ErrorUnsupportedEvent)
+	})
+}
+
+func TestCondition(t *testing.T) {
+	cases := []struct {
+		name     string
+		events   []pipeline.Event
+		expected string
+		err      error
+	}{
+		{
+			name:     "git commit without a filter",
+			events:   []pipeline.Event{pipeline.GitCommitEvent(pipeline.GitCommitFilters{})},
+			expected: "github.event_name == 'push' && startsWith(github.ref, 'refs/heads/')",
+		},
+		{
+			name:     "git commit with a string filter",
+			events:   []pipeline.Event{pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.StringFilter("main")})},
+			expected: "github.event_name == 'push' && github.ref == 'refs/heads/main'",
+		},
+		{
+			name: "git commit and git tag",
+			events: []pipeline.Event{
+				pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.StringFilter("main")}),
+				pipeline.GitTagEvent(pipeline.GitTagFilters{Name: pipeline.GlobFilter("v*")}),
+			},
+			expected: "(github.event_name == 'push' && github.ref == 'refs/heads/main') || (github.event_name == 'push' && startsWith(github.ref, 'refs/tags/v'))",
+		},
+		{
+			name:     "pull request",
+			events:   []pipeline.Event{pipeline.PullRequestEvent(pipeline.PullRequestFilters{})},
+			expected: "github.event_name == 'pull_request'",
+		},
+		{
+			name:     "git commit with an exclude filter",
+			events:   []pipeline.Event{pipeline.GitCommitEvent(pipeline.GitCommitFilters{ExcludeBranch: pipeline.GlobFilter("release-*")})},
+			expected: "github.event_name == 'push' && startsWith(github.ref, 'refs/heads/') && !(startsWith(github.ref, 'refs/heads/release-'))",
+		},
+		{
+			name: "pull request with target branch filters",
+			events: []pipeline.Event{pipeline.PullRequestEvent(pipeline.PullRequestFilters{
+				TargetBranch:        pipeline.GlobFilter("release-*"),
+				ExcludeTargetBranch: pipeline.StringFilter("release-0"),
+			})},
+			expected: "github.event_name == 'pull_request' && startsWith(github.base_ref, 'release-') && !(github.base_ref == 'release-0')",
+		},
+		{
+			name:   "exclude filter that can't be expressed",
+			events: []pipeline.Event{pipeline.GitCommitEvent(pipeline.GitCommitFilters{ExcludeBranch: pipeline.GlobFilter("release-*-rc")})},
+			err:    github.ErrorUnsupportedFilter,
+		},
+		{
+			name:   "glob that can't be expressed",
+			events: []pipeline.Event{pipeline.GitTagEvent(pipeline.GitTagFilters{Name: pipeline.GlobFilter("v*-beta")})},
+			err:    github.ErrorUnsupportedFilter,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cond, err := github.Condition(c.events)
+			if c.err != nil {
+				testutil.EnsureError(t, err, c.err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cond != c.expected {
+				t.Fatalf("unexpected condition.\nexpected: %s\nreceived: %s", c.expected, cond)
+			}
+		})
+	}
+}
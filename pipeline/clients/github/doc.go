@@ -0,0 +1,5 @@
+// Package github contains the GitHub Actions client implementation for generating a GitHub Actions workflow.
+//
+// Every pipeline is a job in a single workflow, so the 'on' triggers of the workflow match the events of every pipeline.
+// When the pipelines are triggered by different events, or when an event has exclude filters, every job has a condition ('if') that only matches the events of its pipeline.
+package github
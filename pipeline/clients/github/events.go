@@ -0,0 +1,245 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/scribe/pipeline"
+)
+
+var (
+	ErrorUnsupportedEvent  = errors.New("event is not supported by GitHub Actions")
+	ErrorUnsupportedFilter = errors.New("filter is not supported by GitHub Actions")
+)
+
+const (
+	refBranchPrefix = "refs/heads/"
+	refTagPrefix    = "refs/tags/"
+)
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+
+	return append(list, value)
+}
+
+// filterPattern converts a FilterValue into a GitHub Actions filter pattern. Patterns for branches and tags are glob-like, so regular expressions are not supported.
+// If the filter is nil, then the pattern matches every branch or tag.
+func filterPattern(f *pipeline.FilterValue) (string, error) {
+	if f == nil {
+		return "**", nil
+	}
+
+	if f.Type == pipeline.FilterValueRegex {
+		return "", fmt.Errorf("%w: regular expression '%s'", ErrorUnsupportedFilter, f.String())
+	}
+
+	return f.String(), nil
+}
+
+func addTrigger(t Triggers, e pipeline.Event) (Triggers, error) {
+	switch e.Name {
+	case "git-commit":
+		branch, err := filterPattern(e.Filters["branch"])
+		if err != nil {
+			return t, err
+		}
+		if t.Push == nil {
+			t.Push = &PushTrigger{}
+		}
+		t.Push.Branches = appendUnique(t.Push.Branches, branch)
+	case "git-tag":
+		tag, err := filterPattern(e.Filters["tag"])
+		if err != nil {
+			return t, err
+		}
+		if t.Push == nil {
+			t.Push = &PushTrigger{}
+		}
+		t.Push.Tags = appendUnique(t.Push.Tags, tag)
+	case "pull-request":
+		branch, err := filterPattern(e.Filters["target-branch"])
+		if err != nil {
+			return t, err
+		}
+
+		// A pull request trigger without branches matches pull requests into every branch.
+		if t.PullRequest == nil {
+			t.PullRequest = &PullRequestTrigger{}
+			if branch != "**" {
+				t.PullRequest.Branches = []string{branch}
+			}
+			break
+		}
+
+		if len(t.PullRequest.Branches) == 0 || branch == "**" {
+			t.PullRequest.Branches = nil
+			break
+		}
+
+		t.PullRequest.Branches = appendUnique(t.PullRequest.Branches, branch)
+	default:
+		return t, fmt.Errorf("%w: '%s'", ErrorUnsupportedEvent, e.Name)
+	}
+
+	return t, nil
+}
+
+// AddTriggers adds the list of pipeline.Events to the 'on' section of a workflow.
+// Because there is only one 'on' section per workflow, the triggers for every pipeline are combined. See 'Condition' for how each job filters these events.
+func AddTriggers(t Triggers, events []pipeline.Event) (Triggers, error) {
+	for _, event := range events {
+		v, err := addTrigger(t, event)
+		if err != nil {
+			return Triggers{}, err
+		}
+
+		t = v
+	}
+
+	return t, nil
+}
+
+func quote(s string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", "''"))
+}
+
+// refCondition returns an expression that checks 'github.ref' against the filter.
+func refCondition(prefix string, f *pipeline.FilterValue) (string, error) {
+	if f == nil {
+		return fmt.Sprintf("startsWith(github.ref, %s)", quote(prefix)), nil
+	}
+
+	return matchCondition("github.ref", prefix, f)
+}
+
+// matchCondition returns an expression that checks the value of the context against the filter, after adding 'prefix' to the filter.
+// Expressions don't support patterns, so only exact values and globs with a single trailing '*' can be converted.
+func matchCondition(context, prefix string, f *pipeline.FilterValue) (string, error) {
+	v := f.String()
+	switch f.Type {
+	case pipeline.FilterValueString:
+		return fmt.Sprintf("%s == %s", context, quote(prefix+v)), nil
+	case pipeline.FilterValueGlob:
+		trimmed := strings.TrimSuffix(v, "*")
+		if !strings.ContainsAny(trimmed, "*?[]+!") {
+			if trimmed == v {
+				return fmt.Sprintf("%s == %s", context, quote(prefix+v)), nil
+			}
+			return fmt.Sprintf("startsWith(%s, %s)", context, quote(prefix+trimmed)), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: '%s' can not be used in a job condition", ErrorUnsupportedFilter, v)
+}
+
+// excludeCondition returns an expression that checks that the value of the context does not match the exclude filter.
+// If the filter is nil, then an empty string is returned.
+func excludeCondition(context, prefix string, f *pipeline.FilterValue) (string, error) {
+	if f == nil {
+		return "", nil
+	}
+
+	c, err := matchCondition(context, prefix, f)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("!(%s)", c), nil
+}
+
+// and joins the expressions that are not empty with '&&'.
+func and(conditions ...string) string {
+	c := []string{}
+	for _, v := range conditions {
+		if v != "" {
+			c = append(c, v)
+		}
+	}
+
+	return strings.Join(c, " && ")
+}
+
+func eventCondition(e pipeline.Event) (string, error) {
+	switch e.Name {
+	case "git-commit":
+		return pushCondition(refBranchPrefix, e.Filters["branch"], e.Exclude["branch"])
+	case "git-tag":
+		return pushCondition(refTagPrefix, e.Filters["tag"], e.Exclude["tag"])
+	case "pull-request":
+		// 'github.base_ref' is the name of the branch that the pull request will be merged into.
+		var target string
+		if f := e.Filters["target-branch"]; f != nil {
+			c, err := matchCondition("github.base_ref", "", f)
+			if err != nil {
+				return "", err
+			}
+			target = c
+		}
+
+		exclude, err := excludeCondition("github.base_ref", "", e.Exclude["target-branch"])
+		if err != nil {
+			return "", err
+		}
+
+		return and("github.event_name == 'pull_request'", target, exclude), nil
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrorUnsupportedEvent, e.Name)
+}
+
+// pushCondition returns the condition for a push of a branch or tag whose ref starts with 'prefix'.
+func pushCondition(prefix string, filter, exclude *pipeline.FilterValue) (string, error) {
+	ref, err := refCondition(prefix, filter)
+	if err != nil {
+		return "", err
+	}
+
+	excluded, err := excludeCondition("github.ref", prefix, exclude)
+	if err != nil {
+		return "", err
+	}
+
+	return and("github.event_name == 'push'", ref, excluded), nil
+}
+
+// hasExclusions returns true if any of the events has exclude filters.
+// Triggers are shared by every job in the workflow, so exclude filters can only be applied by job conditions (see 'Condition').
+func hasExclusions(events []pipeline.Event) bool {
+	for _, v := range events {
+		if len(v.Exclude) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Condition converts the list of pipeline.Events to an expression used in the 'if' of a job.
+// This is used when pipelines in the same workflow don't share the same events, so that each job only runs for the events of its pipeline.
+func Condition(events []pipeline.Event) (string, error) {
+	conditions := make([]string, len(events))
+	for i, event := range events {
+		c, err := eventCondition(event)
+		if err != nil {
+			return "", err
+		}
+
+		conditions[i] = c
+	}
+
+	if len(conditions) == 1 {
+		return conditions[0], nil
+	}
+
+	for i, v := range conditions {
+		conditions[i] = fmt.Sprintf("(%s)", v)
+	}
+
+	return strings.Join(conditions, " || "), nil
+}
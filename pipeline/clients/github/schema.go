@@ -0,0 +1,50 @@
+package github
+
+import "gopkg.in/yaml.v2"
+
+// Workflow is a GitHub Actions workflow definition. Its fields are ordered the way they are typically written in a `.github/workflows/*.yml` file.
+// See https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions.
+type Workflow struct {
+	Name string   `yaml:"name"`
+	On   Triggers `yaml:"on"`
+	// Jobs is a yaml.MapSlice of job IDs to *Job so that the jobs are written in the same order as the pipelines.
+	Jobs yaml.MapSlice `yaml:"jobs"`
+}
+
+// Triggers defines the 'on' section of a workflow.
+type Triggers struct {
+	Push        *PushTrigger        `yaml:"push,omitempty"`
+	PullRequest *PullRequestTrigger `yaml:"pull_request,omitempty"`
+}
+
+type PushTrigger struct {
+	Branches []string `yaml:"branches,omitempty"`
+	Tags     []string `yaml:"tags,omitempty"`
+}
+
+type PullRequestTrigger struct {
+	Branches []string `yaml:"branches,omitempty"`
+}
+
+// Job is a single job in a workflow. Each Scribe pipeline is one Job.
+type Job struct {
+	Name      string     `yaml:"name"`
+	RunsOn    string     `yaml:"runs-on"`
+	Needs     []string   `yaml:"needs,omitempty"`
+	If        string     `yaml:"if,omitempty"`
+	Container *Container `yaml:"container,omitempty"`
+	Steps     []*Step    `yaml:"steps"`
+}
+
+type Container struct {
+	Image string `yaml:"image"`
+}
+
+// Step is a single step in a Job. A Step either 'uses' an action or 'runs' a command.
+type Step struct {
+	Name string            `yaml:"name,omitempty"`
+	Uses string            `yaml:"uses,omitempty"`
+	With map[string]string `yaml:"with,omitempty"`
+	Run  string            `yaml:"run,omitempty"`
+	Env  map[string]string `yaml:"env,omitempty"`
+}
@@ -0,0 +1,290 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/cmdutil"
+	"github.com/grafana/scribe/errors"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipelineutil"
+	"github.com/grafana/scribe/state"
+	"github.com/grafana/scribe/stringutil"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	ErrorNoImage = errors.NewPipelineError("no image provided", "An image is required for all steps in GitLab CI. You can specify one with the '.WithImage(\"name\")' function.")
+	ErrorNoName  = errors.NewPipelineError("no name provided", "A name is required for all steps in GitLab CI. You can specify one with the '.WithName(\"name\")' function.")
+)
+
+var (
+	// PipelinePath is relative to the project directory because GitLab artifacts can only be uploaded from within it.
+	PipelinePath = ".scribe/pipeline"
+	// StatePath is relative to the project directory for the same reason.
+	StatePath  = ".scribe/state"
+	Image      = "golang:1.19"
+	CompileJob = "builtin-compile-pipeline"
+)
+
+// Client is the GitLab CI implementation of the pipeline Client interface.
+// It will create a `.gitlab-ci.yml` file that will run your pipeline the same way that it would run locally.
+// The pipeline is compiled once in the 'builtin-compile-pipeline' job and passed to every other job as an artifact. Each pipeline is a single job that runs the compiled pipeline with the 'cli' client.
+// Every job stores its state in its own directory and uploads it as an artifact. GitLab downloads the artifacts of the jobs that a job needs, which the job reads as fallback states.
+type Client struct {
+	Opts clients.CommonOpts
+
+	Log *logrus.Logger
+}
+
+// Validate ensures that your step has a name and a docker image.
+func (c *Client) Validate(step pipeline.Step) error {
+	if step.Image == "" {
+		return ErrorNoImage
+	}
+
+	if step.Name == "" {
+		return ErrorNoName
+	}
+
+	return nil
+}
+
+// secretVariable converts the argument key into the name of the CI/CD variable that holds the secret.
+// The argument 'gcs-publish-key' is read from the variable 'GCS_PUBLISH_KEY'.
+func secretVariable(key string) string {
+	return strings.ToUpper(stringutil.Slugify(key))
+}
+
+// HandleSecrets returns the '--arg' values for every secret argument required by the pipeline or any of its steps.
+// GitLab exposes CI/CD variables as environment variables, so the values reference the variable directly.
+func HandleSecrets(p pipeline.Pipeline) map[string]string {
+	args := map[string]string{}
+	add := func(arguments state.Arguments) {
+		for _, arg := range arguments {
+			if arg.Type != state.ArgumentTypeSecret {
+				continue
+			}
+			args[arg.Key] = "$" + secretVariable(arg.Key)
+		}
+	}
+
+	add(p.RequiredArgs)
+	for _, node := range p.Graph.Nodes {
+		add(node.Value.RequiredArgs)
+	}
+
+	return args
+}
+
+// stateDir returns the directory of the state of the job, relative to the project directory.
+func stateDir(job string) string {
+	return path.Join(StatePath, job)
+}
+
+// stateURL returns the URL of the state of the job. The path of the project directory is only known when the job runs, so it is read from the 'CI_PROJECT_DIR' variable.
+func stateURL(job string) string {
+	u := &url.URL{
+		Scheme: "file",
+		Path:   path.Join("$CI_PROJECT_DIR", stateDir(job)),
+	}
+
+	return u.String()
+}
+
+// jobNeeds returns the names of the jobs of the pipelines that provide the arguments that the pipeline requires.
+// The collection type has already verified that everything we are expecting exists somewhere in the graph.
+func jobNeeds(p pipeline.Pipeline, pipelines []pipeline.Pipeline) []string {
+	needs := []string{}
+	for _, arg := range p.RequiredArgs {
+		for _, v := range pipelines {
+			if !state.ArgListContains(v.ProvidedArgs, arg) {
+				continue
+			}
+
+			// A pipeline can provide more than one of the arguments, but the job only needs it once.
+			if name := stringutil.Slugify(v.Name); !slices.Contains(needs, name) {
+				needs = append(needs, name)
+			}
+			break
+		}
+	}
+
+	return needs
+}
+
+// PipelineJob returns the job that runs the pipeline using the compiled pipeline binary.
+// The pipeline writes its state to the directory of the job and reads the values that it requires from the states of the jobs that it needs.
+func (c *Client) PipelineJob(p pipeline.Pipeline, needs []string) (*Job, error) {
+	fallback := make([]string, len(needs))
+	for i, v := range needs {
+		fallback[i] = stateURL(v)
+	}
+
+	cmd, err := cmdutil.PipelineCommand(cmdutil.PipelineCommandOpts{
+		Pipeline: p,
+		CommandOpts: cmdutil.CommandOpts{
+			CompiledPipeline: "./" + PipelinePath,
+			PipelineArgs: args.PipelineArgs{
+				Path:          c.Opts.Args.Path,
+				BuildID:       "$CI_PIPELINE_IID",
+				State:         stateURL(stringutil.Slugify(p.Name)),
+				StateFallback: fallback,
+				ArgMap:        HandleSecrets(p),
+				Client:        "cli",
+				LogLevel:      logrus.DebugLevel,
+				Version:       c.Opts.Version,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Job{
+		Image:  Image,
+		Needs:  append([]string{CompileJob}, needs...),
+		Script: []string{strings.Join(cmd, " ")},
+	}, nil
+}
+
+// compileJob returns the job that compiles the pipeline and uploads it as an artifact.
+func (c *Client) compileJob() *Job {
+	command := pipelineutil.GoBuild(context.Background(), pipelineutil.GoBuildOpts{
+		Pipeline: c.Opts.Args.Path,
+		Output:   PipelinePath,
+	})
+
+	return &Job{
+		Image: Image,
+		Variables: map[string]string{
+			"GOOS":        "linux",
+			"GOARCH":      "amd64",
+			"CGO_ENABLED": "0",
+		},
+		Script: []string{strings.Join(command.Args, " ")},
+		Artifacts: &Artifacts{
+			Paths: []string{PipelinePath},
+		},
+	}
+}
+
+// combineRules returns every unique rule in the list of jobs. If a job has no rules then it is always added, so the returned list is empty.
+func combineRules(jobs []*Job) []*Rule {
+	var (
+		rules = []*Rule{}
+		seen  = map[string]bool{}
+	)
+
+	for _, job := range jobs {
+		if len(job.Rules) == 0 {
+			return nil
+		}
+		for _, r := range job.Rules {
+			if seen[r.If] {
+				continue
+			}
+			seen[r.If] = true
+			rules = append(rules, r)
+		}
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].If < rules[j].If
+	})
+
+	return rules
+}
+
+// Done traverses through the tree and writes a .gitlab-ci.yml file to the provided writer
+func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
+	log := c.Log.WithField("client", "gitlab")
+
+	pipelines := []pipeline.Pipeline{}
+
+	if err := w.WalkPipelines(ctx, func(ctx context.Context, p pipeline.Pipeline) error {
+		pipelines = append(pipelines, p)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var (
+		names = []string{}
+		jobs  = []*Job{}
+		// A job only uploads its state if another job needs it.
+		needs  = map[int64][]string{}
+		needed = map[string]bool{}
+	)
+
+	for _, v := range pipelines {
+		if v.ID == 0 {
+			continue
+		}
+
+		needs[v.ID] = jobNeeds(v, pipelines)
+		for _, name := range needs[v.ID] {
+			needed[name] = true
+		}
+	}
+
+	for _, v := range pipelines {
+		if v.ID == 0 {
+			continue
+		}
+		log.Debugf("Processing pipeline '%s'...", v.Name)
+
+		name := stringutil.Slugify(v.Name)
+		job, err := c.PipelineJob(v, needs[v.ID])
+		if err != nil {
+			return err
+		}
+
+		if needed[name] {
+			job.Artifacts = &Artifacts{
+				Paths: []string{stateDir(name)},
+			}
+		}
+
+		if len(v.Events) != 0 {
+			log.Debugf("Generating with %d event filters...", len(v.Events))
+			rules, err := Rules(v.Events)
+			if err != nil {
+				return err
+			}
+
+			job.Rules = rules
+		}
+
+		log.Debugf("Done processing pipeline '%s'", v.Name)
+		names = append(names, name)
+		jobs = append(jobs, job)
+	}
+
+	compile := c.compileJob()
+	compile.Rules = combineRules(jobs)
+
+	cfg := yaml.MapSlice{{Key: CompileJob, Value: compile}}
+	for i := range jobs {
+		cfg = append(cfg, yaml.MapItem{
+			Key:   names[i],
+			Value: jobs[i],
+		})
+	}
+
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("error encoding .gitlab-ci.yml: %w", err)
+	}
+
+	_, err = c.Opts.Output.Write(b)
+	return err
+}
@@ -0,0 +1,138 @@
+package gitlab_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients/gitlab"
+	"github.com/grafana/scribe/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+// testDemoPipeline tests a pipeline located in "demo" folder. the "path" argument should be relative to the demo folder in the root of the project.
+// This function will do a basic equivalency check on what is generated by running the pipeline with the gitlab client and what is in the "gen_gitlab.yml" file in the provided folder.
+func testDemoPipeline(t *testing.T, path string) {
+	t.Helper()
+
+	var (
+		buf          = bytes.NewBuffer(nil)
+		stderr       = bytes.NewBuffer(nil)
+		ctx          = context.Background()
+		pipelinePath = filepath.Join("../../../demo", path)
+	)
+
+	testutil.RunPipeline(ctx, t, pipelinePath, io.MultiWriter(buf, os.Stdout), stderr, &args.PipelineArgs{
+		BuildID:  "test",
+		Client:   "gitlab",
+		Path:     fmt.Sprintf("./demo/%s", path), // Note that we're intentionally using ./demo/ instead of filepath because this path is used in a Go command.
+		LogLevel: logrus.DebugLevel,
+	})
+
+	t.Log(stderr.String())
+
+	expected, err := os.Open(filepath.Join(pipelinePath, "gen_gitlab.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.ReadersEqual(t, buf, expected)
+}
+
+func TestGitLabClient(t *testing.T) {
+	t.Run("It should generate a simple GitLab CI configuration",
+		testutil.WithTimeout(time.Second*10, func(t *testing.T) {
+			testDemoPipeline(t, "basic")
+		}),
+	)
+	t.Run("It should generate a more complex multi GitLab CI configuration",
+		testutil.WithTimeout(time.Second*10, func(t *testing.T) {
+			testDemoPipeline(t, "multi")
+		}),
+	)
+	t.Run("It should generate a GitLab CI configuration with a sub-pipeline",
+		testutil.WithTimeout(time.Second*10, func(t *testing.T) {
+			testDemoPipeline(t, "multi-sub")
+		}),
+	)
+}
+
+func TestRules(t *testing.T) {
+	cases := []struct {
+		name     string
+		event    pipeline.Event
+		expected string
+	}{
+		{
+			name:     "git commit without a filter",
+			event:    pipeline.GitCommitEvent(pipeline.GitCommitFilters{}),
+			expected: "$CI_COMMIT_BRANCH",
+		},
+		{
+			name:     "git commit with a string filter",
+			event:    pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.StringFilter("main")}),
+			expected: `$CI_COMMIT_BRANCH == "main"`,
+		},
+		{
+			name:     "git commit with a glob filter",
+			event:    pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.GlobFilter("release/v?.*")}),
+			expected: `$CI_COMMIT_BRANCH =~ /^release\/v.\..*$/`,
+		},
+		{
+			name:     "git tag with a regex filter",
+			event:    pipeline.GitTagEvent(pipeline.GitTagFilters{Name: pipeline.RegexpFilter(regexp.MustCompile(`^v[0-9]+`))}),
+			expected: "$CI_COMMIT_TAG =~ /^v[0-9]+/",
+		},
+		{
+			name:     "pull request",
+			event:    pipeline.PullRequestEvent(pipeline.PullRequestFilters{}),
+			expected: `$CI_PIPELINE_SOURCE == "merge_request_event"`,
+		},
+		{
+			name:     "git commit with an exclude filter",
+			event:    pipeline.GitCommitEvent(pipeline.GitCommitFilters{ExcludeBranch: pipeline.GlobFilter("release-*")}),
+			expected: `$CI_COMMIT_BRANCH && $CI_COMMIT_BRANCH !~ /^release-.*$/`,
+		},
+		{
+			name:     "git tag with an exclude filter",
+			event:    pipeline.GitTagEvent(pipeline.GitTagFilters{Name: pipeline.GlobFilter("v*"), ExcludeName: pipeline.StringFilter("v0.0.0")}),
+			expected: `$CI_COMMIT_TAG =~ /^v.*$/ && $CI_COMMIT_TAG != "v0.0.0"`,
+		},
+		{
+			name: "pull request with target branch filters",
+			event: pipeline.PullRequestEvent(pipeline.PullRequestFilters{
+				TargetBranch:        pipeline.GlobFilter("release-*"),
+				ExcludeTargetBranch: pipeline.StringFilter("release-0"),
+			}),
+			expected: `$CI_PIPELINE_SOURCE == "merge_request_event" && $CI_MERGE_REQUEST_TARGET_BRANCH_NAME =~ /^release-.*$/ && $CI_MERGE_REQUEST_TARGET_BRANCH_NAME != "release-0"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rules, err := gitlab.Rules([]pipeline.Event{c.event})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rules) != 1 {
+				t.Fatalf("expected 1 rule but received %d", len(rules))
+			}
+			if rules[0].If != c.expected {
+				t.Fatalf("unexpected rule.\nexpected: %s\nreceived: %s", c.expected, rules[0].If)
+			}
+		})
+	}
+
+	t.Run("It should return an error for unsupported events", func(t *testing.T) {
+		_, err := gitlab.Rules([]pipeline.Event{{Name: "unknown"}})
+		testutil.EnsureError(t, err, gitlab.ErrorUnsupportedEvent)
+	})
+}
@@ -0,0 +1,2 @@
+// Package gitlab contains the GitLab CI client implementation for generating a `.gitlab-ci.yml` file.
+package gitlab
@@ -0,0 +1,110 @@
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/scribe/pipeline"
+)
+
+var (
+	ErrorUnsupportedEvent = errors.New("event is not supported by GitLab CI")
+)
+
+// variableCondition returns an expression that checks the predefined variable against the filter.
+// If the filter is nil, then the expression only checks that the variable is set.
+func variableCondition(variable string, f *pipeline.FilterValue) string {
+	if f == nil {
+		return variable
+	}
+
+	return matchCondition(variable, f, "==", "=~")
+}
+
+// excludeCondition returns an expression that checks that the predefined variable does not match the exclude filter.
+// If the filter is nil, then an empty string is returned.
+func excludeCondition(variable string, f *pipeline.FilterValue) string {
+	if f == nil {
+		return ""
+	}
+
+	return matchCondition(variable, f, "!=", "!~")
+}
+
+// matchCondition compares the variable to the filter using 'equal' for exact values and 'match' for patterns.
+func matchCondition(variable string, f *pipeline.FilterValue, equal, match string) string {
+	var pattern string
+	switch f.Type {
+	case pipeline.FilterValueRegex:
+		pattern = f.String()
+	case pipeline.FilterValueGlob:
+		pattern = pipeline.GlobRegexp(f.String())
+	default:
+		return fmt.Sprintf("%s %s %q", variable, equal, f.String())
+	}
+
+	return fmt.Sprintf("%s %s /%s/", variable, match, strings.ReplaceAll(pattern, "/", `\/`))
+}
+
+// and joins the expressions that are not empty with '&&'.
+func and(conditions ...string) string {
+	c := []string{}
+	for _, v := range conditions {
+		if v != "" {
+			c = append(c, v)
+		}
+	}
+
+	return strings.Join(c, " && ")
+}
+
+func eventRule(e pipeline.Event) (*Rule, error) {
+	switch e.Name {
+	case "git-commit":
+		return &Rule{
+			If: and(
+				variableCondition("$CI_COMMIT_BRANCH", e.Filters["branch"]),
+				excludeCondition("$CI_COMMIT_BRANCH", e.Exclude["branch"]),
+			),
+		}, nil
+	case "git-tag":
+		return &Rule{
+			If: and(
+				variableCondition("$CI_COMMIT_TAG", e.Filters["tag"]),
+				excludeCondition("$CI_COMMIT_TAG", e.Exclude["tag"]),
+			),
+		}, nil
+	case "pull-request":
+		target := ""
+		if f := e.Filters["target-branch"]; f != nil {
+			target = variableCondition("$CI_MERGE_REQUEST_TARGET_BRANCH_NAME", f)
+		}
+
+		return &Rule{
+			If: and(
+				`$CI_PIPELINE_SOURCE == "merge_request_event"`,
+				target,
+				excludeCondition("$CI_MERGE_REQUEST_TARGET_BRANCH_NAME", e.Exclude["target-branch"]),
+			),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: '%s'", ErrorUnsupportedEvent, e.Name)
+}
+
+// Rules converts the list of pipeline.Events to a list of GitLab job 'rules'.
+// A job is only added to the GitLab pipeline when at least one of its rules matches.
+func Rules(events []pipeline.Event) ([]*Rule, error) {
+	rules := make([]*Rule, len(events))
+	for i, event := range events {
+		r, err := eventRule(event)
+		if err != nil {
+			return nil, err
+		}
+
+		rules[i] = r
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,21 @@
+package gitlab
+
+// Job is a single job in a `.gitlab-ci.yml` file. Each Scribe pipeline is one Job.
+// See https://docs.gitlab.com/ee/ci/yaml/.
+type Job struct {
+	Image     string            `yaml:"image"`
+	Needs     []string          `yaml:"needs,omitempty"`
+	Variables map[string]string `yaml:"variables,omitempty"`
+	Script    []string          `yaml:"script"`
+	Artifacts *Artifacts        `yaml:"artifacts,omitempty"`
+	Rules     []*Rule           `yaml:"rules,omitempty"`
+}
+
+type Artifacts struct {
+	Paths []string `yaml:"paths"`
+}
+
+// Rule is a single entry in the 'rules' of a job. The job is added to the pipeline if any of its rules match.
+type Rule struct {
+	If string `yaml:"if"`
+}
@@ -1,13 +1,22 @@
 package graphviz
 
 import (
+	"bufio"
 	"context"
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/grafana/scribe/pipeline"
 	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipeline/clients/common"
+	"github.com/grafana/scribe/state"
+	"github.com/grafana/scribe/stringutil"
 )
 
+// Client is the Graphviz implementation of the pipeline Client interface.
+// It writes a DOT document to the provided writer where every pipeline is a cluster and every step is a node.
+// The document can be rendered with 'dot', for example: `scribe -client graphviz ./ci | dot -Tsvg > pipeline.svg`.
 type Client struct {
 	Stdout io.Writer
 }
@@ -18,6 +27,95 @@ func New(ctx context.Context, opts clients.CommonOpts) (pipeline.Client, error)
 	}, nil
 }
 
+func pipelineNodeID(p pipeline.Pipeline) string {
+	return fmt.Sprintf("pipeline_%d", p.ID)
+}
+
+func stepNodeID(s pipeline.Step) string {
+	return fmt.Sprintf("step_%d", s.ID)
+}
+
+func secretNodeID(p pipeline.Pipeline, arg state.Argument) string {
+	return fmt.Sprintf("secret_%d_%s", p.ID, stringutil.Slugify(arg.Key))
+}
+
+// edgeLabel returns the keys of the arguments that link the two nodes of the edge.
+func edgeLabel(args state.Arguments) string {
+	return strings.Join(common.ArgKeys(args), ", ")
+}
+
+func writeAttrs(w io.Writer, attrs [][2]string) {
+	values := make([]string, len(attrs))
+	for i, v := range attrs {
+		values[i] = fmt.Sprintf("%s=%q", v[0], v[1])
+	}
+
+	fmt.Fprintf(w, " [%s];\n", strings.Join(values, ", "))
+}
+
+func writeStep(w io.Writer, s pipeline.Step) {
+	attrs := [][2]string{{"label", s.Name}}
+	if s.Image != "" {
+		attrs[0][1] = fmt.Sprintf("%s\n%s", s.Name, s.Image)
+	}
+	if s.Type == pipeline.StepTypeBackground {
+		attrs = append(attrs, [2]string{"style", "dashed,rounded"})
+	}
+
+	fmt.Fprintf(w, "    %q", stepNodeID(s))
+	writeAttrs(w, attrs)
+}
+
+// writePipeline writes the cluster for a single pipeline.
+// Every cluster has an invisible node that is used to connect pipelines, which allows edges between pipelines that have no steps.
+func writePipeline(w io.Writer, p pipeline.Pipeline) {
+	fmt.Fprintf(w, "  subgraph %q {\n", fmt.Sprintf("cluster_%d", p.ID))
+	fmt.Fprintf(w, "    label=%q;\n", p.Name)
+	if p.Type == pipeline.PipelineTypeSub {
+		fmt.Fprintln(w, `    style="dashed";`)
+	}
+
+	fmt.Fprintf(w, "    %q", pipelineNodeID(p))
+	writeAttrs(w, [][2]string{{"shape", "point"}, {"style", "invis"}})
+
+	secrets := []state.Argument{}
+	for _, node := range p.Graph.Nodes {
+		if node.ID == 0 {
+			continue
+		}
+		writeStep(w, node.Value)
+		for _, arg := range node.Value.RequiredArgs {
+			if arg.Type == state.ArgumentTypeSecret && !state.ArgListContains(secrets, arg) {
+				secrets = append(secrets, arg)
+			}
+		}
+	}
+
+	// Secrets are not provided by any step, so they are drawn as their own nodes that point to the steps that require them.
+	for _, arg := range secrets {
+		fmt.Fprintf(w, "    %q", secretNodeID(p, arg))
+		writeAttrs(w, [][2]string{{"label", arg.Key}, {"shape", "note"}, {"style", "dashed"}, {"color", "red"}})
+	}
+
+	for _, e := range common.WithoutRoot(common.StepEdges(p.Graph)) {
+		fmt.Fprintf(w, "    %q -> %q", stepNodeID(e.From.Value), stepNodeID(e.To.Value))
+		writeAttrs(w, [][2]string{{"label", edgeLabel(e.Args)}})
+	}
+
+	for _, node := range p.Graph.Nodes {
+		for _, arg := range node.Value.RequiredArgs {
+			if arg.Type != state.ArgumentTypeSecret {
+				continue
+			}
+			fmt.Fprintf(w, "    %q -> %q", secretNodeID(p, arg), stepNodeID(node.Value))
+			writeAttrs(w, [][2]string{{"style", "dashed"}, {"color", "red"}})
+		}
+	}
+
+	fmt.Fprintln(w, "  }")
+}
+
+// Done writes the collection as a DOT document. The edges must already be built, which is done in 'Scribe.Execute' before 'Done' is called.
 func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
 	pipelines := []pipeline.Pipeline{}
 	if err := w.WalkPipelines(ctx, func(ctx context.Context, p pipeline.Pipeline) error {
@@ -27,7 +125,29 @@ func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
 		return err
 	}
 
-	return nil
+	buf := bufio.NewWriter(c.Stdout)
+
+	fmt.Fprintln(buf, "digraph scribe {")
+	fmt.Fprintln(buf, "  compound=true;")
+	fmt.Fprintln(buf, `  node [shape="box"];`)
+
+	for _, p := range pipelines {
+		writePipeline(buf, p)
+	}
+
+	for _, e := range common.WithoutRoot(common.PipelineEdges(w.Graph)) {
+		from, to := e.From.Value, e.To.Value
+		fmt.Fprintf(buf, "  %q -> %q", pipelineNodeID(from), pipelineNodeID(to))
+		writeAttrs(buf, [][2]string{
+			{"label", edgeLabel(e.Args)},
+			{"ltail", fmt.Sprintf("cluster_%d", from.ID)},
+			{"lhead", fmt.Sprintf("cluster_%d", to.ID)},
+		})
+	}
+
+	fmt.Fprintln(buf, "}")
+
+	return buf.Flush()
 }
 
 func (c *Client) Validate(step pipeline.Step) error {
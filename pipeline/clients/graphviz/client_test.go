@@ -0,0 +1,68 @@
+package graphviz_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipeline/clients/graphviz"
+	"github.com/grafana/scribe/state"
+	"github.com/sirupsen/logrus"
+)
+
+func TestGraphvizClient(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		buf        = bytes.NewBuffer(nil)
+		argVersion = state.NewStringArgument("version")
+		argSecret  = state.NewSecretArgument("publish-key")
+	)
+
+	collection, err := pipeline.NewCollectionWithSteps("test pipeline",
+		pipeline.Step{ID: 2, Name: "write version", Image: "alpine:latest", ProvidedArgs: state.Arguments{argVersion}},
+		pipeline.Step{ID: 3, Name: "database", Image: "postgres:latest", Type: pipeline.StepTypeBackground},
+		pipeline.Step{ID: 4, Name: "publish", RequiredArgs: state.Arguments{argVersion, argSecret}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection.Root = []int64{1}
+
+	if err := collection.BuildEdges(logrus.StandardLogger()); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := graphviz.New(ctx, clients.CommonOpts{Output: buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Done(ctx, collection); err != nil {
+		t.Fatal(err)
+	}
+
+	dot := buf.String()
+	expected := []string{
+		`subgraph "cluster_1" {`,
+		`label="test pipeline";`,
+		`"step_2" [label="write version\nalpine:latest"];`,
+		`"step_3" [label="database\npostgres:latest", style="dashed,rounded"];`,
+		`"step_4" [label="publish"];`,
+		`"step_2" -> "step_4" [label="version"];`,
+		`"secret_1_publish_key" [label="publish-key", shape="note", style="dashed", color="red"];`,
+		`"secret_1_publish_key" -> "step_4" [style="dashed", color="red"];`,
+	}
+
+	for _, v := range expected {
+		if !strings.Contains(dot, v) {
+			t.Errorf("expected output to contain '%s'", v)
+		}
+	}
+
+	if t.Failed() {
+		t.Log(dot)
+	}
+}
@@ -0,0 +1,104 @@
+package mermaid
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipeline/clients/common"
+	"github.com/grafana/scribe/state"
+)
+
+// Client is the Mermaid implementation of the pipeline Client interface.
+// It writes a Mermaid flowchart to the provided writer where every pipeline is a subgraph and every step is a node.
+// The flowchart can be embedded in Markdown using a 'mermaid' code block.
+type Client struct {
+	Stdout io.Writer
+}
+
+func New(ctx context.Context, opts clients.CommonOpts) (pipeline.Client, error) {
+	return &Client{
+		Stdout: opts.Output,
+	}, nil
+}
+
+// text escapes the characters that would otherwise end a quoted string in a flowchart.
+func text(s string) string {
+	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(s, `"`, "#quot;"))
+}
+
+func pipelineNodeID(p pipeline.Pipeline) string {
+	return fmt.Sprintf("pipeline_%d", p.ID)
+}
+
+func stepNodeID(s pipeline.Step) string {
+	return fmt.Sprintf("step_%d", s.ID)
+}
+
+func writeEdge(w io.Writer, indent, from, to string, args state.Arguments) {
+	if len(args) == 0 {
+		fmt.Fprintf(w, "%s%s --> %s\n", indent, from, to)
+		return
+	}
+
+	fmt.Fprintf(w, "%s%s -->|%s| %s\n", indent, from, text(strings.Join(common.ArgKeys(args), ", ")), to)
+}
+
+// writeStep writes a single step. Background steps use the "stadium" shape to set them apart from regular steps.
+func writeStep(w io.Writer, s pipeline.Step) {
+	if s.Type == pipeline.StepTypeBackground {
+		fmt.Fprintf(w, "    %s([%s])\n", stepNodeID(s), text(s.Name))
+		return
+	}
+
+	fmt.Fprintf(w, "    %s[%s]\n", stepNodeID(s), text(s.Name))
+}
+
+func writePipeline(w io.Writer, p pipeline.Pipeline) {
+	fmt.Fprintf(w, "  subgraph %s [%s]\n", pipelineNodeID(p), text(p.Name))
+
+	for _, node := range p.Graph.Nodes {
+		if node.ID == 0 {
+			continue
+		}
+		writeStep(w, node.Value)
+	}
+
+	for _, e := range common.WithoutRoot(common.StepEdges(p.Graph)) {
+		writeEdge(w, "    ", stepNodeID(e.From.Value), stepNodeID(e.To.Value), e.Args)
+	}
+
+	fmt.Fprintln(w, "  end")
+}
+
+// Done writes the collection as a Mermaid flowchart. The edges must already be built, which is done in 'Scribe.Execute' before 'Done' is called.
+func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
+	pipelines := []pipeline.Pipeline{}
+	if err := w.WalkPipelines(ctx, func(ctx context.Context, p pipeline.Pipeline) error {
+		pipelines = append(pipelines, p)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	buf := bufio.NewWriter(c.Stdout)
+
+	fmt.Fprintln(buf, "flowchart TD")
+	for _, p := range pipelines {
+		writePipeline(buf, p)
+	}
+
+	for _, e := range common.WithoutRoot(common.PipelineEdges(w.Graph)) {
+		writeEdge(buf, "  ", pipelineNodeID(e.From.Value), pipelineNodeID(e.To.Value), e.Args)
+	}
+
+	return buf.Flush()
+}
+
+func (c *Client) Validate(step pipeline.Step) error {
+	return nil
+}
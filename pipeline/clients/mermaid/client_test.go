@@ -0,0 +1,60 @@
+package mermaid_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipeline/clients/mermaid"
+	"github.com/grafana/scribe/state"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMermaidClient(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		buf        = bytes.NewBuffer(nil)
+		argVersion = state.NewStringArgument("version")
+	)
+
+	collection, err := pipeline.NewCollectionWithSteps(`test "pipeline"`,
+		pipeline.Step{ID: 2, Name: "write version", ProvidedArgs: state.Arguments{argVersion}},
+		pipeline.Step{ID: 3, Name: "database", Type: pipeline.StepTypeBackground},
+		pipeline.Step{ID: 4, Name: "publish", RequiredArgs: state.Arguments{argVersion}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection.Root = []int64{1}
+
+	if err := collection.BuildEdges(logrus.StandardLogger()); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := mermaid.New(ctx, clients.CommonOpts{Output: buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Done(ctx, collection); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		`flowchart TD`,
+		`  subgraph pipeline_1 ["test #quot;pipeline#quot;"]`,
+		`    step_2["write version"]`,
+		`    step_3(["database"])`,
+		`    step_4["publish"]`,
+		`    step_2 -->|"version"| step_4`,
+		`  end`,
+		``,
+	}, "\n")
+
+	if buf.String() != expected {
+		t.Fatalf("unexpected flowchart.\nexpected:\n%s\nreceived:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,2 @@
+// Package mermaid contains the client implementation for generating a Mermaid flowchart of a pipeline.
+package mermaid
@@ -0,0 +1,190 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipeline/clients/common"
+	"github.com/grafana/scribe/state"
+)
+
+// Client is the plan implementation of the pipeline Client interface.
+// It writes a JSON document (see 'Plan') to the provided writer that other tools can use to inspect a pipeline without running it.
+type Client struct {
+	Stdout io.Writer
+}
+
+func New(ctx context.Context, opts clients.CommonOpts) (pipeline.Client, error) {
+	return &Client{
+		Stdout: opts.Output,
+	}, nil
+}
+
+func arguments(args state.Arguments) []Argument {
+	a := make([]Argument, len(args))
+	for i, v := range args {
+		a[i] = Argument{
+			Key:  v.Key,
+			Type: v.Type.String(),
+		}
+	}
+
+	return a
+}
+
+func events(e []pipeline.Event) []Event {
+	events := make([]Event, len(e))
+	for i, v := range e {
+		filters := []Filter{}
+		for _, exclude := range []bool{false, true} {
+			m := v.Filters
+			if exclude {
+				m = v.Exclude
+			}
+
+			for key, f := range m {
+				// A nil filter matches everything. See 'pipeline.GitTagEvent'.
+				if f == nil {
+					continue
+				}
+				filters = append(filters, Filter{
+					Key:     key,
+					Type:    f.Type.String(),
+					Value:   f.String(),
+					Exclude: exclude,
+				})
+			}
+		}
+
+		sort.Slice(filters, func(i, j int) bool {
+			if filters[i].Exclude != filters[j].Exclude {
+				return !filters[i].Exclude
+			}
+			return filters[i].Key < filters[j].Key
+		})
+
+		events[i] = Event{
+			Name:     v.Name,
+			Filters:  filters,
+			Provides: arguments(v.Provides),
+		}
+	}
+
+	return events
+}
+
+// edges converts the edges of a graph (see 'common.StepEdges' and 'common.PipelineEdges') to the edges of the plan.
+func edges[T any](linked []common.LinkedEdge[T]) []Edge {
+	e := []Edge{}
+	for _, v := range linked {
+		e = append(e, Edge{
+			From: v.From.ID,
+			To:   v.To.ID,
+			Args: arguments(v.Args),
+		})
+	}
+
+	return e
+}
+
+// duration formats a timeout. Timeouts of 0 or less are not set, so they are omitted from the plan.
+func duration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+
+	return d.String()
+}
+
+func steps(p pipeline.Pipeline) []Step {
+	steps := []Step{}
+	for _, node := range p.Graph.Nodes {
+		if node.ID == 0 {
+			continue
+		}
+
+		s := node.Value
+		step := Step{
+			ID:           s.ID,
+			Name:         s.Name,
+			Image:        s.Image,
+			Type:         s.Type.String(),
+			RequiredArgs: arguments(s.RequiredArgs),
+			ProvidedArgs: arguments(s.ProvidedArgs),
+			Timeout:      duration(s.Timeout),
+			AllowFailure: s.AllowFailure,
+			AlwaysRun:    s.AlwaysRun,
+			Conditional:  s.Condition != nil,
+			Matrix:       s.Matrix,
+			Cached:       s.Cache != nil,
+		}
+
+		if len(s.Events) != 0 {
+			step.Events = events(s.Events)
+		}
+
+		if s.Cache != nil {
+			step.CachedPaths = s.Cache.Paths
+		}
+
+		if s.Retry.Enabled() {
+			step.Retry = &Retry{
+				Attempts: s.Retry.Attempts,
+				Backoff:  s.Retry.Backoff.String(),
+			}
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps
+}
+
+// NewPlan creates the Plan for the collection. The edges must already be built using 'Collection.BuildEdges'.
+func NewPlan(ctx context.Context, w *pipeline.Collection) (Plan, error) {
+	plan := Plan{
+		Pipelines: []Pipeline{},
+		Edges:     edges(common.PipelineEdges(w.Graph)),
+	}
+
+	if err := w.WalkPipelines(ctx, func(ctx context.Context, p pipeline.Pipeline) error {
+		plan.Pipelines = append(plan.Pipelines, Pipeline{
+			ID:           p.ID,
+			Name:         p.Name,
+			Type:         p.Type.String(),
+			RequiredArgs: arguments(p.RequiredArgs),
+			ProvidedArgs: arguments(p.ProvidedArgs),
+			Events:       events(p.Events),
+			Timeout:      duration(p.Timeout),
+			Steps:        steps(p),
+			Edges:        edges(common.StepEdges(p.Graph)),
+		})
+		return nil
+	}); err != nil {
+		return Plan{}, err
+	}
+
+	return plan, nil
+}
+
+// Done writes the Plan for the collection as indented JSON.
+func (c *Client) Done(ctx context.Context, w *pipeline.Collection) error {
+	plan, err := NewPlan(ctx, w)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(c.Stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(plan)
+}
+
+func (c *Client) Validate(step pipeline.Step) error {
+	return nil
+}
@@ -0,0 +1,99 @@
+package plan_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/grafana/scribe/pipeline/clients/plan"
+	"github.com/grafana/scribe/state"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPlanClient(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		buf        = bytes.NewBuffer(nil)
+		argVersion = state.NewStringArgument("version")
+		argSecret  = state.NewSecretArgument("publish-key")
+	)
+
+	collection, err := pipeline.NewCollectionWithSteps("test pipeline",
+		pipeline.Step{ID: 2, Name: "write version", Image: "alpine:latest", ProvidedArgs: state.Arguments{argVersion}, Cache: pipeline.NewCache(pipeline.CachePaths("VERSION"))},
+		pipeline.Step{ID: 3, Name: "database", Image: "postgres:latest", Type: pipeline.StepTypeBackground, AllowFailure: true, AlwaysRun: true},
+		pipeline.Step{ID: 4, Name: "publish", Image: "alpine:latest", RequiredArgs: state.Arguments{argVersion, argSecret}, Retry: pipeline.Retry{Attempts: 3, Backoff: time.Second}, Timeout: time.Minute, Events: []pipeline.Event{pipeline.GitTagEvent(pipeline.GitTagFilters{})}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection.Root = []int64{1}
+
+	if err := collection.AddEvents(1, pipeline.GitCommitEvent(pipeline.GitCommitFilters{
+		Branch: pipeline.StringFilter("main"),
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := collection.BuildEdges(logrus.StandardLogger()); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := plan.New(ctx, clients.CommonOpts{Output: buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Done(ctx, collection); err != nil {
+		t.Fatal(err)
+	}
+
+	result := plan.Plan{}
+	if err := json.NewDecoder(buf).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := plan.Plan{
+		Pipelines: []plan.Pipeline{
+			{
+				ID:           1,
+				Name:         "test pipeline",
+				Type:         "default",
+				RequiredArgs: []plan.Argument{},
+				ProvidedArgs: []plan.Argument{},
+				Events: []plan.Event{
+					{
+						Name:    "git-commit",
+						Filters: []plan.Filter{{Key: "branch", Type: "string", Value: "main"}},
+						Provides: []plan.Argument{
+							{Key: "git-commit-sha", Type: "string"},
+							{Key: "git-branch", Type: "string"},
+							{Key: "remote-url", Type: "string"},
+						},
+					},
+				},
+				Steps: []plan.Step{
+					{ID: 2, Name: "write version", Image: "alpine:latest", Type: "default", RequiredArgs: []plan.Argument{}, ProvidedArgs: []plan.Argument{{Key: "version", Type: "string"}}, Cached: true, CachedPaths: []string{"VERSION"}},
+					{ID: 3, Name: "database", Image: "postgres:latest", Type: "background", RequiredArgs: []plan.Argument{}, ProvidedArgs: []plan.Argument{}, AllowFailure: true, AlwaysRun: true},
+					{ID: 4, Name: "publish", Image: "alpine:latest", Type: "default", RequiredArgs: []plan.Argument{{Key: "version", Type: "string"}, {Key: "publish-key", Type: "secret"}}, ProvidedArgs: []plan.Argument{}, Retry: &plan.Retry{Attempts: 3, Backoff: "1s"}, Timeout: "1m0s", Events: []plan.Event{{Name: "git-tag", Filters: []plan.Filter{}, Provides: []plan.Argument{{Key: "git-commit-sha", Type: "string"}, {Key: "git-commit-ref", Type: "string"}, {Key: "remote-url", Type: "string"}}}}},
+				},
+				Edges: []plan.Edge{
+					{From: 0, To: 2, Args: []plan.Argument{}},
+					{From: 0, To: 3, Args: []plan.Argument{}},
+					{From: 2, To: 4, Args: []plan.Argument{{Key: "version", Type: "string"}}},
+				},
+			},
+		},
+		Edges: []plan.Edge{
+			{From: 0, To: 1, Args: []plan.Argument{}},
+		},
+	}
+
+	if !cmp.Equal(result, expected) {
+		t.Fatal(cmp.Diff(result, expected))
+	}
+}
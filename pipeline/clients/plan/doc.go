@@ -0,0 +1,2 @@
+// Package plan contains the client implementation for generating a JSON document that describes every pipeline, step, and edge in a collection.
+package plan
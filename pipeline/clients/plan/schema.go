@@ -0,0 +1,82 @@
+package plan
+
+// Plan is the JSON document written by the plan client. It describes every pipeline and step in the collection, how they are triggered, and how they are connected.
+// Lists are always in the order that the pipelines, steps, and arguments were defined so that the document is stable between runs.
+type Plan struct {
+	Pipelines []Pipeline `json:"pipelines"`
+	// Edges are the edges between pipelines. An edge from the ID '0' is an edge from the root of the collection.
+	Edges []Edge `json:"edges"`
+}
+
+type Argument struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+}
+
+type Filter struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	// Exclude is true if the event does not trigger the pipeline when the filter matches.
+	Exclude bool `json:"exclude,omitempty"`
+}
+
+type Event struct {
+	Name string `json:"name"`
+	// Filters are sorted by key, with the exclude filters after the include filters.
+	Filters  []Filter   `json:"filters"`
+	Provides []Argument `json:"provides"`
+}
+
+// Edge is a connection from one pipeline or step to another. Args are the arguments provided by 'From' and required by 'To'.
+type Edge struct {
+	From int64      `json:"from"`
+	To   int64      `json:"to"`
+	Args []Argument `json:"args"`
+}
+
+// Retry is the retry policy of a step. The 'retry_on' function of a policy can not be described, so it is omitted.
+type Retry struct {
+	Attempts int `json:"attempts"`
+	// Backoff is formatted like a Go duration, for example '1.5s'.
+	Backoff string `json:"backoff"`
+}
+
+type Step struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Image        string     `json:"image"`
+	Type         string     `json:"type"`
+	RequiredArgs []Argument `json:"required_args"`
+	ProvidedArgs []Argument `json:"provided_args"`
+	// Retry is only set if the step is retried.
+	Retry *Retry `json:"retry,omitempty"`
+	// Timeout is formatted like a Go duration and is only set if the step has a timeout.
+	Timeout      string `json:"timeout,omitempty"`
+	AllowFailure bool   `json:"allow_failure,omitempty"`
+	AlwaysRun    bool   `json:"always_run,omitempty"`
+	// Events are the events that the step runs on. If there are none, then the step runs whenever its pipeline runs.
+	Events []Event `json:"events,omitempty"`
+	// Conditional is true if the step has a condition that is evaluated when the pipeline runs.
+	Conditional bool `json:"conditional,omitempty"`
+	// Matrix contains the matrix values of a step that was created with 'pipeline.Matrix'.
+	Matrix map[string]string `json:"matrix,omitempty"`
+	// CachedPaths are the files and directories that are restored from the cache if the step is skipped because its inputs have not changed.
+	CachedPaths []string `json:"cached_paths,omitempty"`
+	// Cached is true if the step is skipped when its inputs have not changed since a previous run.
+	Cached bool `json:"cached,omitempty"`
+}
+
+type Pipeline struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Type         string     `json:"type"`
+	RequiredArgs []Argument `json:"required_args"`
+	ProvidedArgs []Argument `json:"provided_args"`
+	Events       []Event    `json:"events"`
+	// Timeout is formatted like a Go duration and is only set if the pipeline has a timeout.
+	Timeout string `json:"timeout,omitempty"`
+	Steps   []Step `json:"steps"`
+	// Edges are the edges between the steps in this pipeline. An edge from the ID '0' is an edge from the root of the pipeline.
+	Edges []Edge `json:"edges"`
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/grafana/scribe/pipeline/dag"
 	"github.com/grafana/scribe/state"
@@ -141,6 +142,11 @@ func (c *Collection) BuildEdges(log logrus.FieldLogger, rootArgs ...state.Argume
 			"edges":    len(v.Value.Graph.Edges),
 		}).Debugln("Done building graph")
 	}
+
+	if _, err := c.Graph.TopologicalSort(); err != nil {
+		return pipelineCycleError(c.Graph, err)
+	}
+
 	return nil
 }
 
@@ -158,6 +164,19 @@ func (c *Collection) AddEvents(pipelineID int64, events ...Event) error {
 	return nil
 }
 
+// SetTimeout sets the timeout of the pipeline with the provided ID.
+func (c *Collection) SetTimeout(pipelineID int64, timeout time.Duration) error {
+	node, err := c.Graph.Node(pipelineID)
+	if err != nil {
+		return err
+	}
+
+	pipeline := node.Value
+	pipeline.Timeout = timeout
+	node.Value = pipeline
+	return nil
+}
+
 // pipelineVisitFunc returns a dag.VisitFunc that runs per-pipeline found in the graph.
 func (c *Collection) pipelineVisitFunc(ctx context.Context, wf PipelineWalkFunc) dag.VisitFunc[Pipeline] {
 	return func(n *dag.Node[Pipeline]) error {
@@ -225,52 +244,63 @@ func (c *Collection) ByID(ctx context.Context, id int64) (Step, error) {
 	return Step{}, errors.New("no step found")
 }
 
-// ByName should return the Step that corresponds with a specific Name
+// ByName returns every Step with the provided name. Steps in different pipelines can have the same name, so there can be more than one.
 func (c *Collection) ByName(ctx context.Context, name string) ([]Step, error) {
-	steps := []Step{}
+	return c.stepsWhere(func(s Step) (bool, error) {
+		return s.Name == name, nil
+	})
+}
 
-	// Search every pipeline and step for the listed IDs
-	if err := c.WalkPipelines(ctx, func(ctx context.Context, p Pipeline) error {
-		if p.Name == name {
-			return nil
+// ByNamePattern returns every Step with a name that matches the glob 'pattern' (see 'MatchStepName').
+func (c *Collection) ByNamePattern(ctx context.Context, pattern string) ([]Step, error) {
+	return c.stepsWhere(func(s Step) (bool, error) {
+		return MatchStepName(pattern, s.Name)
+	})
+}
+
+// stepsWhere returns every step in every pipeline that 'match' returns true for.
+// Unlike WalkPipelines, it does not follow the edges of the graph, so it can be used before the edges are built.
+func (c *Collection) stepsWhere(match func(Step) (bool, error)) ([]Step, error) {
+	steps := []Step{}
+	for _, p := range c.Graph.Nodes {
+		if p.ID == 0 {
+			continue
 		}
-		for _, node := range p.Graph.Nodes {
-			if node.Value.Name == name {
-				steps = []Step{node.Value}
-				return dag.ErrorBreak
+
+		for _, node := range p.Value.Graph.Nodes {
+			if node.ID == 0 {
+				continue
 			}
-		}
 
-		return nil
-	}); err != nil {
-		return nil, err
+			ok, err := match(node.Value)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				steps = append(steps, node.Value)
+			}
+		}
 	}
 
 	return steps, nil
 }
 
 // PipelinesByName should return the Pipelines that corresponds with a specified names
+// Like 'stepsWhere', it does not follow the edges of the graph, so it can be used before the edges are built.
 func (c *Collection) PipelinesByName(ctx context.Context, names []string) ([]Pipeline, error) {
-	var (
-		retP  = make([]Pipeline, len(names))
-		found = false
-	)
+	retP := []Pipeline{}
 
 	// Search every pipeline for the listed names
-	if err := c.WalkPipelines(ctx, func(ctx context.Context, p Pipeline) error {
-		for i, argPipeline := range names {
-			if strings.EqualFold(p.Name, argPipeline) {
-				retP[i] = p
-				found = true
+	for _, argPipeline := range names {
+		for _, p := range c.Graph.Nodes {
+			if p.ID != 0 && strings.EqualFold(p.Value.Name, argPipeline) {
+				retP = append(retP, p.Value)
 				break
 			}
 		}
-		return nil
-	}); err != nil {
-		return nil, err
 	}
 
-	if !found {
+	if len(retP) == 0 {
 		return nil, errors.New("no matching pipelines found")
 	}
 	return retP, nil
@@ -237,4 +237,77 @@ func TestCollectionGetters(t *testing.T) {
 }
 
 func TestCollectionByName(t *testing.T) {
+	newCollection := func(t *testing.T) *pipeline.Collection {
+		t.Helper()
+		col := pipeline.NewCollection()
+		testutil.EnsureError(t, col.AddPipelines(pipeline.New("test", 1), pipeline.New("build", 10)), nil)
+		testutil.EnsureError(t, col.AddSteps(1,
+			pipeline.Step{ID: 2, Name: "test"},
+			pipeline.Step{ID: 3, Name: "test backend"},
+		), nil)
+		testutil.EnsureError(t, col.AddSteps(10,
+			pipeline.Step{ID: 11, Name: "test"},
+			pipeline.Step{ID: 12, Name: "build"},
+		), nil)
+
+		return col
+	}
+
+	t.Run("ByName should return the steps with the name in every pipeline", func(t *testing.T) {
+		steps, err := newCollection(t).ByName(context.Background(), "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(steps) != 2 || steps[0].ID != 2 || steps[1].ID != 11 {
+			t.Fatalf("Expected steps 2 and 11 but got '%v'", steps)
+		}
+	})
+
+	t.Run("ByName should return steps in a pipeline with the same name", func(t *testing.T) {
+		steps, err := newCollection(t).ByName(context.Background(), "build")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(steps) != 1 || steps[0].ID != 12 {
+			t.Fatalf("Expected step 12 but got '%v'", steps)
+		}
+	})
+
+	t.Run("ByNamePattern should return the steps with a name or slug that matches the pattern", func(t *testing.T) {
+		steps, err := newCollection(t).ByNamePattern(context.Background(), "test_*")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(steps) != 1 || steps[0].ID != 3 {
+			t.Fatalf("Expected step 3 but got '%v'", steps)
+		}
+	})
+}
+
+func TestCollectionAddStepsDuplicateName(t *testing.T) {
+	t.Run("AddSteps should return an error if a step has the same name as another step in the pipeline", func(t *testing.T) {
+		col := pipeline.NewCollection()
+		testutil.EnsureError(t, col.AddPipelines(pipeline.New("test", 1)), nil)
+		testutil.EnsureError(t, col.AddSteps(1, pipeline.Step{ID: 2, Name: "build"}), nil)
+		testutil.EnsureError(t, col.AddSteps(1, pipeline.Step{ID: 3, Name: "build"}), pipeline.ErrorDuplicateStepName)
+	})
+
+	t.Run("AddSteps should return an error if two steps have names with the same slug", func(t *testing.T) {
+		col := pipeline.NewCollection()
+		testutil.EnsureError(t, col.AddPipelines(pipeline.New("test", 1)), nil)
+		testutil.EnsureError(t, col.AddSteps(1,
+			pipeline.Step{ID: 2, Name: "build backend"},
+			pipeline.Step{ID: 3, Name: "build-backend"},
+		), pipeline.ErrorDuplicateStepName)
+	})
+
+	t.Run("AddSteps should allow steps with the same name in different pipelines", func(t *testing.T) {
+		col := pipeline.NewCollection()
+		testutil.EnsureError(t, col.AddPipelines(pipeline.New("test", 1), pipeline.New("build", 10)), nil)
+		testutil.EnsureError(t, col.AddSteps(1, pipeline.Step{ID: 2, Name: "build"}), nil)
+		testutil.EnsureError(t, col.AddSteps(10, pipeline.Step{ID: 11, Name: "build"}), nil)
+	})
 }
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/scribe/state"
+)
+
+// Condition is evaluated against the state before a step runs. If it returns false, then the step is skipped.
+type Condition func(context.Context, state.Reader) (bool, error)
+
+// FilterArguments maps the keys of event filters to the state arguments that they are checked against when a step's events are evaluated.
+var FilterArguments = map[string]state.Argument{
+	"branch":        ArgumentBranch,
+	"tag":           ArgumentTagName,
+	"target-branch": ArgumentTargetBranch,
+	"cron":          ArgumentCronJob,
+	"target":        ArgumentPromoteTarget,
+}
+
+// GlobRegexp converts a glob pattern into an anchored regular expression, where '*' matches any number of characters and '?' matches one character.
+func GlobRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+
+	return b.String()
+}
+
+// Match returns true if the value matches the filter.
+func (f *FilterValue) Match(value string) (bool, error) {
+	switch f.Type {
+	case FilterValueRegex:
+		if re, ok := f.Value.(*regexp.Regexp); ok {
+			return re.MatchString(value), nil
+		}
+		return regexp.MatchString(f.String(), value)
+	case FilterValueGlob:
+		return regexp.MatchString(GlobRegexp(f.String()), value)
+	default:
+		return f.String() == value, nil
+	}
+}
+
+// ArgumentMatches returns a Condition that is true if the value of the argument matches the filter.
+// If the filter is nil, then the Condition is true if the argument exists. If the argument does not exist, then the Condition is false.
+func ArgumentMatches(arg state.Argument, filter *FilterValue) Condition {
+	return func(ctx context.Context, s state.Reader) (bool, error) {
+		exists, err := s.Exists(ctx, arg)
+		if err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, nil
+		}
+		if filter == nil {
+			return true, nil
+		}
+
+		value, err := state.GetValueAsString(ctx, s, arg)
+		if err != nil {
+			return false, err
+		}
+
+		return filter.Match(value)
+	}
+}
+
+// Matches returns true if every filter in the event matches the value of its argument in the state (see 'FilterArguments'), and none of its exclude filters do.
+// The name of the event is not checked; only the pipeline's events decide whether or not it was triggered.
+func (e Event) Matches(ctx context.Context, s state.Reader) (bool, error) {
+	for key, filter := range e.Filters {
+		arg, ok := FilterArguments[key]
+		if !ok {
+			return false, fmt.Errorf("event '%s' has an unknown filter '%s'", e.Name, key)
+		}
+
+		ok, err := ArgumentMatches(arg, filter)(ctx, s)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	for key, filter := range e.Exclude {
+		arg, ok := FilterArguments[key]
+		if !ok {
+			return false, fmt.Errorf("event '%s' has an unknown exclude filter '%s'", e.Name, key)
+		}
+
+		// A missing argument can not match the filter, so it is not excluded.
+		ok, err := ArgumentMatches(arg, filter)(ctx, s)
+		if err != nil || ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
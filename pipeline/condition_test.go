@@ -0,0 +1,128 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/state"
+	"github.com/grafana/scribe/testutil"
+)
+
+func TestFilterValueMatch(t *testing.T) {
+	cases := []struct {
+		Filter *pipeline.FilterValue
+		Value  string
+		Expect bool
+	}{
+		{Filter: pipeline.StringFilter("main"), Value: "main", Expect: true},
+		{Filter: pipeline.StringFilter("main"), Value: "main-2", Expect: false},
+		{Filter: pipeline.GlobFilter("release-*"), Value: "release-1.0", Expect: true},
+		{Filter: pipeline.GlobFilter("release-*"), Value: "pre-release-1.0", Expect: false},
+		{Filter: pipeline.GlobFilter("v?.0"), Value: "v1.0", Expect: true},
+		{Filter: pipeline.RegexpFilter(regexp.MustCompile(`^v\d+$`)), Value: "v12", Expect: true},
+		{Filter: pipeline.RegexpFilter(regexp.MustCompile(`^v\d+$`)), Value: "v1.2", Expect: false},
+	}
+
+	for _, c := range cases {
+		ok, err := c.Filter.Match(c.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ok != c.Expect {
+			t.Errorf("expected %s filter '%s' matching '%s' to be %t", c.Filter.Type, c.Filter, c.Value, c.Expect)
+		}
+	}
+}
+
+func TestStepShouldRun(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		release = state.NewArgMapReader(args.ArgMap{"git-branch": "release-1.0"})
+		main    = state.NewArgMapReader(args.ArgMap{"git-branch": "main"})
+	)
+
+	t.Run("It should run steps without events or conditions", func(t *testing.T) {
+		ok, err := pipeline.NoOpStep.ShouldRun(ctx, main)
+		testutil.EnsureError(t, err, nil)
+		if !ok {
+			t.Fatal("expected step to run")
+		}
+	})
+
+	t.Run("It should only run steps when one of their events matches the state", func(t *testing.T) {
+		step := pipeline.NoOpStep.When(
+			pipeline.GitCommitEvent(pipeline.GitCommitFilters{Branch: pipeline.GlobFilter("release-*")}),
+		)
+
+		ok, err := step.ShouldRun(ctx, release)
+		testutil.EnsureError(t, err, nil)
+		if !ok {
+			t.Error("expected step to run on a release branch")
+		}
+
+		ok, err = step.ShouldRun(ctx, main)
+		testutil.EnsureError(t, err, nil)
+		if ok {
+			t.Error("expected step to be skipped on the main branch")
+		}
+	})
+
+	t.Run("It should skip steps when one of the exclude filters of their events matches the state", func(t *testing.T) {
+		step := pipeline.NoOpStep.When(
+			pipeline.GitCommitEvent(pipeline.GitCommitFilters{ExcludeBranch: pipeline.GlobFilter("release-*")}),
+		)
+
+		ok, err := step.ShouldRun(ctx, release)
+		testutil.EnsureError(t, err, nil)
+		if ok {
+			t.Error("expected step to be skipped on a release branch")
+		}
+
+		ok, err = step.ShouldRun(ctx, main)
+		testutil.EnsureError(t, err, nil)
+		if !ok {
+			t.Error("expected step to run on the main branch")
+		}
+	})
+
+	t.Run("It should not match a tag event if there is no tag in the state", func(t *testing.T) {
+		step := pipeline.NoOpStep.When(pipeline.GitTagEvent(pipeline.GitTagFilters{}))
+
+		ok, err := step.ShouldRun(ctx, main)
+		testutil.EnsureError(t, err, nil)
+		if ok {
+			t.Error("expected step to be skipped")
+		}
+	})
+
+	t.Run("It should only run steps when their condition is true", func(t *testing.T) {
+		step := pipeline.NoOpStep.WithCondition(pipeline.ArgumentMatches(pipeline.ArgumentBranch, pipeline.GlobFilter("release-*")))
+
+		ok, err := step.ShouldRun(ctx, release)
+		testutil.EnsureError(t, err, nil)
+		if !ok {
+			t.Error("expected step to run on a release branch")
+		}
+
+		ok, err = step.ShouldRun(ctx, main)
+		testutil.EnsureError(t, err, nil)
+		if ok {
+			t.Error("expected step to be skipped on the main branch")
+		}
+	})
+
+	t.Run("It should return the error from the condition", func(t *testing.T) {
+		errTest := errors.New("test error")
+		step := pipeline.NoOpStep.WithCondition(func(context.Context, state.Reader) (bool, error) {
+			return false, errTest
+		})
+
+		_, err := step.ShouldRun(ctx, main)
+		testutil.EnsureError(t, err, errTest)
+	})
+}
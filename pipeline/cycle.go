@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/scribe/pipeline/dag"
+	"github.com/grafana/scribe/state"
+)
+
+// cycleError converts a *dag.CycleError into an error that names each node on the cycle and the arguments that connect them, like:
+// "graph contains a cycle: 'build' -[version]-> 'publish' -[artifact]-> 'build'".
+func cycleError[T any](g *dag.Graph[T], err error, name func(T) string, provided, required func(T) state.Arguments) error {
+	path, err := cyclePath(g, err, name, provided, required)
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: %s", dag.ErrorCycle, path)
+}
+
+// cyclePath returns the description of the cycle in a *dag.CycleError, like "'build' -[version]-> 'publish' -[artifact]-> 'build'".
+// If 'err' is not a *dag.CycleError, then it is returned instead.
+func cyclePath[T any](g *dag.Graph[T], err error, name func(T) string, provided, required func(T) state.Arguments) (string, error) {
+	cycle := &dag.CycleError{}
+	if !errors.As(err, &cycle) {
+		return "", err
+	}
+
+	parts := []string{}
+	for i, id := range cycle.Path {
+		node, err := g.Node(id)
+		if err != nil {
+			return "", err
+		}
+
+		parts = append(parts, fmt.Sprintf("'%s'", name(node.Value)))
+		if i == len(cycle.Path)-1 {
+			break
+		}
+
+		next, err := g.Node(cycle.Path[i+1])
+		if err != nil {
+			return "", err
+		}
+
+		keys := []string{}
+		for _, arg := range required(next.Value) {
+			if state.ArgListContains(provided(node.Value), arg) {
+				keys = append(keys, arg.Key)
+			}
+		}
+		parts = append(parts, fmt.Sprintf("-[%s]->", strings.Join(keys, ", ")))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+func stepCycleError(g *dag.Graph[Step], err error) error {
+	return cycleError(g, err,
+		func(s Step) string { return s.Name },
+		func(s Step) state.Arguments { return s.ProvidedArgs },
+		func(s Step) state.Arguments { return s.RequiredArgs },
+	)
+}
+
+func pipelineCycleError(g *dag.Graph[Pipeline], err error) error {
+	return cycleError(g, err,
+		func(p Pipeline) string { return p.Name },
+		func(p Pipeline) state.Arguments { return p.ProvidedArgs },
+		func(p Pipeline) state.Arguments { return p.RequiredArgs },
+	)
+}
@@ -0,0 +1,158 @@
+package dag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrorCycle = errors.New("graph contains a cycle")
+
+// CycleError is returned when the graph is not acyclic. Path is the list of node IDs that form the cycle, where the first and last IDs are the same node.
+type CycleError struct {
+	Path []int64
+}
+
+func (e *CycleError) Error() string {
+	ids := make([]string, len(e.Path))
+	for i, v := range e.Path {
+		ids[i] = fmt.Sprint(v)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrorCycle.Error(), strings.Join(ids, " -> "))
+}
+
+func (e *CycleError) Unwrap() error {
+	return ErrorCycle
+}
+
+// findCycle performs a depth-first search from every node and returns the first cycle that it finds, or nil if there are no cycles.
+func (g *Graph[T]) findCycle() []int64 {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	var (
+		status = map[int64]int{}
+		stack  = []int64{}
+		visit  func(id int64) []int64
+	)
+
+	visit = func(id int64) []int64 {
+		status[id] = inProgress
+		stack = append(stack, id)
+
+		for _, e := range g.Edges[id] {
+			switch status[e.To.ID] {
+			case inProgress:
+				// The node is already on the stack, so the cycle is everything on the stack after it.
+				for i, v := range stack {
+					if v == e.To.ID {
+						return append(append([]int64{}, stack[i:]...), e.To.ID)
+					}
+				}
+			case unvisited:
+				if cycle := visit(e.To.ID); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		status[id] = done
+		return nil
+	}
+
+	for _, n := range g.Nodes {
+		if status[n.ID] != unvisited {
+			continue
+		}
+		if cycle := visit(n.ID); cycle != nil {
+			return cycle
+		}
+	}
+
+	return nil
+}
+
+// DetectCycle returns a *CycleError if there is a cycle in the graph.
+func (g *Graph[T]) DetectCycle() error {
+	if cycle := g.findCycle(); cycle != nil {
+		return &CycleError{Path: cycle}
+	}
+
+	return nil
+}
+
+// Levels returns the nodes in the graph grouped into layers. The first layer contains every node without incoming edges,
+// and every other node is in the layer after the last of the nodes that have edges to it.
+// Nodes in the same layer do not depend on each other and can be handled in parallel.
+// Nodes within a layer are in the order that they were added to the graph.
+// If the graph contains a cycle, then a *CycleError is returned.
+func (g *Graph[T]) Levels() ([][]*Node[T], error) {
+	indegree := map[int64]int{}
+	for _, edges := range g.Edges {
+		for _, e := range edges {
+			indegree[e.To.ID]++
+		}
+	}
+
+	var (
+		levels = [][]*Node[T]{}
+		level  = []*Node[T]{}
+		seen   = 0
+	)
+
+	for i, n := range g.Nodes {
+		if indegree[n.ID] == 0 {
+			level = append(level, &g.Nodes[i])
+		}
+	}
+
+	for len(level) != 0 {
+		levels = append(levels, level)
+		seen += len(level)
+
+		ready := map[int64]bool{}
+		for _, n := range level {
+			for _, e := range g.Edges[n.ID] {
+				indegree[e.To.ID]--
+				if indegree[e.To.ID] == 0 {
+					ready[e.To.ID] = true
+				}
+			}
+		}
+
+		next := []*Node[T]{}
+		for i, n := range g.Nodes {
+			if ready[n.ID] {
+				next = append(next, &g.Nodes[i])
+			}
+		}
+		level = next
+	}
+
+	if seen != len(g.Nodes) {
+		return nil, g.DetectCycle()
+	}
+
+	return levels, nil
+}
+
+// TopologicalSort returns every node in the graph ordered so that each node comes after all of the nodes that have edges to it.
+// If the graph contains a cycle, then a *CycleError is returned.
+func (g *Graph[T]) TopologicalSort() ([]*Node[T], error) {
+	levels, err := g.Levels()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*Node[T], 0, len(g.Nodes))
+	for _, level := range levels {
+		nodes = append(nodes, level...)
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,109 @@
+package dag_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/scribe/pipeline/dag"
+	"github.com/grafana/scribe/testutil"
+)
+
+func nodeLevelIDs[T any](levels [][]*dag.Node[T]) [][]int64 {
+	ids := make([][]int64, len(levels))
+	for i, level := range levels {
+		ids[i] = make([]int64, len(level))
+		for n, v := range level {
+			ids[i][n] = v.ID
+		}
+	}
+
+	return ids
+}
+
+func newSortGraph(t *testing.T) *dag.Graph[Node] {
+	t.Helper()
+	g := dag.New[Node]()
+	for i := int64(0); i < 6; i++ {
+		testutil.EnsureError(t, g.AddNode(i, Node{}), nil)
+	}
+	// 0 -> 1 -> 3 -> 5
+	// 0 -> 2 -> 3
+	//      2 -> 4
+	testutil.EnsureError(t, g.AddEdge(0, 1), nil)
+	testutil.EnsureError(t, g.AddEdge(0, 2), nil)
+	testutil.EnsureError(t, g.AddEdge(1, 3), nil)
+	testutil.EnsureError(t, g.AddEdge(2, 3), nil)
+	testutil.EnsureError(t, g.AddEdge(2, 4), nil)
+	testutil.EnsureError(t, g.AddEdge(3, 5), nil)
+
+	return g
+}
+
+func TestGraphLevels(t *testing.T) {
+	t.Run("Levels should group nodes that do not depend on each other", func(t *testing.T) {
+		g := newSortGraph(t)
+		levels, err := g.Levels()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := [][]int64{{0}, {1, 2}, {3, 4}, {5}}
+		if ids := nodeLevelIDs(levels); !cmp.Equal(ids, expected) {
+			t.Fatalf("Expected levels '%v' but received '%v'", expected, ids)
+		}
+	})
+
+	t.Run("Levels should return a CycleError if the graph has a cycle", func(t *testing.T) {
+		g := newSortGraph(t)
+		testutil.EnsureError(t, g.AddEdge(5, 2), nil)
+
+		_, err := g.Levels()
+		testutil.EnsureError(t, err, dag.ErrorCycle)
+	})
+}
+
+func TestGraphTopologicalSort(t *testing.T) {
+	t.Run("TopologicalSort should return every node after the nodes it depends on", func(t *testing.T) {
+		g := newSortGraph(t)
+		nodes, err := g.TopologicalSort()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		EnsureNodesExist(t, nodes, 0, 1, 2, 3, 4, 5)
+		position := map[int64]int{}
+		for i, v := range nodes {
+			position[v.ID] = i
+		}
+		for from, edges := range g.Edges {
+			for _, e := range edges {
+				if position[from] > position[e.To.ID] {
+					t.Fatalf("Node '%d' is sorted after node '%d' but has an edge to it", from, e.To.ID)
+				}
+			}
+		}
+	})
+
+	t.Run("TopologicalSort should return a CycleError with the path of the cycle", func(t *testing.T) {
+		g := newSortGraph(t)
+		testutil.EnsureError(t, g.AddEdge(5, 2), nil)
+
+		_, err := g.TopologicalSort()
+		cycle := &dag.CycleError{}
+		if !errors.As(err, &cycle) {
+			t.Fatalf("Expected a CycleError but received '%v'", err)
+		}
+
+		expected := []int64{3, 5, 2, 3}
+		if !cmp.Equal(cycle.Path, expected) {
+			t.Fatalf("Expected cycle '%v' but received '%v'", expected, cycle.Path)
+		}
+	})
+
+	t.Run("DetectCycle should find a node with an edge to itself", func(t *testing.T) {
+		g := newSortGraph(t)
+		testutil.EnsureError(t, g.AddEdge(4, 4), nil)
+		testutil.EnsureError(t, g.DetectCycle(), dag.ErrorCycle)
+	})
+}
@@ -0,0 +1,82 @@
+package dag
+
+// Ancestors returns the IDs of every node that has a path to the node with the given ID, in the order that they were found.
+// The node itself is not included.
+func (g *Graph[T]) Ancestors(id int64) []int64 {
+	// Edges are stored by the node that they start at, so build the reverse of the graph first.
+	// The nodes are used instead of ranging over the map of edges so that the order is the same every time.
+	parents := map[int64][]int64{}
+	for _, n := range g.Nodes {
+		for _, e := range g.Edges[n.ID] {
+			parents[e.To.ID] = append(parents[e.To.ID], n.ID)
+		}
+	}
+
+	return walk(id, func(id int64) []int64 {
+		return parents[id]
+	})
+}
+
+// Descendants returns the IDs of every node that the node with the given ID has a path to, in the order that they were found.
+// The node itself is not included.
+func (g *Graph[T]) Descendants(id int64) []int64 {
+	return walk(id, func(id int64) []int64 {
+		edges := g.Edges[id]
+		ids := make([]int64, len(edges))
+		for i, e := range edges {
+			ids[i] = e.To.ID
+		}
+
+		return ids
+	})
+}
+
+// walk performs a breadth-first search from 'id' using 'next' to find the neighbours of a node, and returns every node that it visited except for 'id'.
+func walk(id int64, next func(int64) []int64) []int64 {
+	var (
+		visited = map[int64]bool{id: true}
+		queue   = []int64{id}
+		ids     = []int64{}
+	)
+
+	for len(queue) > 0 {
+		for _, v := range next(queue[0]) {
+			if visited[v] {
+				continue
+			}
+			visited[v] = true
+			queue = append(queue, v)
+			ids = append(ids, v)
+		}
+		queue = queue[1:]
+	}
+
+	return ids
+}
+
+// Subgraph returns a new graph that only contains the nodes with the given IDs and the edges between them.
+// The nodes keep the order that they have in 'g'. IDs that are not in the graph are ignored.
+func (g *Graph[T]) Subgraph(ids ...int64) *Graph[T] {
+	keep := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		keep[id] = true
+	}
+
+	sub := New[T]()
+	for _, n := range g.Nodes {
+		if keep[n.ID] {
+			sub.Nodes = append(sub.Nodes, n)
+		}
+	}
+
+	for _, n := range g.Nodes {
+		for _, e := range g.Edges[n.ID] {
+			if keep[e.From.ID] && keep[e.To.ID] {
+				// Both nodes were added above, so this can not fail.
+				sub.AddEdge(e.From.ID, e.To.ID)
+			}
+		}
+	}
+
+	return sub
+}
@@ -0,0 +1,55 @@
+package dag_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/scribe/pipeline/dag"
+)
+
+func TestGraphAncestors(t *testing.T) {
+	t.Run("Ancestors should return every node with a path to the node", func(t *testing.T) {
+		g := newSortGraph(t)
+		expected := []int64{1, 2, 0}
+		if ids := g.Ancestors(3); !cmp.Equal(ids, expected) {
+			t.Fatalf("Expected ancestors '%v' but received '%v'", expected, ids)
+		}
+	})
+
+	t.Run("Ancestors should return an empty list for the root node", func(t *testing.T) {
+		g := newSortGraph(t)
+		if ids := g.Ancestors(0); len(ids) != 0 {
+			t.Fatalf("Expected no ancestors but received '%v'", ids)
+		}
+	})
+}
+
+func TestGraphDescendants(t *testing.T) {
+	t.Run("Descendants should return every node that the node has a path to", func(t *testing.T) {
+		g := newSortGraph(t)
+		expected := []int64{3, 4, 5}
+		if ids := g.Descendants(2); !cmp.Equal(ids, expected) {
+			t.Fatalf("Expected descendants '%v' but received '%v'", expected, ids)
+		}
+	})
+}
+
+func TestGraphSubgraph(t *testing.T) {
+	t.Run("Subgraph should only keep the edges between the selected nodes", func(t *testing.T) {
+		g := newSortGraph(t)
+		sub := g.Subgraph(0, 2, 3, 5)
+
+		if ids := dag.NodeIDs(sub.Nodes); !cmp.Equal(ids, []int64{0, 2, 3, 5}) {
+			t.Fatalf("Unexpected nodes: %v", ids)
+		}
+
+		expected := map[int64][]int64{0: {2}, 2: {3}, 3: {5}}
+		if edges := dag.EdgesToMap(sub.Edges); !cmp.Equal(edges, expected) {
+			t.Fatalf("Expected edges '%v' but received '%v'", expected, edges)
+		}
+
+		if len(g.Nodes) != 6 {
+			t.Fatalf("Expected the original graph to be unchanged, but it has '%d' nodes", len(g.Nodes))
+		}
+	})
+}
@@ -22,6 +22,12 @@ const (
 	FilterValueGlob
 )
 
+var filterValueTypeStr = []string{"string", "regex", "glob"}
+
+func (f FilterValueType) String() string {
+	return filterValueTypeStr[int(f)]
+}
+
 type FilterValue struct {
 	Type  FilterValueType
 	Value fmt.Stringer
@@ -62,14 +68,35 @@ func GlobFilter(v string) *FilterValue {
 // 'Handling' events means that the the arguments in the `Provides` key should be available before any first steps are ran. It will not typically be up to pipeline developers to decide what arguments an event provides.
 // The only case where this may happen is if the event is a manual one, where users are able to submit the event with any arbitrary set of keys/values.
 // The 'Filters' key is provided in the pipeline code and should not be populated when pre-defined in the Scribe package.
+// The 'Exclude' key works like 'Filters', but the event does not trigger the pipeline if one of its filters matches.
 type Event struct {
 	Name     string
 	Filters  map[string]*FilterValue
+	Exclude  map[string]*FilterValue
 	Provides []state.Argument
 }
 
+// exclude returns the map of exclude filters that contains every filter in 'filters' that is not nil, or nil if there are none.
+func exclude(filters map[string]*FilterValue) map[string]*FilterValue {
+	f := map[string]*FilterValue{}
+	for k, v := range filters {
+		if v != nil {
+			f[k] = v
+		}
+	}
+
+	if len(f) == 0 {
+		return nil
+	}
+
+	return f
+}
+
 type GitCommitFilters struct {
 	Branch *FilterValue
+
+	// ExcludeBranch prevents commits to the matching branches from triggering the pipeline, even if they match 'Branch'.
+	ExcludeBranch *FilterValue
 }
 
 // GitCommitEventArgs are arguments that should provide in the pipeline state when a pipeline was created from a git commit event.
@@ -89,12 +116,16 @@ func GitCommitEvent(filters GitCommitFilters) Event {
 	return Event{
 		Name:     "git-commit",
 		Filters:  f,
+		Exclude:  exclude(map[string]*FilterValue{"branch": filters.ExcludeBranch}),
 		Provides: GitCommitEventArgs,
 	}
 }
 
 type GitTagFilters struct {
 	Name *FilterValue
+
+	// ExcludeName prevents the matching tags from triggering the pipeline, even if they match 'Name'.
+	ExcludeName *FilterValue
 }
 
 // GitTagEventArgs are arguments that should provide in the pipeline state when a pipeline was created from a git tag event.
@@ -111,21 +142,85 @@ func GitTagEvent(filters GitTagFilters) Event {
 	return Event{
 		Name:     "git-tag",
 		Filters:  f,
+		Exclude:  exclude(map[string]*FilterValue{"tag": filters.ExcludeName}),
 		Provides: GitTagEventArgs,
 	}
 }
 
-type PullRequestFilters struct{}
+type PullRequestFilters struct {
+	// TargetBranch is the branch that the pull request will be merged into.
+	TargetBranch *FilterValue
+
+	// ExcludeTargetBranch prevents pull requests into the matching branches from triggering the pipeline, even if they match 'TargetBranch'.
+	ExcludeTargetBranch *FilterValue
+}
 
 // PullRequestEventArgs are arguments that should provide in the pipeline state when a pipeline was created from a pull request.
-var PullRequestEventArgs = []state.Argument{}
+var PullRequestEventArgs = []state.Argument{
+	ArgumentTargetBranch,
+}
 
 func PullRequestEvent(filters PullRequestFilters) Event {
 	f := map[string]*FilterValue{}
 
+	if filters.TargetBranch != nil {
+		f["target-branch"] = filters.TargetBranch
+	}
+
 	return Event{
 		Name:     "pull-request",
 		Filters:  f,
+		Exclude:  exclude(map[string]*FilterValue{"target-branch": filters.ExcludeTargetBranch}),
 		Provides: PullRequestEventArgs,
 	}
 }
+
+type CronFilters struct {
+	// Job is the name of the scheduled job. The schedule of the job is configured in the CI service.
+	Job *FilterValue
+}
+
+// CronEventArgs are arguments that should provide in the pipeline state when a pipeline was created from a scheduled job.
+var CronEventArgs = []state.Argument{
+	ArgumentCronJob,
+}
+
+// CronEvent is the event of a scheduled job, like a nightly build.
+func CronEvent(filters CronFilters) Event {
+	f := map[string]*FilterValue{}
+
+	if filters.Job != nil {
+		f["cron"] = filters.Job
+	}
+
+	return Event{
+		Name:     "cron",
+		Filters:  f,
+		Provides: CronEventArgs,
+	}
+}
+
+type PromoteFilters struct {
+	// Target is the environment that the build is promoted to, like 'production'.
+	Target *FilterValue
+}
+
+// PromoteEventArgs are arguments that should provide in the pipeline state when a pipeline was created from a promotion.
+var PromoteEventArgs = []state.Argument{
+	ArgumentPromoteTarget,
+}
+
+// PromoteEvent is the event of a build that is manually promoted to an environment, like deploying a build to production. See https://docs.drone.io/promote/.
+func PromoteEvent(filters PromoteFilters) Event {
+	f := map[string]*FilterValue{}
+
+	if filters.Target != nil {
+		f["target"] = filters.Target
+	}
+
+	return Event{
+		Name:     "promote",
+		Filters:  f,
+		Provides: PromoteEventArgs,
+	}
+}
@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"sort"
+	"strings"
+)
+
+// MatrixValues is a single combination of values in a matrix, where the keys are the keys of the matrix.
+type MatrixValues map[string]string
+
+// Keys returns the keys of the matrix values in sorted order.
+func (m MatrixValues) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Name appends the values to 'name' in the order of their keys, so that every combination in a matrix gets a unique name.
+// For example, 'build' with the values '{goos: linux, goarch: amd64}' becomes 'build amd64-linux'.
+func (m MatrixValues) Name(name string) string {
+	keys := m.Keys()
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = m[k]
+	}
+
+	return name + " " + strings.Join(values, "-")
+}
+
+// MatrixEnvKey returns the name of the environment variable that has the value of the matrix key in the steps created with Matrix.
+// The key is upper-cased and every character that is not a letter or a digit is replaced with '_'. For example, 'goos' becomes 'MATRIX_GOOS'.
+func MatrixEnvKey(key string) string {
+	return "MATRIX_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+
+		return '_'
+	}, key)
+}
+
+// MatrixCombinations returns every combination of the values in the matrix.
+// The combinations are ordered by the keys of the matrix and then by the order of their values, so that the result is the same every time.
+func MatrixCombinations(matrix map[string][]string) []MatrixValues {
+	keys := make([]string, 0, len(matrix))
+	for k := range matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	combinations := []MatrixValues{{}}
+	for _, k := range keys {
+		next := make([]MatrixValues, 0, len(combinations)*len(matrix[k]))
+		for _, c := range combinations {
+			for _, v := range matrix[k] {
+				m := make(MatrixValues, len(c)+1)
+				for ck, cv := range c {
+					m[ck] = cv
+				}
+				m[k] = v
+				next = append(next, m)
+			}
+		}
+		combinations = next
+	}
+
+	return combinations
+}
+
+// Matrix calls 'f' once for every combination of the values in the matrix and returns the resulting steps.
+// Every step is given a unique name (see 'MatrixValues.Name'), and its matrix values are added to its environment (see 'MatrixEnvKey'),
+// so that they are available in 'ActionOpts.Env', in the commands that the step runs, and in the step's container in clients like Drone.
+// The steps do not have IDs yet; like any other step, they are given IDs when they are added to a pipeline with 'Scribe.Add', so the IDs are the same in every client.
+func Matrix(matrix map[string][]string, f func(MatrixValues) Step) []Step {
+	combinations := MatrixCombinations(matrix)
+	steps := make([]Step, len(combinations))
+	for i, values := range combinations {
+		step := f(values)
+		step.Name = values.Name(step.Name)
+		step.Matrix = values
+		for _, k := range values.Keys() {
+			step = step.WithEnvVar(MatrixEnvKey(k), NewEnvString(values[k]))
+		}
+
+		steps[i] = step
+	}
+
+	return steps
+}
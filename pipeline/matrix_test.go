@@ -0,0 +1,85 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/state"
+)
+
+func TestMatrixCombinations(t *testing.T) {
+	t.Run("It should return every combination ordered by key and then by value", func(t *testing.T) {
+		combinations := pipeline.MatrixCombinations(map[string][]string{
+			"goos":   {"linux", "darwin"},
+			"goarch": {"amd64", "arm64"},
+		})
+
+		expected := []pipeline.MatrixValues{
+			{"goarch": "amd64", "goos": "linux"},
+			{"goarch": "amd64", "goos": "darwin"},
+			{"goarch": "arm64", "goos": "linux"},
+			{"goarch": "arm64", "goos": "darwin"},
+		}
+
+		if !cmp.Equal(combinations, expected) {
+			t.Fatal(cmp.Diff(combinations, expected))
+		}
+	})
+
+	t.Run("It should return no combinations for an empty matrix", func(t *testing.T) {
+		if combinations := pipeline.MatrixCombinations(map[string][]string{}); len(combinations) != 0 {
+			t.Fatalf("expected no combinations but got %d", len(combinations))
+		}
+	})
+}
+
+func TestMatrix(t *testing.T) {
+	matrix := map[string][]string{
+		"goos":   {"linux", "windows"},
+		"goarch": {"amd64"},
+	}
+
+	t.Run("It should create a step with a unique name for every combination", func(t *testing.T) {
+		steps := pipeline.Matrix(matrix, func(v pipeline.MatrixValues) pipeline.Step {
+			return pipeline.NoOpStep.WithName("build")
+		})
+
+		names := pipeline.StepNames(steps)
+		expected := []string{"build amd64-linux", "build amd64-windows"}
+		if !cmp.Equal(names, expected) {
+			t.Fatal(cmp.Diff(names, expected))
+		}
+	})
+
+	t.Run("It should add the matrix values to the environment of every step", func(t *testing.T) {
+		steps := pipeline.Matrix(matrix, func(v pipeline.MatrixValues) pipeline.Step {
+			return pipeline.NamedStep("build", func(ctx context.Context, opts pipeline.ActionOpts) error {
+				if goos := opts.Getenv("MATRIX_GOOS"); goos != v["goos"] {
+					t.Errorf("expected matrix value 'goos' to be '%s' but got '%s'", v["goos"], goos)
+				}
+				return nil
+			})
+		})
+
+		for _, step := range steps {
+			env, err := step.Environment.Environ(context.Background(), state.NewNoOpHandler())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := step.Action(context.Background(), pipeline.ActionOpts{Env: env}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestMatrixEnvKey(t *testing.T) {
+	t.Run("It should upper-case the key and replace the characters that can not be in a variable name", func(t *testing.T) {
+		if v := pipeline.MatrixEnvKey("go-version.1"); v != "MATRIX_GO_VERSION_1" {
+			t.Fatalf("expected 'MATRIX_GO_VERSION_1' but got '%s'", v)
+		}
+	})
+}
@@ -3,14 +3,17 @@ package pipeline
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/grafana/scribe/pipeline/dag"
 	"github.com/grafana/scribe/state"
+	"github.com/grafana/scribe/stringutil"
 )
 
 var (
 	ErrorNoStepProvider    = errors.New("no step in the graph provides a required argument")
 	ErrorAmbiguousProvider = errors.New("more than one step provides the same argument(s)")
+	ErrorDuplicateStepName = errors.New("more than one step in the pipeline has the same name")
 )
 
 // A Pipeline is really similar to a Step, except that it contains a graph of steps rather than
@@ -27,6 +30,9 @@ type Pipeline struct {
 
 	RequiredArgs state.Arguments
 	ProvidedArgs state.Arguments
+
+	// Timeout is the maximum amount of time that every step in the pipeline can run for. If it is 0, then there is no timeout.
+	Timeout time.Duration
 }
 
 func (p Pipeline) SetProvider(arg state.Argument, id int64) error {
@@ -37,10 +43,35 @@ func (p Pipeline) SetProvider(arg state.Argument, id int64) error {
 	return nil
 }
 
+// checkStepName returns an ErrorDuplicateStepName if there is already a step in the pipeline with the same name as 'step', or with a name that has the same slug.
+// Steps are selected by their name (see 'MatchStepName'), and some clients use the slug of the name as an identifier, so both have to be unique.
+func (p *Pipeline) checkStepName(step Step) error {
+	if step.Name == "" {
+		return nil
+	}
+
+	for _, v := range p.Graph.Nodes {
+		if v.Value.Name == "" {
+			continue
+		}
+
+		if v.Value.Name == step.Name || stringutil.Slugify(v.Value.Name) == stringutil.Slugify(step.Name) {
+			return fmt.Errorf("%w: '%s' in pipeline '%s'", ErrorDuplicateStepName, step.Name, p.Name)
+		}
+	}
+
+	return nil
+}
+
 // AddStep adds all of the provided steps into the pipeline.
 // The Step's ID field is used as the node ID.
+// If a step has the same name as a step that is already in the pipeline, then an ErrorDuplicateStepName is returned.
 func (p *Pipeline) AddSteps(steps ...Step) error {
 	for _, v := range steps {
+		if err := p.checkStepName(v); err != nil {
+			return err
+		}
+
 		id := v.ID
 		// This node doesn't require anything before running, therefore it is a root node.
 		if len(v.RequiredArgs) == 0 {
@@ -60,7 +91,7 @@ func (p *Pipeline) AddSteps(steps ...Step) error {
 }
 
 // BuildEdges generates the edges of the step graph based on the required / provided args of each step.
-// It will return an error if there are required arguments that are not satisfied.
+// It will return an error if there are required arguments that are not satisfied, or if the steps depend on each other in a cycle.
 func (p Pipeline) BuildEdges(rootArgs ...state.Argument) error {
 	for _, v := range rootArgs {
 		if err := p.SetProvider(v, 0); err != nil {
@@ -110,6 +141,11 @@ func (p Pipeline) BuildEdges(rootArgs ...state.Argument) error {
 		}
 	}
 
+	// Steps that require arguments from each other can never run, so fail while the pipeline is being defined instead.
+	if _, err := p.Graph.TopologicalSort(); err != nil {
+		return fmt.Errorf("error in pipeline '%s': %w", p.Name, stepCycleError(p.Graph, err))
+	}
+
 	return nil
 }
 
@@ -123,6 +159,40 @@ func (p Pipeline) Provides(args ...state.Argument) Pipeline {
 	return p
 }
 
+// WithTimeout sets the maximum amount of time that every step in the pipeline can run for.
+func (p Pipeline) WithTimeout(timeout time.Duration) Pipeline {
+	p.Timeout = timeout
+	return p
+}
+
+// everyStep returns true if the pipeline has at least one step and 'f' returns true for every step.
+func (p Pipeline) everyStep(f func(Step) bool) bool {
+	n := 0
+	for _, node := range p.Graph.Nodes {
+		// Skip the root step that's always present on every pipeline.
+		if node.ID == 0 {
+			continue
+		}
+		if !f(node.Value) {
+			return false
+		}
+		n++
+	}
+
+	return n != 0
+}
+
+// AllowFailure returns true if every step in the pipeline is allowed to fail, so the pipeline itself can never fail.
+func (p Pipeline) AllowFailure() bool {
+	return p.everyStep(func(s Step) bool { return s.AllowFailure })
+}
+
+// AlwaysRun returns true if every step in the pipeline always runs, so the pipeline should run even if a pipeline that it depends on failed.
+// A pipeline that has only some steps that always run does not run after a pipeline that it depends on failed; put those steps in their own pipeline instead.
+func (p Pipeline) AlwaysRun() bool {
+	return p.everyStep(func(s Step) bool { return s.AlwaysRun })
+}
+
 func nodeID(steps []Step) int64 {
 	return steps[len(steps)-1].ID
 }
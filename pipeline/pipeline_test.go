@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/dag"
 	"github.com/grafana/scribe/state"
+	"github.com/grafana/scribe/testutil"
 )
 
 func TestBuildEdges(t *testing.T) {
@@ -22,4 +24,28 @@ func TestBuildEdges(t *testing.T) {
 			t.Fatal(err)
 		}
 	})
+	t.Run("BuildEdges should return an error that names the steps and arguments in a cycle", func(t *testing.T) {
+		var (
+			argVersion  = state.NewStringArgument("version")
+			argArtifact = state.NewFileArgument("artifact")
+		)
+
+		p := pipeline.New("test-pipeline", 1)
+		build := pipeline.NoOpStep.WithName("build").Requires(argVersion).Provides(argArtifact)
+		build.ID = 2
+		publish := pipeline.NoOpStep.WithName("publish").Requires(argArtifact).Provides(argVersion)
+		publish.ID = 3
+
+		if err := p.AddSteps(build, publish); err != nil {
+			t.Fatal(err)
+		}
+
+		err := p.BuildEdges()
+		testutil.EnsureError(t, err, dag.ErrorCycle)
+
+		expected := "error in pipeline 'test-pipeline': graph contains a cycle: 'build' -[artifact]-> 'publish' -[version]-> 'build'"
+		if err.Error() != expected {
+			t.Fatalf("Unexpected error message.\nExpected: %s\nReceived: %s", expected, err.Error())
+		}
+	})
 }
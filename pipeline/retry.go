@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Retry defines how a step is retried when its Action returns an error.
+// Retries are handled while the step is running, so every client that runs steps with the 'cli' client (like the Dagger, Drone, GitHub, and GitLab clients) honors them.
+type Retry struct {
+	// Attempts is the maximum number of times that the Action is ran, including the first attempt.
+	// If it is 1 or less, then the Action is never retried.
+	Attempts int
+
+	// Backoff is how long to wait after the first failed attempt. The wait doubles after every following failed attempt.
+	Backoff time.Duration
+
+	// RetryOn returns true if the error returned by the Action should be retried.
+	// If it is nil, then every error is retried.
+	RetryOn func(error) bool
+}
+
+// Enabled returns true if the Action would be ran more than once.
+func (r Retry) Enabled() bool {
+	return r.Attempts > 1
+}
+
+func (r Retry) shouldRetry(err error) bool {
+	if r.RetryOn == nil {
+		return true
+	}
+
+	return r.RetryOn(err)
+}
+
+// Wrap returns an Action that runs 'action' until it succeeds, until it returns an error that should not be retried, or until it has been ran 'Attempts' times.
+// If retries are not enabled, then the action is returned unchanged.
+func (r Retry) Wrap(action Action) Action {
+	if action == nil || !r.Enabled() {
+		return action
+	}
+
+	return func(ctx context.Context, opts ActionOpts) error {
+		backoff := r.Backoff
+		for attempt := 1; ; attempt++ {
+			err := action(ctx, opts)
+			if err == nil {
+				return nil
+			}
+
+			if !r.shouldRetry(err) {
+				return err
+			}
+
+			if attempt >= r.Attempts {
+				return fmt.Errorf("failed after %d attempts: %w", attempt, err)
+			}
+
+			if opts.Logger != nil {
+				opts.Logger.Warnf("attempt %d of %d failed with error '%s', retrying in %s", attempt, r.Attempts, err.Error(), backoff)
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+		}
+	}
+}
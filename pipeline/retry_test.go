@@ -0,0 +1,82 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/testutil"
+)
+
+// failingAction returns an action that fails 'n' times before it succeeds and counts how many times it was ran.
+func failingAction(n int, err error, count *int) pipeline.Action {
+	return func(context.Context, pipeline.ActionOpts) error {
+		*count++
+		if *count <= n {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestRetryWrap(t *testing.T) {
+	errTest := errors.New("test error")
+
+	t.Run("It should not retry if the policy has 1 attempt or less", func(t *testing.T) {
+		count := 0
+		action := pipeline.Retry{Attempts: 1}.Wrap(failingAction(1, errTest, &count))
+
+		testutil.EnsureError(t, action(context.Background(), pipeline.ActionOpts{}), errTest)
+		if count != 1 {
+			t.Fatalf("expected action to run once but it ran %d times", count)
+		}
+	})
+
+	t.Run("It should run the action until it succeeds", func(t *testing.T) {
+		count := 0
+		action := pipeline.Retry{Attempts: 3}.Wrap(failingAction(2, errTest, &count))
+
+		testutil.EnsureError(t, action(context.Background(), pipeline.ActionOpts{}), nil)
+		if count != 3 {
+			t.Fatalf("expected action to run 3 times but it ran %d times", count)
+		}
+	})
+
+	t.Run("It should return the last error once every attempt fails", func(t *testing.T) {
+		count := 0
+		action := pipeline.Retry{Attempts: 3}.Wrap(failingAction(5, errTest, &count))
+
+		testutil.EnsureError(t, action(context.Background(), pipeline.ActionOpts{}), errTest)
+		if count != 3 {
+			t.Fatalf("expected action to run 3 times but it ran %d times", count)
+		}
+	})
+
+	t.Run("It should not retry errors that RetryOn rejects", func(t *testing.T) {
+		count := 0
+		action := pipeline.Retry{
+			Attempts: 3,
+			RetryOn: func(err error) bool {
+				return !errors.Is(err, errTest)
+			},
+		}.Wrap(failingAction(5, errTest, &count))
+
+		testutil.EnsureError(t, action(context.Background(), pipeline.ActionOpts{}), errTest)
+		if count != 1 {
+			t.Fatalf("expected action to run once but it ran %d times", count)
+		}
+	})
+
+	t.Run("It should stop waiting for the backoff if the context is cancelled", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		var (
+			count       = 0
+			ctx, cancel = context.WithCancel(context.Background())
+		)
+		action := pipeline.Retry{Attempts: 3, Backoff: time.Hour}.Wrap(failingAction(5, errTest, &count))
+		cancel()
+
+		testutil.EnsureError(t, action(ctx, pipeline.ActionOpts{}), context.Canceled)
+	}))
+}
@@ -0,0 +1,167 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/grafana/scribe/pipeline/dag"
+	"github.com/grafana/scribe/stringutil"
+)
+
+// MatchStepName reports whether the step name 'name' matches the glob 'pattern' (see 'path.Match').
+// The pattern is matched against both the name and its slug (see 'stringutil.Slugify'), so 'build_*' matches a step named 'build backend'.
+func MatchStepName(pattern, name string) (bool, error) {
+	if ok, err := path.Match(pattern, name); err != nil || ok {
+		return ok, err
+	}
+
+	return path.Match(pattern, stringutil.Slugify(name))
+}
+
+// WithSteps returns a new Collection that only contains the steps with the provided IDs.
+// Like 'WithDownstream', the arguments that the steps require from the removed steps must already be in the state, or be provided with the '-arg' flag.
+// The edges of the collection must already be built (see 'BuildEdges'); the edges of the new Collection are copied from it.
+func (c *Collection) WithSteps(ids ...int64) (*Collection, error) {
+	return c.selectSteps(ids, func(*dag.Graph[Step], int64) []int64 {
+		return nil
+	}, func(int64) []int64 {
+		return nil
+	})
+}
+
+// WithUpstream returns a new Collection that only contains the steps with the provided IDs and every step that they depend on, directly or indirectly.
+// The pipelines that the pipelines of those steps depend on are kept whole.
+// The edges of the collection must already be built (see 'BuildEdges'); the edges of the new Collection are copied from it.
+func (c *Collection) WithUpstream(ids ...int64) (*Collection, error) {
+	return c.selectSteps(ids, func(g *dag.Graph[Step], id int64) []int64 {
+		return g.Ancestors(id)
+	}, c.Graph.Ancestors)
+}
+
+// WithDownstream returns a new Collection that only contains the steps with the provided IDs and every step that depends on them, directly or indirectly.
+// The pipelines that depend on the pipelines of those steps are kept whole.
+// Steps that no longer have a step to wait for start right away, so the arguments that they require from the removed steps must already be in the state, or be provided with the '-arg' flag.
+// The edges of the collection must already be built (see 'BuildEdges'); the edges of the new Collection are copied from it.
+func (c *Collection) WithDownstream(ids ...int64) (*Collection, error) {
+	return c.selectSteps(ids, func(g *dag.Graph[Step], id int64) []int64 {
+		return g.Descendants(id)
+	}, c.Graph.Descendants)
+}
+
+// WithPipelines returns a new Collection that only contains the pipelines with the provided names.
+// Like 'WithSteps', the arguments that the pipelines require from the removed pipelines must already be in the state, or be provided with the '-arg' flag.
+// The edges of the collection must already be built (see 'BuildEdges'); the edges of the new Collection are copied from it.
+func (c *Collection) WithPipelines(ctx context.Context, names ...string) (*Collection, error) {
+	pipelines, err := c.PipelinesByName(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := []int64{0}
+	for _, v := range pipelines {
+		keep = append(keep, v.ID)
+	}
+
+	graph := c.Graph.Subgraph(keep...)
+
+	return &Collection{
+		Graph:     graph,
+		Providers: c.Providers,
+		Root:      connectRoot(graph),
+	}, nil
+}
+
+// pipelineOf returns the pipeline that contains the step with the provided ID.
+func (c *Collection) pipelineOf(id int64) (Pipeline, error) {
+	for _, p := range c.Graph.Nodes {
+		if p.ID == 0 {
+			continue
+		}
+		if _, err := p.Value.Graph.Node(id); err == nil {
+			return p.Value, nil
+		}
+	}
+
+	return Pipeline{}, fmt.Errorf("%w. id: %d", ErrorStepNotFound, id)
+}
+
+// selectSteps creates a new Collection with the steps with the provided IDs and the steps related to them by 'steps',
+// and with every pipeline related to their pipelines by 'pipelines'.
+func (c *Collection) selectSteps(ids []int64, steps func(*dag.Graph[Step], int64) []int64, pipelines func(int64) []int64) (*Collection, error) {
+	var (
+		// selected is the list of steps to keep for each pipeline that is not kept whole.
+		selected = map[int64][]int64{}
+		whole    = map[int64]bool{}
+	)
+
+	for _, id := range ids {
+		p, err := c.pipelineOf(id)
+		if err != nil {
+			return nil, err
+		}
+
+		selected[p.ID] = append(selected[p.ID], id)
+		selected[p.ID] = append(selected[p.ID], steps(p.Graph, id)...)
+	}
+
+	for id := range selected {
+		for _, v := range pipelines(id) {
+			whole[v] = true
+		}
+	}
+
+	keep := []int64{0}
+	for _, n := range c.Graph.Nodes {
+		if _, ok := selected[n.ID]; ok || whole[n.ID] {
+			keep = append(keep, n.ID)
+		}
+	}
+
+	graph := c.Graph.Subgraph(keep...)
+	for i, n := range graph.Nodes {
+		if stepIDs, ok := selected[n.ID]; ok && !whole[n.ID] {
+			graph.Nodes[i].Value = n.Value.subset(stepIDs)
+		}
+	}
+
+	return &Collection{
+		Graph:     graph,
+		Providers: c.Providers,
+		Root:      connectRoot(graph),
+	}, nil
+}
+
+// subset returns a copy of the pipeline that only contains the steps with the provided IDs.
+func (p Pipeline) subset(ids []int64) Pipeline {
+	graph := p.Graph.Subgraph(append([]int64{0}, ids...)...)
+
+	p.Graph = graph
+	p.Root = connectRoot(graph)
+	return p
+}
+
+// connectRoot adds an edge from the root node (0) to every other node in the graph that no node has an edge to, so that they still run after other nodes were removed from the graph.
+// It returns the IDs of every node that the root node has an edge to.
+func connectRoot[T any](g *dag.Graph[T]) []int64 {
+	indegree := map[int64]int{}
+	for _, edges := range g.Edges {
+		for _, e := range edges {
+			indegree[e.To.ID]++
+		}
+	}
+
+	for _, n := range g.Nodes {
+		if n.ID != 0 && indegree[n.ID] == 0 {
+			// Both nodes are in the graph, so this can not fail.
+			g.AddEdge(0, n.ID)
+		}
+	}
+
+	root := []int64{}
+	for _, n := range g.Adj(0) {
+		root = append(root, n.ID)
+	}
+
+	return root
+}
@@ -0,0 +1,235 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/dag"
+	"github.com/grafana/scribe/state"
+	"github.com/grafana/scribe/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	argumentBinary  = state.NewFileArgument("binary")
+	argumentTested  = state.NewBoolArgument("tested")
+	argumentPackage = state.NewFileArgument("package")
+)
+
+// newSelectCollection creates a collection with two pipelines where the 'publish' pipeline depends on the 'build' pipeline:
+//
+//	build:   0 -> compile (2) -> test (3) -> package (4)
+//	         0 -> lint (5)
+//	publish: 0 -> upload (11)
+func newSelectCollection(t *testing.T) *pipeline.Collection {
+	t.Helper()
+
+	build := pipeline.New("build", 1)
+	build.ProvidedArgs = []state.Argument{argumentPackage}
+
+	publish := pipeline.New("publish", 10)
+	publish.RequiredArgs = []state.Argument{argumentPackage}
+
+	col := pipeline.NewCollection()
+	testutil.EnsureError(t, col.AddPipelines(build, publish), nil)
+	testutil.EnsureError(t, col.AddSteps(1,
+		pipeline.Step{ID: 2, Name: "compile"}.Provides(argumentBinary),
+		pipeline.Step{ID: 3, Name: "test"}.Requires(argumentBinary).Provides(argumentTested),
+		pipeline.Step{ID: 4, Name: "package"}.Requires(argumentTested).Provides(argumentPackage),
+		pipeline.Step{ID: 5, Name: "lint"},
+	), nil)
+	testutil.EnsureError(t, col.AddSteps(10,
+		pipeline.Step{ID: 11, Name: "upload"},
+	), nil)
+	testutil.EnsureError(t, col.BuildEdges(logrus.New()), nil)
+
+	return col
+}
+
+// stepGraph returns the node IDs and edges of the steps in the pipeline with the provided ID.
+func stepGraph(t *testing.T, col *pipeline.Collection, id int64) ([]int64, map[int64][]int64) {
+	t.Helper()
+
+	node, err := col.Graph.Node(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dag.NodeIDs(node.Value.Graph.Nodes), dag.EdgesToMap(node.Value.Graph.Edges)
+}
+
+func TestCollectionWithUpstream(t *testing.T) {
+	t.Run("It should keep the step and every step that it depends on", func(t *testing.T) {
+		col, err := newSelectCollection(t).WithUpstream(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ids := dag.NodeIDs(col.Graph.Nodes); !cmp.Equal(ids, []int64{0, 1}) {
+			t.Fatalf("Unexpected pipelines: %v", ids)
+		}
+
+		nodes, edges := stepGraph(t, col, 1)
+		if expected := []int64{0, 2, 3, 4}; !cmp.Equal(nodes, expected) {
+			t.Fatalf("Expected steps '%v' but received '%v'", expected, nodes)
+		}
+
+		expected := map[int64][]int64{0: {2}, 2: {3}, 3: {4}}
+		if !cmp.Equal(edges, expected) {
+			t.Fatalf("Expected edges '%v' but received '%v'", expected, edges)
+		}
+	})
+
+	t.Run("It should keep the pipelines that the step's pipeline depends on whole", func(t *testing.T) {
+		col, err := newSelectCollection(t).WithUpstream(11)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ids := dag.NodeIDs(col.Graph.Nodes); !cmp.Equal(ids, []int64{0, 1, 10}) {
+			t.Fatalf("Unexpected pipelines: %v", ids)
+		}
+
+		if nodes, _ := stepGraph(t, col, 1); !cmp.Equal(nodes, []int64{0, 2, 3, 4, 5}) {
+			t.Fatalf("Expected every step in the 'build' pipeline but received '%v'", nodes)
+		}
+	})
+
+	t.Run("It should not modify the original collection", func(t *testing.T) {
+		original := newSelectCollection(t)
+		if _, err := original.WithUpstream(3); err != nil {
+			t.Fatal(err)
+		}
+
+		if nodes, _ := stepGraph(t, original, 1); !cmp.Equal(nodes, []int64{0, 2, 3, 4, 5}) {
+			t.Fatalf("Expected every step in the original collection but received '%v'", nodes)
+		}
+	})
+
+	t.Run("It should return ErrorStepNotFound if the step does not exist", func(t *testing.T) {
+		_, err := newSelectCollection(t).WithUpstream(100)
+		if !errors.Is(err, pipeline.ErrorStepNotFound) {
+			t.Fatalf("Expected error '%v' but received '%v'", pipeline.ErrorStepNotFound, err)
+		}
+	})
+}
+
+func TestCollectionWithDownstream(t *testing.T) {
+	t.Run("It should keep the step and every step that depends on it", func(t *testing.T) {
+		col, err := newSelectCollection(t).WithDownstream(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ids := dag.NodeIDs(col.Graph.Nodes); !cmp.Equal(ids, []int64{0, 1, 10}) {
+			t.Fatalf("Unexpected pipelines: %v", ids)
+		}
+
+		nodes, edges := stepGraph(t, col, 1)
+		if expected := []int64{0, 3, 4}; !cmp.Equal(nodes, expected) {
+			t.Fatalf("Expected steps '%v' but received '%v'", expected, nodes)
+		}
+
+		// The step no longer has a step to wait for, so it should start right away.
+		expected := map[int64][]int64{0: {3}, 3: {4}}
+		if !cmp.Equal(edges, expected) {
+			t.Fatalf("Expected edges '%v' but received '%v'", expected, edges)
+		}
+	})
+
+	t.Run("It should select the steps between two steps when combined with WithUpstream", func(t *testing.T) {
+		col, err := newSelectCollection(t).WithDownstream(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		col, err = col.WithUpstream(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if nodes, _ := stepGraph(t, col, 1); !cmp.Equal(nodes, []int64{0, 2, 3}) {
+			t.Fatalf("Expected steps '%v' but received '%v'", []int64{0, 2, 3}, nodes)
+		}
+	})
+}
+
+func TestCollectionWithSteps(t *testing.T) {
+	t.Run("It should only keep the selected steps", func(t *testing.T) {
+		col, err := newSelectCollection(t).WithSteps(3, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ids := dag.NodeIDs(col.Graph.Nodes); !cmp.Equal(ids, []int64{0, 1}) {
+			t.Fatalf("Unexpected pipelines: %v", ids)
+		}
+
+		nodes, edges := stepGraph(t, col, 1)
+		if expected := []int64{0, 3, 5}; !cmp.Equal(nodes, expected) {
+			t.Fatalf("Expected steps '%v' but received '%v'", expected, nodes)
+		}
+
+		// 'lint' already started at the root node, and 'test' no longer has a step to wait for.
+		expected := map[int64][]int64{0: {5, 3}}
+		if !cmp.Equal(edges, expected) {
+			t.Fatalf("Expected edges '%v' but received '%v'", expected, edges)
+		}
+	})
+}
+
+func TestCollectionWithPipelines(t *testing.T) {
+	t.Run("It should only keep the selected pipelines", func(t *testing.T) {
+		col, err := newSelectCollection(t).WithPipelines(context.Background(), "Publish")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ids := dag.NodeIDs(col.Graph.Nodes); !cmp.Equal(ids, []int64{0, 10}) {
+			t.Fatalf("Unexpected pipelines: %v", ids)
+		}
+
+		// 'publish' no longer has a pipeline to wait for, so it starts at the root node.
+		if edges := dag.EdgesToMap(col.Graph.Edges); !cmp.Equal(edges, map[int64][]int64{0: {10}}) {
+			t.Fatalf("Unexpected edges: %v", edges)
+		}
+	})
+
+	t.Run("It should return an error if no pipeline has the name", func(t *testing.T) {
+		if _, err := newSelectCollection(t).WithPipelines(context.Background(), "deploy"); err == nil {
+			t.Fatal("Expected an error but received none")
+		}
+	})
+}
+
+func TestMatchStepName(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "test", name: "test", expected: true},
+		{pattern: "test*", name: "test backend", expected: true},
+		{pattern: "test_backend", name: "test backend", expected: true},
+		{pattern: "build_*", name: "build-frontend", expected: true},
+		{pattern: "test", name: "test backend", expected: false},
+		{pattern: "*lint", name: "test", expected: false},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("It should return %t for the pattern '%s' and the name '%s'", c.expected, c.pattern, c.name), func(t *testing.T) {
+			ok, err := pipeline.MatchStepName(c.pattern, c.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ok != c.expected {
+				t.Fatalf("Expected '%t' but received '%t'", c.expected, ok)
+			}
+		})
+	}
+}
@@ -3,8 +3,11 @@ package pipeline
 import (
 	"context"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/grafana/scribe/state"
+	"github.com/grafana/scribe/stringutil"
 	"github.com/opentracing/opentracing-go"
 	"github.com/sirupsen/logrus"
 )
@@ -28,6 +31,19 @@ const (
 	PipelineTypeSub
 )
 
+var (
+	stepTypeStr     = []string{"default", "background"}
+	pipelineTypeStr = []string{"default", "sub"}
+)
+
+func (t StepType) String() string {
+	return stepTypeStr[int(t)]
+}
+
+func (t PipelineType) String() string {
+	return pipelineTypeStr[int(t)]
+}
+
 // The ActionOpts are provided to every step that is ran.
 // Each step can choose to use these options.
 type ActionOpts struct {
@@ -42,6 +58,20 @@ type ActionOpts struct {
 	// Version refers to the version of Scribe that was used to run the pipeline.
 	// This value is set using the `-version` argument when running a pipeline, which is automatically set by the `scribe` command.
 	Version string
+	// Env is the environment of the step (see 'Step.Environment') in the 'KEY=value' format.
+	// The cli client only sets it in the environment of the process when it runs a single step, so commands that the action runs should add Env to their environment, like the functions in the 'exec' package do.
+	Env []string
+}
+
+// Getenv returns the value of the variable in the step's environment ('Env'), or an empty string if the step's environment does not have it.
+func (o ActionOpts) Getenv(key string) string {
+	for _, v := range o.Env {
+		if k, value, ok := strings.Cut(v, "="); ok && k == key {
+			return value
+		}
+	}
+
+	return ""
 }
 
 // A Step stores a Action and a name for use in pipelines.
@@ -74,6 +104,33 @@ type Step struct {
 	ProvidedArgs state.Arguments
 
 	Environment StepEnv
+
+	// Retry defines if and how the step's Action is retried when it returns an error. By default, steps are not retried.
+	Retry Retry
+
+	// Timeout is the maximum amount of time that the step can run for, including every retry. If it is 0, then there is no timeout.
+	Timeout time.Duration
+
+	// AllowFailure is true if the step is allowed to fail. The error is logged, but the steps that depend on this step still run and the pipeline does not fail.
+	AllowFailure bool
+
+	// AlwaysRun is true if the step runs even if a step that it depends on failed, or if the pipeline was cancelled.
+	// This is typically used for cleanup and notification steps.
+	AlwaysRun bool
+
+	// Events are the events that this step runs on. If none of the events match (see 'Event.Matches'), then the step is skipped.
+	// If there are no events, then the step runs whenever its pipeline runs.
+	Events []Event
+
+	// Condition is evaluated before the step runs. If it returns false, then the step is skipped.
+	Condition Condition
+
+	// Matrix holds the values of the matrix combination that this step was created for by 'Matrix'.
+	Matrix MatrixValues
+
+	// Cache defines the inputs and outputs of the step. If the inputs have not changed since a previous run, then local clients skip the step and restore its outputs.
+	// If it is nil, then the step always runs.
+	Cache *Cache
 }
 
 func (s Step) IsBackground() bool {
@@ -85,24 +142,128 @@ func (s Step) WithImage(image string) Step {
 	return s
 }
 
+// WithTimeout sets the maximum amount of time that the step can run for. Clients cancel the context provided to the Action once the timeout has passed,
+// wait for the Action to return, and report the step as timed out rather than failed.
+func (s Step) WithTimeout(timeout time.Duration) Step {
+	s.Timeout = timeout
+	return s
+}
+
+// When sets the events that this step runs on. This works like 'Scribe.When', but only for this step.
+// Skipped steps are treated as completed, so the steps that depend on them still run. However, a skipped step does not provide any arguments.
+func (s Step) When(events ...Event) Step {
+	s.Events = events
+	return s
+}
+
+// WithCondition sets a condition that is evaluated against the state before this step runs. If it returns false, then the step is skipped.
+// Skipped steps are treated as completed, so the steps that depend on them still run. However, a skipped step does not provide any arguments.
+func (s Step) WithCondition(c Condition) Step {
+	s.Condition = c
+	return s
+}
+
+// ShouldRun returns true if one of the step's events matches the state and if the step's condition is true.
+func (s Step) ShouldRun(ctx context.Context, r state.Reader) (bool, error) {
+	if len(s.Events) != 0 {
+		matched := false
+		for _, e := range s.Events {
+			ok, err := e.Matches(ctx, r)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false, nil
+		}
+	}
+
+	if s.Condition == nil {
+		return true, nil
+	}
+
+	return s.Condition(ctx, r)
+}
+
+// WithAllowFailure marks the step as allowed to fail. If the step fails, the error is logged, but the steps that depend on it still run and the pipeline does not fail.
+func (s Step) WithAllowFailure() Step {
+	s.AllowFailure = true
+	return s
+}
+
+// WithAlwaysRun marks the step to always run once the steps that it depends on have completed, even if they failed or the pipeline was cancelled.
+// These steps are not cancelled when another step fails or when the pipeline is cancelled, which makes them useful for cleanup and notifications.
+func (s Step) WithAlwaysRun() Step {
+	s.AlwaysRun = true
+	return s
+}
+
+// WithCache makes the step cacheable, adding the inputs and outputs from the provided cachers to the step's Cache.
+// The arguments that the step provides are always part of its outputs.
+//
+// Example:
+//
+//	pipeline.NamedStep("install dependencies", yarn.InstallAction()).
+//		WithCache(fs.Cache("node_modules", fs.FileHasChanged("yarn.lock")))
+func (s Step) WithCache(cachers ...Cacher) Step {
+	s.Cache = s.Cache.With(cachers...)
+	return s
+}
+
+// CacheOutputs returns the step's Cache with the arguments that the step provides added to its outputs.
+// If the Cache does not have a name, then it is named after the step, so that steps with the same inputs have different cache keys.
+// If the step is not cacheable, then it returns nil.
+func (s Step) CacheOutputs() *Cache {
+	if s.Cache == nil {
+		return nil
+	}
+
+	cachers := []Cacher{CacheArguments(s.ProvidedArgs...)}
+	if s.Cache.Name == "" {
+		cachers = append(cachers, CacheName(stringutil.Slugify(s.Name)))
+	}
+
+	return s.Cache.With(cachers...)
+}
+
+// WithRetry sets the retry policy for this step. Clients that run the step re-run its Action according to the policy when it returns an error.
+func (s Step) WithRetry(retry Retry) Step {
+	s.Retry = retry
+	return s
+}
+
 // WithEnvVar appends a new EnvVar to the Step's environment, replacing existing EnvVars with the provided key.
 // If an EnvVar is provided with a type of EnvVarArgument, then the argument is also added to this step's required arguments.
+// The Drone and dagger clients set the variable in the step's container, and the cli client sets it in the environment of the process when it runs a single step ('--step').
+// When the cli client runs every step in a pipeline ('--pipeline'), the steps share the process, so the variable is only available in 'ActionOpts.Env'.
 func (s Step) WithEnvVar(key string, val EnvVar) Step {
-	if val.Type == EnvVarArgument {
-		s = s.Requires(val.Argument())
+	// The environment is copied so that steps that are created from the same step do not share it.
+	env := make(StepEnv, len(s.Environment)+1)
+	for k, v := range s.Environment {
+		env[k] = v
 	}
-	return s
+	env[key] = val
+
+	return s.WithEnvironment(env)
 }
 
 // WithEnvironment replaces the entire environment for this step.
 // If an EnvVar is provided with a type of EnvVarArgument, then the argument is also added to this step's required arguments.
 func (s Step) WithEnvironment(env StepEnv) Step {
-	for _, v := range env {
-		if v.Type == EnvVarArgument {
-			s = s.Requires(v.Argument())
+	// The arguments in the environment are added to a copy of the required arguments so that the arguments that the step already requires are kept.
+	required := append(state.Arguments{}, s.RequiredArgs...)
+	for _, k := range env.Keys() {
+		if v := env[k]; v.Type == EnvVarArgument && !state.ArgListContains(required, v.Argument()) {
+			required = append(required, v.Argument())
 		}
 	}
 
+	s.RequiredArgs = required
 	s.Environment = env
 	return s
 }
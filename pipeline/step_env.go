@@ -1,6 +1,12 @@
 package pipeline
 
-import "github.com/grafana/scribe/state"
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/scribe/state"
+)
 
 type EnvVarType int
 
@@ -36,7 +42,7 @@ func NewEnvArgument(arg state.Argument) EnvVar {
 // NewEnvString creates a new EnvVar that will be populated with a static string value.
 func NewEnvString(val string) EnvVar {
 	return EnvVar{
-		Type: EnvVarArgument,
+		Type: EnvVarString,
 		str:  val,
 	}
 }
@@ -52,7 +58,7 @@ func (e EnvVar) String() string {
 }
 
 // Argument retrieves the argument value set when using the NewEnvArgument function.
-// If the EnvVar's Type property is not "EnvVarString" then it will panic.
+// If the EnvVar's Type property is not "EnvVarArgument" then it will panic.
 func (e EnvVar) Argument() state.Argument {
 	if e.Type != EnvVarArgument {
 		panic("envvar is not an argument type, but Argument() was called")
@@ -60,3 +66,59 @@ func (e EnvVar) Argument() state.Argument {
 
 	return e.argument
 }
+
+// Value returns the value of the EnvVar. Static values are returned as they are, and the values of arguments are read from the state.
+func (e EnvVar) Value(ctx context.Context, r state.Reader) (string, error) {
+	if e.Type == EnvVarString {
+		return e.str, nil
+	}
+
+	value, err := state.GetValueAsString(ctx, r, e.argument)
+	if err != nil {
+		return "", fmt.Errorf("error getting value of argument '%s': %w", e.argument.Key, err)
+	}
+
+	return value, nil
+}
+
+// Keys returns the names of the environment variables in alphabetical order, so that they are always added in the same order.
+func (e StepEnv) Keys() []string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Values returns the value of every environment variable, reading the values of arguments from the state.
+// Steps require the arguments in their environment, so they are in the state by the time the step runs.
+func (e StepEnv) Values(ctx context.Context, r state.Reader) (map[string]string, error) {
+	values := make(map[string]string, len(e))
+	for k, v := range e {
+		value, err := v.Value(ctx, r)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable '%s': %w", k, err)
+		}
+
+		values[k] = value
+	}
+
+	return values, nil
+}
+
+// Environ returns the environment variables in the 'KEY=value' format of 'os.Environ', in alphabetical order.
+func (e StepEnv) Environ(ctx context.Context, r state.Reader) ([]string, error) {
+	values, err := e.Values(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	env := make([]string, 0, len(values))
+	for _, k := range e.Keys() {
+		env = append(env, fmt.Sprintf("%s=%s", k, values[k]))
+	}
+
+	return env, nil
+}
@@ -1,9 +1,12 @@
 package pipeline_test
 
 import (
+	"context"
 	"testing"
 
+	"github.com/grafana/scribe/args"
 	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/state"
 )
 
 func TestStepIsBackground(t *testing.T) {
@@ -14,3 +17,94 @@ func TestStepIsBackground(t *testing.T) {
 		t.Fatal("step.IsBackground should return true if the step.Type is pipeline.StepTypeBackground")
 	}
 }
+
+func TestStepWithEnvVar(t *testing.T) {
+	var (
+		version = state.NewStringArgument("version")
+		token   = state.NewSecretArgument("token")
+	)
+
+	t.Run("It should store static values", func(t *testing.T) {
+		step := pipeline.NamedStep("test", pipeline.DefaultAction).WithEnvVar("GOOS", pipeline.NewEnvString("linux"))
+
+		v, ok := step.Environment["GOOS"]
+		if !ok {
+			t.Fatal("Expected the step environment to contain 'GOOS'")
+		}
+		if v.String() != "linux" {
+			t.Fatalf("Expected 'GOOS' to be 'linux' but received '%s'", v.String())
+		}
+		if len(step.RequiredArgs) != 0 {
+			t.Fatalf("Expected static values to not require any arguments but received '%v'", step.RequiredArgs)
+		}
+	})
+
+	t.Run("It should require the arguments in the environment and keep the existing required arguments", func(t *testing.T) {
+		step := pipeline.NamedStep("test", pipeline.DefaultAction).
+			Requires(pipeline.ArgumentSourceFS).
+			WithEnvVar("VERSION", pipeline.NewEnvArgument(version)).
+			WithEnvVar("TOKEN", pipeline.NewEnvArgument(token)).
+			WithEnvVar("VERSION_AGAIN", pipeline.NewEnvArgument(version))
+
+		if len(step.Environment) != 3 {
+			t.Fatalf("Expected the step environment to have 3 values but received '%d'", len(step.Environment))
+		}
+
+		expected := state.Arguments{pipeline.ArgumentSourceFS, token, version}
+		if len(step.RequiredArgs) != len(expected) {
+			t.Fatalf("Expected the step to require '%v' but received '%v'", expected, step.RequiredArgs)
+		}
+		for _, v := range expected {
+			if !state.ArgListContains(step.RequiredArgs, v) {
+				t.Fatalf("Expected the step to require '%s' but received '%v'", v.Key, step.RequiredArgs)
+			}
+		}
+	})
+
+	t.Run("It should not modify the environment of the step that it was created from", func(t *testing.T) {
+		step := pipeline.NamedStep("test", pipeline.DefaultAction).WithEnvVar("A", pipeline.NewEnvString("a"))
+		step.WithEnvVar("B", pipeline.NewEnvString("b"))
+
+		if _, ok := step.Environment["B"]; ok {
+			t.Fatal("Expected the environment of the original step to not contain 'B'")
+		}
+	})
+}
+
+func TestStepEnvValues(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		version = state.NewStringArgument("version")
+		env     = pipeline.StepEnv{
+			"GOOS":    pipeline.NewEnvString("linux"),
+			"VERSION": pipeline.NewEnvArgument(version),
+		}
+	)
+
+	t.Run("It should return static values and read arguments from the state", func(t *testing.T) {
+		values, err := env.Values(ctx, state.NewArgMapReader(args.ArgMap{"version": "v1.0.0"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if values["GOOS"] != "linux" {
+			t.Fatalf("Expected 'GOOS' to be 'linux' but received '%s'", values["GOOS"])
+		}
+		if values["VERSION"] != "v1.0.0" {
+			t.Fatalf("Expected 'VERSION' to be 'v1.0.0' but received '%s'", values["VERSION"])
+		}
+	})
+
+	t.Run("It should return an error if an argument is not in the state", func(t *testing.T) {
+		if _, err := env.Values(ctx, state.NewArgMapReader(args.ArgMap{})); err == nil {
+			t.Fatal("Expected an error but received none")
+		}
+	})
+
+	t.Run("It should return the keys in alphabetical order", func(t *testing.T) {
+		keys := env.Keys()
+		if len(keys) != 2 || keys[0] != "GOOS" || keys[1] != "VERSION" {
+			t.Fatalf("Expected keys '[GOOS VERSION]' but received '%v'", keys)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrorTimeout is wrapped by every TimeoutError so that timeouts can be checked with errors.Is.
+var ErrorTimeout = errors.New("timed out")
+
+// TimeoutError is returned when a step or pipeline does not complete before its timeout.
+// It allows a step that hung to be told apart from a step that failed.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s after %s", ErrorTimeout, e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return ErrorTimeout
+}
+
+// cancelledError is returned by RunWithTimeout when the parent context is cancelled and 'f' returns an error.
+// It wraps the error from 'f', and errors.Is also matches the context's error.
+type cancelledError struct {
+	cause error
+	err   error
+}
+
+func (e *cancelledError) Error() string {
+	return fmt.Sprintf("%s: %s", e.cause, e.err)
+}
+
+func (e *cancelledError) Unwrap() error {
+	return e.err
+}
+
+func (e *cancelledError) Is(target error) bool {
+	return errors.Is(e.cause, target)
+}
+
+// RunWithTimeout calls 'f' with a context that is cancelled once the timeout has passed.
+// If the timeout passes, then the context is cancelled and a *TimeoutError is returned once 'f' returns. Waiting for 'f' lets it clean up, and lets steps that always run complete.
+// If the provided context is cancelled before 'f' returns, then the error from 'f' is returned wrapped with the context's error. If 'f' returns nil, then nil is returned, even if the timeout has passed.
+// If the timeout is 0 or less, then 'f' is called with the provided context.
+func RunWithTimeout(ctx context.Context, timeout time.Duration, f func(context.Context) error) error {
+	if timeout <= 0 {
+		return f(ctx)
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := f(tctx)
+
+	// 'f' can complete right as the timeout passes; it still succeeded.
+	if err == nil {
+		return nil
+	}
+
+	// The parent context being cancelled also cancels 'tctx', but it is not a timeout of 'f'.
+	if ctx.Err() != nil {
+		return &cancelledError{cause: ctx.Err(), err: err}
+	}
+
+	if errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Timeout: timeout}
+	}
+
+	return err
+}
+
+// TimeoutAction returns an Action that runs 'action' with RunWithTimeout.
+// If the timeout is 0 or less, then the action is returned unchanged.
+func TimeoutAction(timeout time.Duration, action Action) Action {
+	if action == nil || timeout <= 0 {
+		return action
+	}
+
+	return func(ctx context.Context, opts ActionOpts) error {
+		return RunWithTimeout(ctx, timeout, func(ctx context.Context) error {
+			return action(ctx, opts)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/testutil"
+)
+
+func TestRunWithTimeout(t *testing.T) {
+	t.Run("It should return the error from the function if it completes before the timeout", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		errTest := errors.New("test error")
+		err := pipeline.RunWithTimeout(context.Background(), time.Minute, func(ctx context.Context) error {
+			return errTest
+		})
+
+		testutil.EnsureError(t, err, errTest)
+	}))
+
+	t.Run("It should cancel the context and return a TimeoutError once the timeout passes", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		err := pipeline.RunWithTimeout(context.Background(), time.Millisecond*10, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		testutil.EnsureError(t, err, pipeline.ErrorTimeout)
+
+		var timeoutErr *pipeline.TimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("expected error to be a *pipeline.TimeoutError but got %T", err)
+		}
+		if timeoutErr.Timeout != time.Millisecond*10 {
+			t.Fatalf("expected timeout to be 10ms but got '%s'", timeoutErr.Timeout)
+		}
+	}))
+
+	t.Run("It should wait for the function to return before returning the TimeoutError", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		returned := false
+		err := pipeline.RunWithTimeout(context.Background(), time.Millisecond*10, func(ctx context.Context) error {
+			<-ctx.Done()
+			// Cleaning up after the timeout.
+			time.Sleep(time.Millisecond * 20)
+			returned = true
+			return ctx.Err()
+		})
+
+		testutil.EnsureError(t, err, pipeline.ErrorTimeout)
+		if !returned {
+			t.Fatal("expected RunWithTimeout to wait for the function to return")
+		}
+	}))
+
+	t.Run("It should not report a timeout if the parent context is cancelled", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := pipeline.RunWithTimeout(ctx, time.Minute, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		testutil.EnsureError(t, err, context.Canceled)
+		if errors.Is(err, pipeline.ErrorTimeout) {
+			t.Fatal("a cancelled context should not be reported as a timeout")
+		}
+	}))
+
+	t.Run("It should return the errors from both the function and the parent context if the parent context is cancelled", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		errTest := errors.New("test error")
+		err := pipeline.RunWithTimeout(ctx, time.Minute, func(ctx context.Context) error {
+			return errTest
+		})
+
+		testutil.EnsureError(t, err, context.Canceled)
+		testutil.EnsureError(t, err, errTest)
+	}))
+
+	t.Run("It should not report a timeout if the function succeeds as the timeout passes", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		err := pipeline.RunWithTimeout(context.Background(), time.Millisecond*10, func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		testutil.EnsureError(t, err, nil)
+	}))
+
+	t.Run("It should not set a deadline if the timeout is 0", func(t *testing.T) {
+		err := pipeline.RunWithTimeout(context.Background(), 0, func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); ok {
+				t.Error("expected context to have no deadline")
+			}
+			return nil
+		})
+
+		testutil.EnsureError(t, err, nil)
+	})
+}
@@ -0,0 +1,227 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/scribe/errors"
+	"github.com/grafana/scribe/pipeline/dag"
+	"github.com/grafana/scribe/state"
+)
+
+var (
+	ErrorUnusedArgument = errors.New("argument is provided but never required")
+	ErrorLatestImage    = errors.New("image does not have a fixed tag")
+	ErrorSecretArgument = errors.New("secret is passed through an argument that is not a secret")
+	ErrorInvalidStep    = errors.New("step is not valid for a client")
+	ErrorNoStepName     = errors.New("step has no name")
+)
+
+// secretKeywords are the words that are commonly found in the keys of arguments that hold secrets.
+var secretKeywords = []string{"secret", "token", "password", "passwd", "credential", "api-key", "api_key", "apikey", "private-key", "private_key"}
+
+// A StepValidator checks if a step can be used. The 'Validate' function of every client is a StepValidator.
+type StepValidator func(Step) error
+
+// Validate reports the problems in the pipelines and steps of the collection without running them.
+// Unlike 'BuildEdges', it does not stop at the first problem and it does not modify the collection, so it can be used before the edges are built.
+// The 'validators' are called for every step, and are typically the 'Validate' functions of the clients that the pipeline is used with.
+// The problems are returned in the order that the pipelines and steps were added.
+func Validate(c *Collection, validators ...StepValidator) []*errors.PipelineError {
+	v := &validator{}
+
+	pipelines := []Pipeline{}
+	for _, node := range c.Graph.Nodes {
+		if node.ID == 0 {
+			continue
+		}
+		pipelines = append(pipelines, node.Value)
+	}
+
+	v.validatePipelines(c, pipelines)
+
+	// An argument that is provided by a step is used if a step in any pipeline requires it, or if its pipeline provides it to other pipelines.
+	used := state.Arguments{}
+	for _, p := range pipelines {
+		used = append(used, p.ProvidedArgs...)
+		for _, s := range steps(p) {
+			used = append(used, s.RequiredArgs...)
+		}
+	}
+
+	for _, p := range pipelines {
+		v.validateSteps(p, used, validators)
+	}
+
+	return v.problems
+}
+
+type validator struct {
+	problems []*errors.PipelineError
+}
+
+func (v *validator) add(err error, format string, args ...any) {
+	v.problems = append(v.problems, errors.NewPipelineError(err.Error(), fmt.Sprintf(format, args...)))
+}
+
+// steps returns every step in the pipeline without the root node.
+func steps(p Pipeline) []Step {
+	s := []Step{}
+	for _, node := range p.Graph.Nodes {
+		if node.ID != 0 {
+			s = append(s, node.Value)
+		}
+	}
+
+	return s
+}
+
+// providers returns the IDs of the nodes that provide each argument.
+func providers[T any](nodes []dag.Node[T], provided func(T) state.Arguments) map[state.Argument][]int64 {
+	p := map[state.Argument][]int64{}
+	for _, n := range nodes {
+		if n.ID == 0 {
+			continue
+		}
+		for _, arg := range provided(n.Value) {
+			p[arg] = append(p[arg], n.ID)
+		}
+	}
+
+	return p
+}
+
+// edges creates a copy of the graph with an edge from the providers of every argument to the nodes that require it, so that cycles can be detected.
+func edges[T any](nodes []dag.Node[T], p map[state.Argument][]int64, required func(T) state.Arguments) *dag.Graph[T] {
+	g := dag.New[T]()
+	for _, n := range nodes {
+		g.AddNode(n.ID, n.Value)
+	}
+
+	for _, n := range nodes {
+		for _, arg := range required(n.Value) {
+			for _, id := range p[arg] {
+				g.AddEdge(id, n.ID)
+			}
+		}
+	}
+
+	return g
+}
+
+func (v *validator) validatePipelines(c *Collection, pipelines []Pipeline) {
+	var (
+		provided = func(p Pipeline) state.Arguments { return p.ProvidedArgs }
+		required = func(p Pipeline) state.Arguments { return p.RequiredArgs }
+		p        = providers(c.Graph.Nodes, provided)
+	)
+
+	for _, pipeline := range pipelines {
+		for _, arg := range pipeline.ProvidedArgs {
+			if ids := p[arg]; len(ids) > 1 && ids[0] == pipeline.ID {
+				v.add(ErrorAmbiguousProvider, "argument '%s' is provided by %d pipelines, including '%s'", arg.Key, len(ids), pipeline.Name)
+			}
+		}
+
+		for _, arg := range pipeline.RequiredArgs {
+			if len(p[arg]) == 0 && arg.Type != state.ArgumentTypeSecret && !state.ArgListContains(ClientProvidedArguments, arg) {
+				v.add(ErrorNoPipelineProvider, "argument '%s' (%s) is required by pipeline '%s'", arg.Key, arg.Type.String(), pipeline.Name)
+			}
+		}
+	}
+
+	g := edges(c.Graph.Nodes, p, required)
+	if err := g.DetectCycle(); err != nil {
+		path, err := cyclePath(g, err, func(p Pipeline) string { return p.Name }, provided, required)
+		if err != nil {
+			path = err.Error()
+		}
+		v.add(dag.ErrorCycle, "the pipelines depend on each other: %s", path)
+	}
+}
+
+func (v *validator) validateSteps(p Pipeline, used state.Arguments, validators []StepValidator) {
+	var (
+		provided      = func(s Step) state.Arguments { return s.ProvidedArgs }
+		required      = func(s Step) state.Arguments { return s.RequiredArgs }
+		stepProviders = providers(p.Graph.Nodes, provided)
+	)
+
+	for _, s := range steps(p) {
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("unnamed-step-%d", s.ID)
+			v.add(ErrorNoStepName, "step '%d' in pipeline '%s' has no name", s.ID, p.Name)
+		}
+
+		for _, validate := range validators {
+			if err := validate(s); err != nil {
+				v.add(ErrorInvalidStep, "step '%s' in pipeline '%s': %s", name, p.Name, err)
+			}
+		}
+
+		if s.Image != "" && latestImage(s.Image) {
+			v.add(ErrorLatestImage, "step '%s' in pipeline '%s' uses the image '%s'; use a specific tag so that the step does not change between runs", name, p.Name, s.Image)
+		}
+
+		for _, arg := range s.ProvidedArgs {
+			if ids := stepProviders[arg]; len(ids) > 1 && ids[0] == s.ID {
+				v.add(ErrorAmbiguousProvider, "argument '%s' is provided by %d steps in pipeline '%s', including '%s'", arg.Key, len(ids), p.Name, name)
+			}
+
+			if !state.ArgListContains(used, arg) {
+				v.add(ErrorUnusedArgument, "argument '%s' is provided by step '%s' in pipeline '%s', but no step requires it", arg.Key, name, p.Name)
+			}
+		}
+
+		for _, arg := range append(append(state.Arguments{}, s.RequiredArgs...), s.ProvidedArgs...) {
+			if arg.Type == state.ArgumentTypeString && secretKey(arg.Key) {
+				v.add(ErrorSecretArgument, "argument '%s' of step '%s' in pipeline '%s' looks like a secret; use 'state.NewSecretArgument' so that its value is not stored or logged", arg.Key, name, p.Name)
+			}
+		}
+
+		for _, arg := range s.RequiredArgs {
+			if len(stepProviders[arg]) != 0 ||
+				arg.Type == state.ArgumentTypeSecret ||
+				state.ArgListContains(ClientProvidedArguments, arg) ||
+				state.ArgListContains(p.RequiredArgs, arg) {
+				continue
+			}
+
+			v.add(ErrorNoStepProvider, "argument '%s' (%s) is required by step '%s' in pipeline '%s'", arg.Key, arg.Type.String(), name, p.Name)
+		}
+	}
+
+	g := edges(p.Graph.Nodes, stepProviders, required)
+	if err := g.DetectCycle(); err != nil {
+		path, err := cyclePath(g, err, func(s Step) string { return s.Name }, provided, required)
+		if err != nil {
+			path = err.Error()
+		}
+		v.add(dag.ErrorCycle, "the steps in pipeline '%s' depend on each other: %s", p.Name, path)
+	}
+}
+
+// latestImage returns true if the image has no tag or digest, or if its tag is 'latest'.
+func latestImage(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+
+	// The registry can have a port, like 'localhost:5000/image', so only look for a tag in the last part of the name.
+	name := image[strings.LastIndex(image, "/")+1:]
+	i := strings.LastIndex(name, ":")
+
+	return i == -1 || name[i+1:] == "latest"
+}
+
+func secretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, v := range secretKeywords {
+		if strings.Contains(key, v) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,148 @@
+package pipeline_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/state"
+	"github.com/grafana/scribe/testutil"
+)
+
+// newValidateCollection creates a collection with one pipeline that contains the provided steps.
+// The steps are added to the graph directly so that steps that 'AddSteps' would reject can be validated.
+func newValidateCollection(t *testing.T, steps ...pipeline.Step) *pipeline.Collection {
+	t.Helper()
+
+	p := pipeline.New("test", 1)
+	for _, v := range steps {
+		testutil.EnsureError(t, p.Graph.AddNode(v.ID, v), nil)
+	}
+
+	col := pipeline.NewCollection()
+	testutil.EnsureError(t, col.AddPipelines(p), nil)
+
+	return col
+}
+
+// problems returns the 'Err' of every problem so that they can be compared.
+func problems(col *pipeline.Collection, validators ...pipeline.StepValidator) []string {
+	p := pipeline.Validate(col, validators...)
+	errs := make([]string, len(p))
+	for i, v := range p {
+		errs[i] = v.Err
+	}
+
+	return errs
+}
+
+func TestValidate(t *testing.T) {
+	var (
+		argA = state.NewStringArgument("a")
+		argB = state.NewStringArgument("b")
+	)
+
+	t.Run("It should not report any problems in a valid pipeline", func(t *testing.T) {
+		col := newValidateCollection(t,
+			pipeline.Step{ID: 2, Name: "a", Image: "alpine:3.16"}.Provides(argA),
+			pipeline.Step{ID: 3, Name: "b", Image: "alpine:3.16"}.Requires(argA, pipeline.ArgumentSourceFS, state.NewSecretArgument("token")),
+		)
+
+		if p := problems(col); len(p) != 0 {
+			t.Fatalf("Expected no problems but received '%v'", p)
+		}
+	})
+
+	cases := []struct {
+		Name     string
+		Steps    []pipeline.Step
+		Expected []error
+	}{
+		{
+			Name:     "It should report arguments that no step provides",
+			Steps:    []pipeline.Step{pipeline.Step{ID: 2, Name: "a", Image: "alpine:3.16"}.Requires(argA)},
+			Expected: []error{pipeline.ErrorNoStepProvider},
+		},
+		{
+			Name: "It should report arguments that are provided by more than one step",
+			Steps: []pipeline.Step{
+				pipeline.Step{ID: 2, Name: "a", Image: "alpine:3.16"}.Provides(argA),
+				pipeline.Step{ID: 3, Name: "b", Image: "alpine:3.16"}.Provides(argA),
+				pipeline.Step{ID: 4, Name: "c", Image: "alpine:3.16"}.Requires(argA),
+			},
+			Expected: []error{pipeline.ErrorAmbiguousProvider},
+		},
+		{
+			Name:     "It should report arguments that no step requires",
+			Steps:    []pipeline.Step{pipeline.Step{ID: 2, Name: "a", Image: "alpine:3.16"}.Provides(argA)},
+			Expected: []error{pipeline.ErrorUnusedArgument},
+		},
+		{
+			Name: "It should report steps that depend on each other",
+			Steps: []pipeline.Step{
+				pipeline.Step{ID: 2, Name: "a", Image: "alpine:3.16"}.Requires(argB).Provides(argA),
+				pipeline.Step{ID: 3, Name: "b", Image: "alpine:3.16"}.Requires(argA).Provides(argB),
+			},
+			Expected: []error{errors.New("graph contains a cycle")},
+		},
+		{
+			Name:     "It should report steps without a name",
+			Steps:    []pipeline.Step{{ID: 2, Image: "alpine:3.16"}},
+			Expected: []error{pipeline.ErrorNoStepName},
+		},
+		{
+			Name: "It should report images without a fixed tag",
+			Steps: []pipeline.Step{
+				{ID: 2, Name: "a", Image: "alpine"},
+				{ID: 3, Name: "b", Image: "alpine:latest"},
+				{ID: 4, Name: "c", Image: "localhost:5000/alpine"},
+				{ID: 5, Name: "d", Image: "localhost:5000/alpine:3.16"},
+				{ID: 6, Name: "e", Image: "alpine@sha256:bc41182d7ef5ffc53a40b044e725193bc10142a1243f395ee852a8d9730fc2ad"},
+			},
+			Expected: []error{pipeline.ErrorLatestImage, pipeline.ErrorLatestImage, pipeline.ErrorLatestImage},
+		},
+		{
+			Name: "It should report secrets in string arguments",
+			Steps: []pipeline.Step{
+				pipeline.Step{ID: 2, Name: "a", Image: "alpine:3.16"}.Requires(state.NewStringArgument("github-token")),
+			},
+			Expected: []error{pipeline.ErrorSecretArgument, pipeline.ErrorNoStepProvider},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			p := problems(newValidateCollection(t, c.Steps...))
+			if len(p) != len(c.Expected) {
+				t.Fatalf("Expected '%d' problems but received '%v'", len(c.Expected), p)
+			}
+
+			for i, v := range c.Expected {
+				if p[i] != v.Error() {
+					t.Fatalf("Expected problem '%d' to be '%s' but received '%s'", i, v.Error(), p[i])
+				}
+			}
+		})
+	}
+
+	t.Run("It should report the errors returned by the validators", func(t *testing.T) {
+		col := newValidateCollection(t, pipeline.Step{ID: 2, Name: "a", Image: "alpine:3.16"})
+		validator := func(pipeline.Step) error {
+			return errors.New("invalid step")
+		}
+
+		p := pipeline.Validate(col, validator)
+		if len(p) != 1 || p[0].Err != pipeline.ErrorInvalidStep.Error() {
+			t.Fatalf("Expected an invalid step problem but received '%v'", p)
+		}
+	})
+
+	t.Run("It should report pipelines that require an argument that no pipeline provides", func(t *testing.T) {
+		col := pipeline.NewCollection()
+		testutil.EnsureError(t, col.AddPipelines(pipeline.New("test", 1).Requires(argA)), nil)
+
+		if p := problems(col); len(p) != 1 || p[0] != pipeline.ErrorNoPipelineProvider.Error() {
+			t.Fatalf("Expected a missing provider problem but received '%v'", p)
+		}
+	})
+}
@@ -24,6 +24,10 @@ import (
 
 var ErrorCancelled = errors.New("cancelled")
 
+// ErrorTimeout is wrapped by the error returned when a step or pipeline does not complete before its timeout.
+// It can be checked with errors.Is to tell a step that hung apart from a step that failed.
+var ErrorTimeout = pipeline.ErrorTimeout
+
 const DefaultPipelineID int64 = 1
 
 // Scribe is the client that is used in every pipeline to declare the steps that make up a pipeline.
@@ -42,7 +46,13 @@ type Scribe struct {
 	n        *counter
 	pipeline int64
 
+	// cache is shared with the other pipelines of a ScribeMulti so that every cached action uses the same CacheStore.
+	cache *cacheStore
+
 	prevPipelines []pipeline.Pipeline
+
+	// problems are the errors that were found while the pipeline was defined with the '--validate' argument (see 'fatal').
+	problems []error
 }
 
 // Pipeline returns the current Pipeline ID used in the collection.
@@ -67,21 +77,29 @@ func (s *Scribe) When(events ...pipeline.Event) {
 	}
 }
 
+// Timeout sets the maximum amount of time that every step in this pipeline can run for.
+// Clients that run the pipeline cancel the steps that are still running once the timeout has passed.
+func (s *Scribe) Timeout(timeout time.Duration) {
+	if err := s.Collection.SetTimeout(s.pipeline, timeout); err != nil {
+		s.Log.WithError(err).Fatalln("Failed to set the pipeline timeout")
+	}
+}
+
 // Background allows users to define steps that run in the background. In some environments this is referred to as a "Service" or "Background service".
 // In many scenarios, users would like to simply use a docker image with the default command. In order to accomplish that, simply provide a step without an action.
+// In Drone, background steps without an action are services, and background steps with an action are detached steps. The other steps can reach them using their name as the hostname.
+// The cli client runs background steps with an action next to the other steps of the pipeline when it is ran with '--pipeline'.
+// The dagger client runs background steps next to the other steps too, but can not bind them to a hostname, so the other steps can not reach them over the network.
 func (s *Scribe) Background(steps ...pipeline.Step) {
-	if err := s.validateSteps(steps...); err != nil {
-		s.Log.Fatalln(err)
-	}
-
+	// The type is set before the steps are validated so that clients can reject background steps.
 	for i := range steps {
 		steps[i].Type = pipeline.StepTypeBackground
 	}
 
 	steps = s.setup(steps...)
 
-	if err := s.Collection.AddSteps(s.pipeline, steps...); err != nil {
-		s.Log.Fatalln(err)
+	if err := s.runSteps(steps...); err != nil {
+		s.fatal(err)
 	}
 }
 
@@ -92,13 +110,25 @@ func (s *Scribe) Add(steps ...pipeline.Step) {
 	steps = s.setup(steps...)
 
 	if err := s.runSteps(steps...); err != nil {
+		s.fatal(err)
+	}
+}
+
+// fatal stops the program with the error. If the pipeline is being validated ('--validate'), then the error is reported with the other problems in the pipeline instead.
+func (s *Scribe) fatal(err error) {
+	if !s.Opts.Args.Validate {
 		s.Log.Fatalln(err)
 	}
+
+	s.problems = append(s.problems, err)
 }
 
 func (s *Scribe) runSteps(steps ...pipeline.Step) error {
-	if err := s.validateSteps(steps...); err != nil {
-		return err
+	// When the pipeline is being validated, every step is validated by 'pipeline.Validate' along with the rest of the pipeline.
+	if !s.Opts.Args.Validate {
+		if err := s.validateSteps(steps...); err != nil {
+			return err
+		}
 	}
 
 	if err := s.Collection.AddSteps(s.pipeline, steps...); err != nil {
@@ -108,8 +138,25 @@ func (s *Scribe) runSteps(steps ...pipeline.Step) error {
 	return nil
 }
 
-func (s *Scribe) Cache(action pipeline.Action, c pipeline.Cacher) pipeline.Action {
-	return action
+// Cache returns an Action that is skipped if the inputs added by the cachers have not changed since a previous run. Its outputs are restored from the cache instead.
+// The returned action checks the cache itself, so the cache is only used when the action runs in the same process as the pipeline, like with the 'cli' client.
+// 'Step.WithCache' should be preferred, as it allows every local client to skip the step entirely.
+// The returned action does not know the name of its step, so actions that are cached with the same inputs should be given different names with 'pipeline.CacheName'.
+func (s *Scribe) Cache(action pipeline.Action, cachers ...pipeline.Cacher) pipeline.Action {
+	c := pipeline.NewCache(cachers...)
+	if s.cache == nil {
+		s.cache = &cacheStore{}
+	}
+	cache := s.cache
+
+	return func(ctx context.Context, opts pipeline.ActionOpts) error {
+		store, err := cache.get(ctx, s.Opts.Args)
+		if err != nil {
+			return err
+		}
+
+		return store.Wrap(c, action)(ctx, opts)
+	}
 }
 
 func (s *Scribe) setup(steps ...pipeline.Step) []pipeline.Step {
@@ -170,6 +217,12 @@ func (s *Scribe) Execute(ctx context.Context, collection *pipeline.Collection) e
 		if err := collection.BuildEdges(s.Log, rootArgs...); err != nil {
 			return err
 		}
+
+		c, err := selectSteps(ctx, s.Opts.Args, collection)
+		if err != nil {
+			return err
+		}
+		collection = c
 	}
 
 	if err := s.Client.Done(ctx, collection); err != nil {
@@ -184,7 +237,7 @@ func (s *Scribe) Done() {
 		log = s.Log
 	)
 
-	if err := execute(ctx, s.Collection, nameOrDefault(s.Opts.Name), s.Opts, s.n, s.Execute); err != nil {
+	if err := execute(ctx, s.Collection, nameOrDefault(s.Opts.Name), s.Opts, s.n, validation{client: s.Client, problems: s.problems}, s.Execute); err != nil {
 		log.WithError(err).Fatalln("error in execution")
 	}
 }
@@ -267,7 +320,8 @@ func NewWithClient(opts clients.CommonOpts, client pipeline.Client) *Scribe {
 		Collection: NewDefaultCollection(opts),
 		pipeline:   DefaultPipelineID,
 
-		n: &counter{1},
+		n:     &counter{1},
+		cache: &cacheStore{},
 	}
 }
 
@@ -276,7 +330,8 @@ func NewWithClient(opts clients.CommonOpts, client pipeline.Client) *Scribe {
 func NewClient(ctx context.Context, c clients.CommonOpts, collection *pipeline.Collection) *Scribe {
 	c.Log.Infof("Initializing Scribe client '%s'", c.Args.Client)
 	sw := &Scribe{
-		n: &counter{1},
+		n:     &counter{1},
+		cache: &cacheStore{},
 	}
 
 	initializer, ok := ClientInitializers[c.Args.Client]
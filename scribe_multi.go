@@ -23,6 +23,10 @@ type ScribeMulti struct {
 
 	n        *counter
 	pipeline int64
+	cache    *cacheStore
+
+	// problems are the errors that were found while the pipelines were defined with the '--validate' argument (see 'fatal').
+	problems []error
 }
 
 func (s *ScribeMulti) serial() int64 {
@@ -39,10 +43,19 @@ func (s *ScribeMulti) Add(pipelines ...pipeline.Pipeline) {
 		}).Debugln("adding pipeline")
 	}
 	if err := s.Collection.AddPipelines(pipelines...); err != nil {
-		s.Log.WithError(err).Fatalln("error adding pipelines")
+		s.fatal(fmt.Errorf("error adding pipelines: %w", err))
 	}
 }
 
+// fatal stops the program with the error. If the pipelines are being validated ('--validate'), then the error is reported with the other problems in the pipelines instead.
+func (s *ScribeMulti) fatal(err error) {
+	if !s.Opts.Args.Validate {
+		s.Log.Fatalln(err)
+	}
+
+	s.problems = append(s.problems, err)
+}
+
 // Execute is the equivalent of Done, but returns an error.
 // Done should be preferred in Scribe pipelines as it includes sub-process handling and logging.
 func (s *ScribeMulti) Execute(ctx context.Context, collection *pipeline.Collection) error {
@@ -52,6 +65,12 @@ func (s *ScribeMulti) Execute(ctx context.Context, collection *pipeline.Collecti
 		if err := collection.BuildEdges(s.Opts.Log, rootArgs...); err != nil {
 			return err
 		}
+
+		c, err := selectSteps(ctx, s.Opts.Args, collection)
+		if err != nil {
+			return err
+		}
+		collection = c
 	}
 	if err := s.Client.Done(ctx, collection); err != nil {
 		return err
@@ -61,7 +80,7 @@ func (s *ScribeMulti) Execute(ctx context.Context, collection *pipeline.Collecti
 
 func (s *ScribeMulti) Done() {
 	ctx := context.Background()
-	if err := execute(ctx, s.Collection, nameOrDefault(s.Opts.Name), s.Opts, s.n, s.Execute); err != nil {
+	if err := execute(ctx, s.Collection, nameOrDefault(s.Opts.Name), s.Opts, s.n, validation{client: s.Client, problems: s.problems}, s.Execute); err != nil {
 		s.Log.WithError(err).Fatal("error in execution")
 	}
 }
@@ -88,6 +107,7 @@ func NewMulti() *ScribeMulti {
 		// Ensure that no matter the behavior of the initializer, we still set the version on the scribe object.
 		Version: opts.Args.Version,
 		n:       &counter{1},
+		cache:   sw.cache,
 	}
 }
 
@@ -103,6 +123,7 @@ func NewMultiWithClient(opts clients.CommonOpts, client pipeline.Client) *Scribe
 		Log:        opts.Log,
 		Collection: NewMultiCollection(),
 		n:          &counter{1},
+		cache:      &cacheStore{},
 	}
 }
 
@@ -137,6 +158,9 @@ func (s *ScribeMulti) New(name string, mf MultiFunc) pipeline.Pipeline {
 	wrappedMultiFunc := MultiFuncWithLogging(log, mf)
 	wrappedMultiFunc(sw)
 
+	// The problems that were found in the sub-pipeline are reported with the problems in every other pipeline.
+	s.problems = append(s.problems, sw.problems...)
+
 	// Update our counter with the new value of the sub-pipeline counter
 	s.n = sw.n
 
@@ -163,9 +187,28 @@ func (s *ScribeMulti) New(name string, mf MultiFunc) pipeline.Pipeline {
 		Root:         node.Value.Root,
 		RequiredArgs: node.Value.RequiredArgs,
 		ProvidedArgs: node.Value.ProvidedArgs,
+		Timeout:      node.Value.Timeout,
 	}
 }
 
+// MatrixFunc is called by Matrix once for every combination of the values in the matrix.
+type MatrixFunc func(*Scribe, pipeline.MatrixValues)
+
+// Matrix creates one pipeline for every combination of the values in the matrix and returns them so that they can be added with 'Add'.
+// Every pipeline is given a unique name (see 'pipeline.MatrixValues.Name'). In clients like Drone, every combination is its own pipeline.
+func (s *ScribeMulti) Matrix(name string, matrix map[string][]string, mf MatrixFunc) []pipeline.Pipeline {
+	combinations := pipeline.MatrixCombinations(matrix)
+	pipelines := make([]pipeline.Pipeline, len(combinations))
+	for i, values := range combinations {
+		values := values
+		pipelines[i] = s.New(values.Name(name), func(sw *Scribe) {
+			mf(sw, values)
+		})
+	}
+
+	return pipelines
+}
+
 func (s *ScribeMulti) newMulti(name string) (*Scribe, error) {
 	log := s.Log.WithField("pipeline", name)
 	collection := NewMultiCollection()
@@ -179,6 +222,7 @@ func (s *ScribeMulti) newMulti(name string) (*Scribe, error) {
 		Log:        log,
 		Version:    s.Version,
 		n:          s.n,
+		cache:      s.cache,
 		Collection: collection,
 		pipeline:   DefaultPipelineID,
 	}
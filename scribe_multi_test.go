@@ -3,6 +3,7 @@ package scribe_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/grafana/scribe"
 	"github.com/grafana/scribe/pipeline"
@@ -91,3 +92,50 @@ func TestMultiWithEvent(t *testing.T) {
 		})
 	})
 }
+
+func TestMultiWithTimeout(t *testing.T) {
+	t.Run("Once setting a timeout, it should be present in the collection", func(t *testing.T) {
+		ens := newEnsurer()
+		sw := scribe.NewMultiWithClient(testOpts, ens)
+
+		mf := func(sw *scribe.Scribe) {
+			sw.Timeout(time.Minute)
+			sw.Add(pipeline.NoOpStep.WithName("step 1"))
+		}
+
+		sw.Add(
+			sw.New("test 1", mf),
+		)
+
+		sw.Collection.WalkPipelines(context.Background(), func(ctx context.Context, p pipeline.Pipeline) error {
+			if p.Timeout != time.Minute {
+				t.Fatalf("Expected pipeline timeout to be '%s', but found '%s'", time.Minute, p.Timeout)
+			}
+
+			return nil
+		})
+	})
+}
+
+func TestMultiMatrix(t *testing.T) {
+	t.Run("It should create a pipeline for every combination in the matrix", func(t *testing.T) {
+		ens := newEnsurer()
+		sw := scribe.NewMultiWithClient(testOpts, ens)
+
+		pipelines := sw.Matrix("build", map[string][]string{"goos": {"linux", "darwin"}}, func(s *scribe.Scribe, v pipeline.MatrixValues) {
+			s.Add(pipeline.NoOpStep.WithName("compile " + v["goos"]))
+		})
+
+		if len(pipelines) != 2 {
+			t.Fatalf("Expected 2 pipelines, but found %d", len(pipelines))
+		}
+
+		for i, name := range []string{"build linux", "build darwin"} {
+			if pipelines[i].Name != name {
+				t.Errorf("Expected pipeline %d to be named '%s', but found '%s'", i, name, pipelines[i].Name)
+			}
+		}
+
+		sw.Add(pipelines...)
+	})
+}
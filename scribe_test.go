@@ -169,3 +169,49 @@ func TestWithEvent(t *testing.T) {
 		})
 	})
 }
+
+func TestCache(t *testing.T) {
+	t.Run("It should skip the action and restore its outputs when its inputs have not changed", func(t *testing.T) {
+		var (
+			ctx     = context.Background()
+			arg     = state.NewStringArgument("version")
+			runs    = 0
+			content = func(context.Context, pipeline.CacheKeyOpts) ([]byte, error) {
+				return []byte("a"), nil
+			}
+		)
+
+		sw := scribe.NewWithClient(clients.CommonOpts{
+			Log:  logger(),
+			Args: &args.PipelineArgs{Cache: "fs://" + t.TempDir()},
+		}, nil)
+
+		action := sw.Cache(func(ctx context.Context, opts pipeline.ActionOpts) error {
+			runs++
+			return opts.State.SetString(ctx, arg, "v1.0.0")
+		}, pipeline.CacheKeys(content), pipeline.CacheArguments(arg))
+
+		for i := 0; i < 2; i++ {
+			st, err := state.NewFilesystemState(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := action(ctx, pipeline.ActionOpts{State: st, Logger: logger()}); err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := st.GetString(ctx, arg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != "v1.0.0" {
+				t.Fatalf("Expected '%s' to be 'v1.0.0' but received '%s'", arg.Key, v)
+			}
+		}
+
+		if runs != 1 {
+			t.Fatalf("Expected the action to run once but it ran %d times", runs)
+		}
+	})
+}
@@ -63,20 +63,44 @@ var states = map[string]func(context.Context, *url.URL) (Handler, error){
 	"s3":   newS3State,
 }
 
+// NewHandler creates a new state handler from a URL, like the one provided to the --state flag.
+// The URL scheme determines where the handler stores its data. See the '--state' flag for the supported schemes.
+func NewHandler(ctx context.Context, rawURL string) (Handler, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	v, ok := states[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("state URL scheme '%s' not recognized", rawURL)
+	}
+
+	return v(ctx, u)
+}
+
 // NewDefaultState creates a new default state given the arguments provided.
 // The --no-stdin flag will prevent the State object from using the stdin to populate the state for ClientProvidedArguments. (See `pipeline/arguments_known.go` for those).
 // The --state flag defines where the state JSON and state data will be stored.
-// If the value for a key is not available in the primary state (defined by the --state flag), then the state object will attempt to retrieve it from the fallback. Currently, the fallback options are the `--arg` flags (--arg={key}={value}), or, if `--no-stdin` is not set, then from the stdin.
+// If the value for a key is not available in the primary state (defined by the --state flag), then the state object will attempt to retrieve it from the fallback. Currently, the fallback options are the states provided with the --state-fallback flag, the `--arg` flags (--arg={key}={value}), or, if `--no-stdin` is not set, then from the stdin.
 func NewDefaultState(ctx context.Context, log logrus.FieldLogger, pargs *args.PipelineArgs) (*State, error) {
 	u, err := url.Parse(pargs.State)
 	if err != nil {
 		return nil, err
 	}
 
-	fallback := []Reader{
-		ReaderWithLogs(log.WithField("state", "arguments"), NewArgMapReader(pargs.ArgMap)),
+	fallback := []Reader{}
+	for _, v := range pargs.StateFallback {
+		handler, err := NewHandler(ctx, v)
+		if err != nil {
+			return nil, fmt.Errorf("error opening fallback state '%s': %w", v, err)
+		}
+
+		fallback = append(fallback, ReaderWithLogs(log.WithField("state", "fallback"), handler))
 	}
 
+	fallback = append(fallback, ReaderWithLogs(log.WithField("state", "arguments"), NewArgMapReader(pargs.ArgMap)))
+
 	if pargs.CanStdinPrompt {
 		fallback = append(fallback, ReaderWithLogs(log.WithField("state", "stdin"), NewStdinReader(os.Stdin, os.Stdout)))
 	}
@@ -285,7 +285,8 @@ func (f *FilesystemState) Exists(ctx context.Context, arg Argument) (bool, error
 		return true, nil
 	}
 
-	if errors.Is(err, ErrorNotFound) {
+	// The state file is only created once the first value is set, so a state without a file, or with an empty file, does not have any values yet.
+	if errors.Is(err, ErrorNotFound) || errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrorEmptyState) {
 		return false, nil
 	}
 
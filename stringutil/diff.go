@@ -0,0 +1,139 @@
+package stringutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines that are shown around every change in a unified diff.
+const diffContext = 3
+
+type diffLine struct {
+	// op is ' ' for lines in both texts, '-' for lines only in 'a', and '+' for lines only in 'b'.
+	op   byte
+	text string
+	// a and b are the indexes of the line in 'a' and 'b'. The index of the text that the line is not in is the index of the next line in that text.
+	a, b int
+}
+
+// splitLines splits the text into lines that keep their line endings. Only the last line can be missing one.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diffLines returns the lines of both texts in order, using the longest common subsequence of lines as the unchanged lines.
+func diffLines(a, b []string) []diffLine {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := []diffLine{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{op: ' ', text: a[i], a: i, b: j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{op: '-', text: a[i], a: i, b: j})
+			i++
+		default:
+			lines = append(lines, diffLine{op: '+', text: b[j], a: i, b: j})
+			j++
+		}
+	}
+
+	return lines
+}
+
+// hunkRange formats the start and length of a hunk in one of the texts, like '12,4'. Line numbers start at 1, and an empty range starts at the line before it.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprint(start + 1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// UnifiedDiff returns the differences between the texts 'a' and 'b' in the unified diff format, with 'aName' and 'bName' as the names of the files.
+// If the texts are the same, then an empty string is returned.
+func UnifiedDiff(aName, bName, a, b string) string {
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	changes := []int{}
+	for i, v := range lines {
+		if v.op != ' ' {
+			changes = append(changes, i)
+		}
+	}
+
+	if len(changes) == 0 {
+		return ""
+	}
+
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", aName, bName)
+
+	for n := 0; n < len(changes); {
+		// Every hunk starts with the context before its first change, and is extended until the gap to the next change is too large to share the context.
+		start := changes[n] - diffContext
+		if start < 0 {
+			start = 0
+		}
+
+		end := changes[n]
+		for n < len(changes) && changes[n]-end <= 2*diffContext {
+			end = changes[n]
+			n++
+		}
+		end += diffContext + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		hunk := lines[start:end]
+		aLen, bLen := 0, 0
+		for _, v := range hunk {
+			if v.op != '+' {
+				aLen++
+			}
+			if v.op != '-' {
+				bLen++
+			}
+		}
+
+		fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(hunk[0].a, aLen), hunkRange(hunk[0].b, bLen))
+		for _, v := range hunk {
+			out.WriteByte(v.op)
+			out.WriteString(v.text)
+			if !strings.HasSuffix(v.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,71 @@
+package stringutil_test
+
+import (
+	"testing"
+
+	"github.com/grafana/scribe/stringutil"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Run("It should return an empty string if the texts are the same", func(t *testing.T) {
+		if diff := stringutil.UnifiedDiff("a", "b", "a\nb\n", "a\nb\n"); diff != "" {
+			t.Fatalf("Expected no diff but received:\n%s", diff)
+		}
+	})
+
+	t.Run("It should show the changed lines with their context", func(t *testing.T) {
+		a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+		b := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n11\n12\n13\n"
+		expected := `--- a.yml
++++ b.yml
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`
+		if diff := stringutil.UnifiedDiff("a.yml", "b.yml", a, b); diff != expected {
+			t.Fatalf("Expected diff:\n%s\nbut received:\n%s", expected, diff)
+		}
+	})
+
+	t.Run("It should merge changes that share their context into one hunk", func(t *testing.T) {
+		expected := `--- a
++++ b
+@@ -1,5 +1,5 @@
+-1
++one
+ 2
+ 3
+ 4
+-5
++five
+`
+		if diff := stringutil.UnifiedDiff("a", "b", "1\n2\n3\n4\n5\n", "one\n2\n3\n4\nfive\n"); diff != expected {
+			t.Fatalf("Expected diff:\n%s\nbut received:\n%s", expected, diff)
+		}
+	})
+
+	t.Run("It should mark lines without a newline at the end of the file", func(t *testing.T) {
+		expected := "--- a\n+++ b\n@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n"
+		if diff := stringutil.UnifiedDiff("a", "b", "a\n", "a"); diff != expected {
+			t.Fatalf("Expected diff:\n%s\nbut received:\n%s", expected, diff)
+		}
+	})
+
+	t.Run("It should show the whole file if it was empty", func(t *testing.T) {
+		expected := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+		if diff := stringutil.UnifiedDiff("a", "b", "", "a\nb\n"); diff != expected {
+			t.Fatalf("Expected diff:\n%s\nbut received:\n%s", expected, diff)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package swfs
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// An ignoreRule is a single pattern from a '.gitignore' file.
+type ignoreRule struct {
+	// base is the directory that contains the '.gitignore' file that the rule came from.
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// gitIgnore holds the rules from every '.gitignore' file that has been found while walking a filesystem.
+// It supports the commonly used subset of the gitignore syntax: comments, negation ('!'), directory-only patterns (a trailing '/'),
+// patterns that are anchored to the directory of the '.gitignore' file (patterns that contain a '/'), and '**'.
+type gitIgnore struct {
+	rules []ignoreRule
+}
+
+// load reads the '.gitignore' file in the directory 'dir', if there is one, and adds its rules.
+func (g *gitIgnore) load(fsys fs.FS, dir string) error {
+	f, err := fsys.Open(path.Join(dir, ".gitignore"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: dir}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+
+		if line == "" {
+			continue
+		}
+
+		rule.pattern = line
+		g.rules = append(g.rules, rule)
+	}
+
+	return scanner.Err()
+}
+
+// ignored returns true if the file or directory at 'name' is ignored. Like git, the last rule that matches 'name' decides.
+func (g *gitIgnore) ignored(name string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		rel := name
+		if rule.base != "." {
+			if !strings.HasPrefix(name, rule.base+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(name, rule.base+"/")
+		}
+
+		// Patterns without a '/' match a file or directory with that name at any depth.
+		// The directories above 'name' have already been checked while walking, so only the last element has to be matched.
+		if !rule.anchored {
+			rel = path.Base(rel)
+		}
+
+		if ok, err := Match(rule.pattern, rel); err == nil && ok {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
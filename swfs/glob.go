@@ -0,0 +1,76 @@
+package swfs
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether the slash-separated path 'name' matches the glob 'pattern'.
+// The pattern syntax is the same as 'path.Match', with the addition of '**', which matches zero or more directories when it is used as a whole path segment.
+// For example, 'src/**/*.go' matches 'src/main.go' and 'src/pkg/util/util.go'.
+func Match(pattern, name string) (bool, error) {
+	return matchSegments(splitPath(pattern), splitPath(name))
+}
+
+// MatchOrParent reports whether 'name' or one of its parent directories matches the glob 'pattern'.
+// This allows a pattern like 'node_modules' to match every file in the 'node_modules' directory.
+func MatchOrParent(pattern, name string) (bool, error) {
+	for {
+		ok, err := Match(pattern, name)
+		if err != nil || ok {
+			return ok, err
+		}
+
+		parent := path.Dir(name)
+		if parent == "." || parent == "/" || parent == name {
+			return false, nil
+		}
+		name = parent
+	}
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "" {
+		return nil
+	}
+
+	return strings.Split(p, "/")
+}
+
+func matchSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse repeated '**' segments; they are equivalent to one.
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true, nil
+			}
+
+			for i := 0; i <= len(name); i++ {
+				ok, err := matchSegments(pattern, name[i:])
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+
+			return false, nil
+		}
+
+		if len(name) == 0 {
+			return false, nil
+		}
+
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false, err
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0, nil
+}
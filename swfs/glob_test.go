@@ -0,0 +1,46 @@
+package swfs_test
+
+import (
+	"testing"
+
+	"github.com/grafana/scribe/swfs"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{pattern: "*.go", name: "main.go", match: true},
+		{pattern: "*.go", name: "pkg/main.go", match: false},
+		{pattern: "**/*.go", name: "main.go", match: true},
+		{pattern: "**/*.go", name: "pkg/util/util.go", match: true},
+		{pattern: "src/**/*.go", name: "src/main.go", match: true},
+		{pattern: "src/**/*.go", name: "vendor/main.go", match: false},
+		{pattern: "src/**", name: "src/a/b/c.txt", match: true},
+		{pattern: "./yarn.lock", name: "yarn.lock", match: true},
+	}
+
+	for _, c := range cases {
+		ok, err := swfs.Match(c.pattern, c.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ok != c.match {
+			t.Errorf("Match('%s', '%s'): expected %t but got %t", c.pattern, c.name, c.match, ok)
+		}
+	}
+}
+
+func TestMatchOrParent(t *testing.T) {
+	ok, err := swfs.MatchOrParent("node_modules", "node_modules/left-pad/index.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok {
+		t.Fatal("Expected a file in a matching directory to match")
+	}
+}
@@ -2,60 +2,136 @@ package swfs
 
 import (
 	"crypto/sha256"
+	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
 )
 
+// ErrorNoFiles is returned when the 'Include' patterns of HashOpts did not match any file.
+var ErrorNoFiles = errors.New("no files matched")
+
+// HashOpts changes which files are part of the hash created by HashFSWithOpts.
+type HashOpts struct {
+	// Include is a list of glob patterns (see 'Match'). If it is not empty, then only the files that match one of the patterns, or that are in a directory that matches one of the patterns, are hashed.
+	Include []string
+
+	// Exclude is a list of glob patterns. Files and directories that match one of the patterns are not hashed.
+	Exclude []string
+
+	// GitIgnore is true if the files ignored by '.gitignore' files should not be hashed. The '.git' directory is also excluded.
+	GitIgnore bool
+}
+
+func matchAny(patterns []string, name string, match func(string, string) (bool, error)) (bool, error) {
+	for _, pattern := range patterns {
+		ok, err := match(pattern, name)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// HashDirectory hashes the path, mode, and contents of every file in the directory 'dir'.
 func HashDirectory(dir string) ([]byte, error) {
 	fs := os.DirFS(dir)
 	return HashFS(fs)
 }
 
+// HashFiles hashes the files in the current working directory that match one of the glob patterns (see 'Match').
+// It returns ErrorNoFiles if none of the patterns matched a file.
+func HashFiles(globs ...string) ([]byte, error) {
+	return HashFSWithOpts(os.DirFS("."), HashOpts{Include: globs})
+}
+
+// HashFS hashes the path, mode, and contents of every file in the filesystem.
 func HashFS(dir fs.FS) ([]byte, error) {
-	hashes := map[string][]byte{}
+	return HashFSWithOpts(dir, HashOpts{})
+}
+
+// HashFSWithOpts hashes the path, mode, and contents of every file in the filesystem that is selected by the options.
+// The filesystem is walked in lexical order, so the hash only changes if a file is added, removed, renamed, or if its mode or contents change.
+func HashFSWithOpts(dir fs.FS, opts HashOpts) ([]byte, error) {
+	var (
+		hash    = sha256.New()
+		ignore  = &gitIgnore{}
+		matched = 0
+	)
 
-	// Hash each file, add the hash to the map above
-	if err := fs.WalkDir(dir, ".", func(path string, d fs.DirEntry, err error) error {
+	err := fs.WalkDir(dir, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return nil
+			return err
 		}
 
-		if path == "." {
-			return nil
+		if path != "." {
+			ok, err := matchAny(opts.Exclude, path, Match)
+			if err != nil {
+				return err
+			}
+
+			if ok || (opts.GitIgnore && (d.Name() == ".git" || ignore.ignored(path, d.IsDir()))) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
 		}
 
 		if d.IsDir() {
+			if opts.GitIgnore {
+				return ignore.load(dir, path)
+			}
 			return nil
 		}
 
-		f, err := dir.Open(path)
+		if len(opts.Include) != 0 {
+			ok, err := matchAny(opts.Include, path, MatchOrParent)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+		}
+
+		info, err := d.Info()
 		if err != nil {
 			return err
 		}
 
+		f, err := dir.Open(path)
+		if err != nil {
+			return err
+		}
 		defer f.Close()
 
 		b, err := HashFile(f)
 		if err != nil {
-			return err
+			return fmt.Errorf("error hashing file '%s': %w", path, err)
 		}
-		hashes[path] = b
-		return nil
-	}); err != nil {
+
+		matched++
+		_, err = fmt.Fprintf(hash, "%s\x00%o\x00%x\n", path, info.Mode().Perm(), b)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// For each hashed file, add it to one big hash
-	hash := sha256.New()
-	for k, b := range hashes {
-		hash.Sum([]byte(k))
-		hash.Sum(b)
+	if len(opts.Include) != 0 && matched == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrorNoFiles, opts.Include)
 	}
 
 	return hash.Sum(nil), nil
 }
 
+// HashFile hashes the contents read from 'r'.
 func HashFile(r io.Reader) ([]byte, error) {
 	hash := sha256.New()
 
@@ -2,8 +2,9 @@ package swfs_test
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -30,25 +31,154 @@ func TestHashFile(t *testing.T) {
 	}
 }
 
-func TestEncodeDir(t *testing.T) {
-	dir := filepath.Clean("testdata")
+// writeFiles creates the files in the directory 'dir', creating parent directories as needed.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
 
-	b, err := swfs.HashDirectory(dir)
+func hashDir(t *testing.T, dir string, opts swfs.HashOpts) string {
+	t.Helper()
+	b, err := swfs.HashFSWithOpts(os.DirFS(dir), opts)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	hash := sha256.New()
-	hash.Sum([]byte("a.json"))
-	hash.Sum([]byte("e8f1fae1d192acff9666ffb429757fb60cb92dfa39e3ac074777fd01e1bfabbf"))
-	hash.Sum([]byte("b.json"))
-	hash.Sum([]byte("497ec934da3f4dc5708e4be58a11f72224b23127b8b402256c114a892ae2aba2"))
-	hash.Sum([]byte("c/c.json"))
-	hash.Sum([]byte("bbd82e48900b9f9bbe1a00eca6a9ec646eb7126a442dc60b6dd0255de6abd48c"))
+	return hex.EncodeToString(b)
+}
+
+func TestEncodeDir(t *testing.T) {
+	t.Run("It should return the same hash every time for the same directory", func(t *testing.T) {
+		dir := filepath.Clean("testdata")
+
+		first, err := swfs.HashDirectory(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	expect := hash.Sum(nil)
+		for i := 0; i < 10; i++ {
+			b, err := swfs.HashDirectory(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	if !bytes.Equal(b, expect) {
-		t.Fatalf("Unexpected result from HashDirectory:\nExpected: '%x'\nReceived: '%x'", expect, b)
+			if !bytes.Equal(b, first) {
+				t.Fatalf("Unexpected result from HashDirectory:\nExpected: '%x'\nReceived: '%x'", first, b)
+			}
+		}
+	})
+
+	files := map[string]string{
+		"a.json":   `{"a": 1}`,
+		"c/c.json": `{"c": 1}`,
 	}
+
+	t.Run("It should return a different hash if the contents of a file change", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, files)
+		before := hashDir(t, dir, swfs.HashOpts{})
+
+		writeFiles(t, dir, map[string]string{"c/c.json": `{"c": 2}`})
+		if after := hashDir(t, dir, swfs.HashOpts{}); before == after {
+			t.Fatal("Expected hash to change when the contents of a file changed")
+		}
+	})
+
+	t.Run("It should return a different hash if a file is renamed", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, files)
+		before := hashDir(t, dir, swfs.HashOpts{})
+
+		if err := os.Rename(filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")); err != nil {
+			t.Fatal(err)
+		}
+		if after := hashDir(t, dir, swfs.HashOpts{}); before == after {
+			t.Fatal("Expected hash to change when a file was renamed")
+		}
+	})
+
+	t.Run("It should return a different hash if the mode of a file changes", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, files)
+		before := hashDir(t, dir, swfs.HashOpts{})
+
+		if err := os.Chmod(filepath.Join(dir, "a.json"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if after := hashDir(t, dir, swfs.HashOpts{}); before == after {
+			t.Fatal("Expected hash to change when the mode of a file changed")
+		}
+	})
+}
+
+func TestHashOpts(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".gitignore":                 "node_modules/\n*.log\n!keep.log\n",
+		"main.go":                    "package main",
+		"debug.log":                  "log",
+		"keep.log":                   "log",
+		"pkg/util.go":                "package pkg",
+		"pkg/.gitignore":             "/generated.go\n",
+		"pkg/generated.go":           "package pkg",
+		"node_modules/left-pad/a.js": "module.exports = {}",
+	})
+
+	t.Run("It should only hash the files that match the include patterns", func(t *testing.T) {
+		before := hashDir(t, dir, swfs.HashOpts{Include: []string{"**/*.go"}})
+
+		writeFiles(t, dir, map[string]string{"debug.log": "changed"})
+		if after := hashDir(t, dir, swfs.HashOpts{Include: []string{"**/*.go"}}); before != after {
+			t.Fatal("Expected hash not to change when a file that is not included changed")
+		}
+
+		writeFiles(t, dir, map[string]string{"pkg/util.go": "package pkg // changed"})
+		if after := hashDir(t, dir, swfs.HashOpts{Include: []string{"**/*.go"}}); before == after {
+			t.Fatal("Expected hash to change when an included file changed")
+		}
+	})
+
+	t.Run("It should not hash the files that match the exclude patterns", func(t *testing.T) {
+		opts := swfs.HashOpts{Exclude: []string{"node_modules", "*.log"}}
+		before := hashDir(t, dir, opts)
+
+		writeFiles(t, dir, map[string]string{"node_modules/left-pad/a.js": "changed", "keep.log": "changed"})
+		if after := hashDir(t, dir, opts); before != after {
+			t.Fatal("Expected hash not to change when an excluded file changed")
+		}
+	})
+
+	t.Run("It should not hash the files that are ignored by .gitignore files", func(t *testing.T) {
+		opts := swfs.HashOpts{GitIgnore: true}
+		before := hashDir(t, dir, opts)
+
+		writeFiles(t, dir, map[string]string{
+			"node_modules/left-pad/a.js": "changed again",
+			"debug.log":                  "changed again",
+			"pkg/generated.go":           "package pkg // changed",
+		})
+		if after := hashDir(t, dir, opts); before != after {
+			t.Fatal("Expected hash not to change when an ignored file changed")
+		}
+
+		writeFiles(t, dir, map[string]string{"keep.log": "changed again"})
+		if after := hashDir(t, dir, opts); before == after {
+			t.Fatal("Expected hash to change when a file that was negated in .gitignore changed")
+		}
+	})
+
+	t.Run("It should return an error if the include patterns do not match any files", func(t *testing.T) {
+		_, err := swfs.HashFSWithOpts(os.DirFS(dir), swfs.HashOpts{Include: []string{"*.rs"}})
+		if !errors.Is(err, swfs.ErrorNoFiles) {
+			t.Fatalf("Expected error '%v', but got '%v'", swfs.ErrorNoFiles, err)
+		}
+	})
 }
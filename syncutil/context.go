@@ -0,0 +1,32 @@
+package syncutil
+
+import (
+	"context"
+	"time"
+)
+
+// detachedContext has the values of its parent context, but is never cancelled and has no deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
+
+// detach returns a context that keeps the values of 'ctx' (like tracing spans), but is not cancelled when 'ctx' is.
+func detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
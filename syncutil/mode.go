@@ -0,0 +1,80 @@
+package syncutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/scribe/args"
+)
+
+// Mode defines what a WaitGroup or a Scheduler does when one of its functions returns an error.
+type Mode int
+
+const (
+	// FailFast cancels the context that is shared by every function as soon as one of them returns an error.
+	// Once every running function has returned, the first error is returned.
+	FailFast Mode = iota
+
+	// RunAll lets every function run to completion, even if one of them returns an error.
+	// Every error that was returned is combined into an Errors.
+	RunAll
+)
+
+func (m Mode) String() string {
+	switch m {
+	case FailFast:
+		return "fail-fast"
+	case RunAll:
+		return "keep-going"
+	}
+
+	return "unknown"
+}
+
+// ModeFromArgs returns the Mode that the user requested with the '--fail-fast' / '--keep-going' flags.
+func ModeFromArgs(pargs *args.PipelineArgs) Mode {
+	if pargs != nil && pargs.KeepGoing {
+		return RunAll
+	}
+
+	return FailFast
+}
+
+// Errors is returned in the RunAll mode when one or more functions return an error.
+type Errors []error
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = "* " + err.Error()
+	}
+
+	return fmt.Sprintf("%d errors occurred:\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+// Is returns true if any of the errors matches the target.
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first error that matches the target.
+func (e Errors) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
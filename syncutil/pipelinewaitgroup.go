@@ -2,6 +2,7 @@ package syncutil
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/grafana/scribe/pipeline"
 )
@@ -14,19 +15,23 @@ type PipelineWaitGroup struct {
 // Add adds a new Action to the waitgroup. The provided function will be run in parallel with all other added functions.
 func (w *PipelineWaitGroup) Add(f pipeline.Pipeline, walker *pipeline.Collection, wf pipeline.StepWalkFunc) {
 	w.wg.Add(func(ctx context.Context) error {
-		return walker.WalkSteps(ctx, f.ID, wf)
+		if err := walker.WalkSteps(ctx, f.ID, wf); err != nil {
+			return fmt.Errorf("pipeline '%s': %w", f.Name, err)
+		}
+		return nil
 	})
 }
 
-// Wait runs all provided functions (via Add(...)) and runs them in parallel and waits for them to finish.
-// If they are not all finished before the provided timeout (via NewPipelineWaitGroup), then an error is returned.
-// If any functions return an error, the first error encountered is returned.
+// Wait runs all provided functions (via Add(...)) in parallel and waits for them to finish.
+// In the FailFast mode, the first error stops the other pipelines by cancelling their context and is returned.
+// In the RunAll mode, every pipeline runs to completion and every failed pipeline is listed in the returned Errors.
 func (w *PipelineWaitGroup) Wait(ctx context.Context) error {
 	return w.wg.Wait(ctx)
 }
 
-func NewPipelineWaitGroup() *PipelineWaitGroup {
+// NewPipelineWaitGroup creates a new PipelineWaitGroup that handles errors according to the provided mode.
+func NewPipelineWaitGroup(mode Mode) *PipelineWaitGroup {
 	return &PipelineWaitGroup{
-		wg: NewWaitGroup(),
+		wg: NewWaitGroup(mode),
 	}
 }
@@ -0,0 +1,167 @@
+package syncutil
+
+import (
+	"context"
+
+	"github.com/grafana/scribe/pipeline/dag"
+)
+
+// NodeFunc is called by the Scheduler once for every node in the graph.
+type NodeFunc[T any] func(context.Context, *dag.Node[T]) error
+
+// Scheduler runs a function for every node in a graph while respecting the edges of the graph.
+// A node is started as soon as every node with an edge to it has completed, so independent branches of the graph run in parallel.
+type Scheduler[T any] struct {
+	Graph *dag.Graph[T]
+
+	// MaxConcurrency is the maximum number of nodes that run at the same time. If it is 0 or less, then there is no limit.
+	MaxConcurrency int
+
+	// Mode defines what happens to the rest of the graph when a node returns an error.
+	Mode Mode
+
+	// AlwaysRun returns true for nodes that run even if a node they depend on failed or was skipped, or if the run was cancelled.
+	// These nodes are given a context that is not cancelled by the Scheduler or by the context provided to Run.
+	// If it is nil, then no nodes always run.
+	AlwaysRun func(*dag.Node[T]) bool
+}
+
+type nodeResult[T any] struct {
+	node *dag.Node[T]
+	err  error
+}
+
+func (s *Scheduler[T]) alwaysRun(n *dag.Node[T]) bool {
+	return s.AlwaysRun != nil && s.AlwaysRun(n)
+}
+
+// Run calls 'f' for every node in the graph, including the root node.
+// Nodes that depend on a node that returned an error or that was skipped are skipped.
+// In the FailFast mode, the context of the running nodes is cancelled, no more nodes are started, and the first error is returned once the running nodes complete.
+// In the RunAll mode, the nodes that do not depend on the failed node keep running, and every error is returned as Errors.
+// If the provided context is cancelled, then no more nodes are started and the context's error is returned once the running nodes complete.
+// Nodes that should always run (see 'AlwaysRun') are started once every node that they depend on has completed or was skipped, even after a failure or cancellation.
+// If the graph has a cycle, then a dag.CycleError is returned before any node is started.
+func (s *Scheduler[T]) Run(ctx context.Context, f NodeFunc[T]) error {
+	if err := s.Graph.DetectCycle(); err != nil {
+		return err
+	}
+
+	indegree := map[int64]int{}
+	for _, edges := range s.Graph.Edges {
+		for _, e := range edges {
+			indegree[e.To.ID]++
+		}
+	}
+
+	var (
+		ready = []*dag.Node[T]{}
+		// blocked contains the nodes that depend on a node that returned an error or that was skipped.
+		blocked = map[int64]bool{}
+		running = 0
+		// done is buffered so that the goroutines never block if we stop listening early.
+		done      = make(chan nodeResult[T], len(s.Graph.Nodes))
+		errs      Errors
+		cancelled bool
+	)
+
+	fctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for i, n := range s.Graph.Nodes {
+		if indegree[n.ID] == 0 {
+			ready = append(ready, &s.Graph.Nodes[i])
+		}
+	}
+
+	// complete marks every node that depends on 'n' as ready once all of their dependencies have completed.
+	// If 'n' did not succeed, then those nodes are blocked.
+	complete := func(n *dag.Node[T], ok bool) {
+		for _, e := range s.Graph.Edges[n.ID] {
+			if !ok {
+				blocked[e.To.ID] = true
+			}
+
+			indegree[e.To.ID]--
+			if indegree[e.To.ID] == 0 {
+				ready = append(ready, e.To)
+			}
+		}
+	}
+
+	for {
+		stopped := cancelled || (s.Mode == FailFast && len(errs) != 0)
+		for len(ready) != 0 && (s.MaxConcurrency <= 0 || running < s.MaxConcurrency) {
+			node := ready[0]
+			ready = ready[1:]
+
+			always := s.alwaysRun(node)
+			if !always && (stopped || blocked[node.ID]) {
+				// Skipped nodes complete immediately so that the nodes that always run after them can still start.
+				complete(node, false)
+				continue
+			}
+
+			// Nodes that always run should not be stopped by a failure or cancellation that happened somewhere else in the graph.
+			nctx := fctx
+			if always {
+				nctx = detach(ctx)
+			}
+
+			running++
+			go func(ctx context.Context, n *dag.Node[T]) {
+				done <- nodeResult[T]{node: n, err: f(ctx, n)}
+			}(nctx, node)
+		}
+
+		if running == 0 {
+			if cancelled {
+				return ctx.Err()
+			}
+
+			return s.result(errs)
+		}
+
+		// Once the context is cancelled, there's no need to keep listening to it.
+		var ctxDone <-chan struct{}
+		if !cancelled {
+			ctxDone = ctx.Done()
+		}
+
+		select {
+		case <-ctxDone:
+			cancelled = true
+		case res := <-done:
+			running--
+			if res.err != nil {
+				errs = append(errs, res.err)
+				if s.Mode == FailFast {
+					cancel()
+				}
+			}
+
+			complete(res.node, res.err == nil)
+		}
+	}
+}
+
+func (s *Scheduler[T]) result(errs Errors) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	if s.Mode == FailFast {
+		return errs[0]
+	}
+
+	return errs
+}
+
+// NewScheduler creates a new Scheduler for the graph that runs at most 'limit' nodes at once. If 'limit' is 0 or less, then there is no limit.
+func NewScheduler[T any](g *dag.Graph[T], limit int, mode Mode) *Scheduler[T] {
+	return &Scheduler[T]{
+		Graph:          g,
+		MaxConcurrency: limit,
+		Mode:           mode,
+	}
+}
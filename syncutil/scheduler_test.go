@@ -0,0 +1,285 @@
+package syncutil_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/scribe/pipeline/dag"
+	"github.com/grafana/scribe/syncutil"
+	"github.com/grafana/scribe/testutil"
+)
+
+// newGraph creates a graph with the nodes 0 through 'n' and the provided edges.
+func newGraph(t *testing.T, n int64, edges ...[2]int64) *dag.Graph[int64] {
+	t.Helper()
+	g := dag.New[int64]()
+	for i := int64(0); i <= n; i++ {
+		testutil.EnsureError(t, g.AddNode(i, i), nil)
+	}
+	for _, e := range edges {
+		testutil.EnsureError(t, g.AddEdge(e[0], e[1]), nil)
+	}
+
+	return g
+}
+
+func TestSchedulerRun(t *testing.T) {
+	t.Run("It should run every node after the nodes that have edges to it", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		// 0 -> 1 -> 3
+		// 0 -> 2 -> 3 -> 4
+		g := newGraph(t, 4, [2]int64{0, 1}, [2]int64{0, 2}, [2]int64{1, 3}, [2]int64{2, 3}, [2]int64{3, 4})
+
+		var (
+			mutex sync.Mutex
+			done  = map[int64]bool{}
+		)
+
+		err := syncutil.NewScheduler(g, 0, syncutil.FailFast).Run(context.Background(), func(ctx context.Context, n *dag.Node[int64]) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			for from, edges := range g.Edges {
+				for _, e := range edges {
+					if e.To.ID == n.ID && !done[from] {
+						t.Errorf("node '%d' started before node '%d' completed", n.ID, from)
+					}
+				}
+			}
+			done[n.ID] = true
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(done) != 5 {
+			t.Fatalf("expected 5 nodes to run but %d ran", len(done))
+		}
+	}))
+
+	t.Run("It should run independent nodes in parallel", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		g := newGraph(t, 2, [2]int64{0, 1}, [2]int64{0, 2})
+
+		// Both nodes wait for each other, so this only completes if they are running at the same time.
+		var wg sync.WaitGroup
+		wg.Add(2)
+		err := syncutil.NewScheduler(g, 0, syncutil.FailFast).Run(context.Background(), func(ctx context.Context, n *dag.Node[int64]) error {
+			if n.ID == 0 {
+				return nil
+			}
+			wg.Done()
+			wg.Wait()
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	t.Run("It should not run more nodes than the max concurrency at once", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		g := newGraph(t, 6, [2]int64{0, 1}, [2]int64{0, 2}, [2]int64{0, 3}, [2]int64{0, 4}, [2]int64{0, 5}, [2]int64{0, 6})
+
+		var running, peak int64
+		err := syncutil.NewScheduler(g, 2, syncutil.FailFast).Run(context.Background(), func(ctx context.Context, n *dag.Node[int64]) error {
+			v := atomic.AddInt64(&running, 1)
+			defer atomic.AddInt64(&running, -1)
+			for {
+				m := atomic.LoadInt64(&peak)
+				if v <= m || atomic.CompareAndSwapInt64(&peak, m, v) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond * 10)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if peak > 2 {
+			t.Fatalf("expected at most 2 nodes to run at once but %d did", peak)
+		}
+	}))
+
+	t.Run("It should not start dependent nodes if a node returns an error", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		g := newGraph(t, 2, [2]int64{0, 1}, [2]int64{1, 2})
+
+		var (
+			errTest = errors.New("test error")
+			ran     int64
+		)
+		err := syncutil.NewScheduler(g, 0, syncutil.FailFast).Run(context.Background(), func(ctx context.Context, n *dag.Node[int64]) error {
+			if n.ID == 1 {
+				return errTest
+			}
+			if n.ID == 2 {
+				atomic.AddInt64(&ran, 1)
+			}
+			return nil
+		})
+
+		testutil.EnsureError(t, err, errTest)
+		if ran != 0 {
+			t.Fatal("node '2' should not run when the node it depends on fails")
+		}
+	}))
+
+	t.Run("It should cancel the running nodes in the fail-fast mode", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		g := newGraph(t, 2, [2]int64{0, 1}, [2]int64{0, 2})
+
+		errTest := errors.New("test error")
+		err := syncutil.NewScheduler(g, 0, syncutil.FailFast).Run(context.Background(), func(ctx context.Context, n *dag.Node[int64]) error {
+			switch n.ID {
+			case 1:
+				return errTest
+			case 2:
+				// This node only returns once its context is cancelled by the error in node '1'.
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		})
+
+		testutil.EnsureError(t, err, errTest)
+	}))
+
+	t.Run("It should keep running independent nodes and return every error in the run-all mode", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		// 0 -> 1 -> 2
+		// 0 -> 3 -> 4
+		// 0 -> 5
+		g := newGraph(t, 5, [2]int64{0, 1}, [2]int64{1, 2}, [2]int64{0, 3}, [2]int64{3, 4}, [2]int64{0, 5})
+
+		var (
+			errA  = errors.New("error a")
+			errB  = errors.New("error b")
+			mutex sync.Mutex
+			ran   = map[int64]bool{}
+		)
+
+		err := syncutil.NewScheduler(g, 0, syncutil.RunAll).Run(context.Background(), func(ctx context.Context, n *dag.Node[int64]) error {
+			mutex.Lock()
+			ran[n.ID] = true
+			mutex.Unlock()
+
+			switch n.ID {
+			case 1:
+				return errA
+			case 4:
+				return errB
+			}
+			return nil
+		})
+
+		testutil.EnsureError(t, err, errA)
+		testutil.EnsureError(t, err, errB)
+		if ran[2] {
+			t.Error("node '2' should not run when the node it depends on fails")
+		}
+		for _, id := range []int64{3, 4, 5} {
+			if !ran[id] {
+				t.Errorf("node '%d' should run in the run-all mode", id)
+			}
+		}
+	}))
+
+	t.Run("It should run nodes that always run after the nodes they depend on fail", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		// 0 -> 1 -> 2 -> 3
+		// Node '1' fails, node '2' is skipped, and node '3' always runs.
+		g := newGraph(t, 3, [2]int64{0, 1}, [2]int64{1, 2}, [2]int64{2, 3})
+
+		for _, mode := range []syncutil.Mode{syncutil.FailFast, syncutil.RunAll} {
+			var (
+				errTest = errors.New("test error")
+				mutex   sync.Mutex
+				ran     = map[int64]bool{}
+			)
+
+			s := syncutil.NewScheduler(g, 0, mode)
+			s.AlwaysRun = func(n *dag.Node[int64]) bool {
+				return n.ID == 3
+			}
+
+			err := s.Run(context.Background(), func(ctx context.Context, n *dag.Node[int64]) error {
+				mutex.Lock()
+				ran[n.ID] = true
+				mutex.Unlock()
+
+				if n.ID == 1 {
+					return errTest
+				}
+				if n.ID == 3 && ctx.Err() != nil {
+					t.Errorf("[%s] node '3' should not be given a cancelled context", mode)
+				}
+				return nil
+			})
+
+			testutil.EnsureError(t, err, errTest)
+			if ran[2] {
+				t.Errorf("[%s] node '2' should not run when the node it depends on fails", mode)
+			}
+			if !ran[3] {
+				t.Errorf("[%s] node '3' should always run", mode)
+			}
+		}
+	}))
+
+	t.Run("It should run nodes that always run after the context is cancelled", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		g := newGraph(t, 2, [2]int64{0, 1}, [2]int64{1, 2})
+
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+			ran         int64
+		)
+
+		s := syncutil.NewScheduler(g, 0, syncutil.FailFast)
+		s.AlwaysRun = func(n *dag.Node[int64]) bool {
+			return n.ID == 2
+		}
+
+		err := s.Run(ctx, func(ctx context.Context, n *dag.Node[int64]) error {
+			switch n.ID {
+			case 1:
+				// This node is running when the context is cancelled.
+				cancel()
+				<-ctx.Done()
+				return ctx.Err()
+			case 2:
+				atomic.AddInt64(&ran, 1)
+			}
+			return nil
+		})
+
+		testutil.EnsureError(t, err, context.Canceled)
+		if ran != 1 {
+			t.Fatal("node '2' should always run")
+		}
+	}))
+
+	t.Run("It should return the context's error if the context times out", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		g := newGraph(t, 2, [2]int64{0, 1}, [2]int64{1, 2})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		s := syncutil.NewScheduler(g, 0, syncutil.FailFast)
+		err := s.Run(ctx, func(ctx context.Context, n *dag.Node[int64]) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		testutil.EnsureError(t, err, context.DeadlineExceeded)
+	}))
+
+	t.Run("It should return an error if the graph has a cycle", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		g := newGraph(t, 2, [2]int64{0, 1}, [2]int64{1, 2}, [2]int64{2, 1})
+
+		err := syncutil.NewScheduler(g, 0, syncutil.FailFast).Run(context.Background(), func(ctx context.Context, n *dag.Node[int64]) error {
+			return nil
+		})
+
+		testutil.EnsureError(t, err, dag.ErrorCycle)
+	}))
+}
@@ -0,0 +1,16 @@
+package syncutil
+
+import (
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/dag"
+)
+
+// NewStepScheduler creates a new Scheduler for the steps in a pipeline. Steps that always run (see 'pipeline.Step.AlwaysRun') are started even after a failure or cancellation.
+func NewStepScheduler(p pipeline.Pipeline, limit int, mode Mode) *Scheduler[pipeline.Step] {
+	s := NewScheduler(p.Graph, limit, mode)
+	s.AlwaysRun = func(n *dag.Node[pipeline.Step]) bool {
+		return n.Value.AlwaysRun
+	}
+
+	return s
+}
@@ -2,6 +2,7 @@ package syncutil
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/grafana/scribe/pipeline"
 )
@@ -14,19 +15,23 @@ type StepWaitGroup struct {
 // Add adds a new Action to the waitgroup. The provided function will be run in parallel with all other added functions.
 func (w *StepWaitGroup) Add(f pipeline.Step, opts pipeline.ActionOpts) {
 	w.wg.Add(func(ctx context.Context) error {
-		return f.Action(ctx, opts)
+		if err := f.Action(ctx, opts); err != nil {
+			return fmt.Errorf("step '%s': %w", f.Name, err)
+		}
+		return nil
 	})
 }
 
-// Wait runs all provided functions (via Add(...)) and runs them in parallel and waits for them to finish.
-// If they are not all finished before the provided timeout (via NewStepWaitGroup), then an error is returned.
-// If any functions return an error, the first error encountered is returned.
+// Wait runs all provided functions (via Add(...)) in parallel and waits for them to finish.
+// In the FailFast mode, the first error stops the other steps by cancelling their context and is returned.
+// In the RunAll mode, every step runs to completion and every failed step is listed in the returned Errors.
 func (w *StepWaitGroup) Wait(ctx context.Context) error {
 	return w.wg.Wait(ctx)
 }
 
-func NewStepWaitGroup() *StepWaitGroup {
+// NewStepWaitGroup creates a new StepWaitGroup that handles errors according to the provided mode.
+func NewStepWaitGroup(mode Mode) *StepWaitGroup {
 	return &StepWaitGroup{
-		wg: NewWaitGroup(),
+		wg: NewWaitGroup(mode),
 	}
 }
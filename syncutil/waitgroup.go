@@ -2,59 +2,88 @@ package syncutil
 
 import (
 	"context"
-	"fmt"
 	"sync"
 )
 
 type WaitGroupFunc func(context.Context) error
 
+// WaitGroup runs a list of functions in parallel and handles their errors according to its Mode.
 type WaitGroup struct {
 	funcs []WaitGroupFunc
 
-	wg *sync.WaitGroup
+	Mode Mode
 }
 
 func (w *WaitGroup) Add(f WaitGroupFunc) {
 	w.funcs = append(w.funcs, f)
 }
 
+// Wait runs every function provided with Add in parallel and waits for all of them to return.
+// In the FailFast mode, the context provided to the functions is cancelled when one of them returns an error, and that error is returned.
+// In the RunAll mode, every error is returned as Errors, in the order that their functions were added.
+// If the provided context is cancelled, then the context's error is returned.
 func (w *WaitGroup) Wait(ctx context.Context) error {
 	var (
-		doneChan = make(chan bool)
-		errChan  = make(chan error)
+		wg      = &sync.WaitGroup{}
+		mutex   = &sync.Mutex{}
+		results = make([]error, len(w.funcs))
+		// firstErr is the error that caused the shared context to be cancelled in the FailFast mode.
+		firstErr error
 	)
 
-	w.wg.Add(len(w.funcs))
+	fctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	for _, v := range w.funcs {
-		go func(f WaitGroupFunc) {
-			if err := f(ctx); err != nil {
-				errChan <- err
+	wg.Add(len(w.funcs))
+	for i, v := range w.funcs {
+		go func(i int, f WaitGroupFunc) {
+			defer wg.Done()
+
+			err := f(fctx)
+			if err == nil {
+				return
+			}
+
+			mutex.Lock()
+			results[i] = err
+			if firstErr == nil {
+				firstErr = err
 			}
+			mutex.Unlock()
 
-			w.wg.Done()
-		}(v)
+			if w.Mode == FailFast {
+				cancel()
+			}
+		}(i, v)
 	}
 
-	go func() {
-		w.wg.Wait()
-		doneChan <- true
-	}()
+	wg.Wait()
 
-	select {
-	case err := <-ctx.Done():
-		return fmt.Errorf("%w: %s", context.Canceled, err)
-	case <-doneChan:
-		return nil
-	case err := <-errChan:
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
+	if w.Mode == FailFast {
+		return firstErr
+	}
+
+	var errs Errors
+	for _, err := range results {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
 }
 
-func NewWaitGroup() *WaitGroup {
+func NewWaitGroup(mode Mode) *WaitGroup {
 	return &WaitGroup{
 		funcs: []WaitGroupFunc{},
-		wg:    &sync.WaitGroup{},
+		Mode:  mode,
 	}
 }
@@ -0,0 +1,119 @@
+package syncutil_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/scribe/syncutil"
+	"github.com/grafana/scribe/testutil"
+)
+
+func TestWaitGroupWait(t *testing.T) {
+	var (
+		errA = errors.New("error a")
+		errB = errors.New("error b")
+	)
+
+	t.Run("It should return nil if every function succeeds", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		var ran int64
+		wg := syncutil.NewWaitGroup(syncutil.FailFast)
+		for i := 0; i < 3; i++ {
+			wg.Add(func(ctx context.Context) error {
+				atomic.AddInt64(&ran, 1)
+				return nil
+			})
+		}
+
+		testutil.EnsureError(t, wg.Wait(context.Background()), nil)
+		if ran != 3 {
+			t.Fatalf("expected 3 functions to run but %d ran", ran)
+		}
+	}))
+
+	t.Run("It should cancel the other functions and return the first error in the fail-fast mode", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		wg := syncutil.NewWaitGroup(syncutil.FailFast)
+		wg.Add(func(ctx context.Context) error {
+			return errA
+		})
+
+		// This function only returns once its context is cancelled by the error above.
+		wg.Add(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		testutil.EnsureError(t, wg.Wait(context.Background()), errA)
+	}))
+
+	t.Run("It should run every function and return every error in the run-all mode", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		var ran int64
+		wg := syncutil.NewWaitGroup(syncutil.RunAll)
+		wg.Add(func(ctx context.Context) error {
+			return errA
+		})
+		wg.Add(func(ctx context.Context) error {
+			time.Sleep(time.Millisecond * 10)
+			if ctx.Err() != nil {
+				t.Error("the context should not be cancelled in the run-all mode")
+			}
+			atomic.AddInt64(&ran, 1)
+			return nil
+		})
+		wg.Add(func(ctx context.Context) error {
+			return errB
+		})
+
+		err := wg.Wait(context.Background())
+		testutil.EnsureError(t, err, errA)
+		testutil.EnsureError(t, err, errB)
+
+		var errs syncutil.Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("expected error to be syncutil.Errors but got %T", err)
+		}
+		if len(errs) != 2 {
+			t.Fatalf("expected 2 errors but got %d", len(errs))
+		}
+		if ran != 1 {
+			t.Fatal("the function that succeeds should run in the run-all mode")
+		}
+	}))
+
+	t.Run("It should wait for every function to return when the context is cancelled", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+			returned    int64
+		)
+
+		wg := syncutil.NewWaitGroup(syncutil.FailFast)
+		for i := 0; i < 2; i++ {
+			wg.Add(func(ctx context.Context) error {
+				<-ctx.Done()
+				atomic.AddInt64(&returned, 1)
+				return nil
+			})
+		}
+
+		cancel()
+		testutil.EnsureError(t, wg.Wait(ctx), context.Canceled)
+		if returned != 2 {
+			t.Fatalf("expected 2 functions to return before Wait but %d did", returned)
+		}
+	}))
+
+	t.Run("It should return the context's error if the context times out", testutil.WithTimeout(time.Second, func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		wg := syncutil.NewWaitGroup(syncutil.FailFast)
+		wg.Add(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		testutil.EnsureError(t, wg.Wait(ctx), context.DeadlineExceeded)
+	}))
+}
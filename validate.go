@@ -0,0 +1,105 @@
+package scribe
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/grafana/scribe/errors"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
+)
+
+var ErrorInvalidPipeline = errors.New("pipeline is not valid")
+
+// GeneratorModes are the clients that generate the configuration of a CI service.
+// Pipelines are validated with them as well as with the selected client, as a pipeline that is ran locally is typically also ran in CI.
+var GeneratorModes = []string{ClientDrone, ClientGitHub, ClientGitLab}
+
+// validation holds what executeWithValidate needs from the Scribe or ScribeMulti that defined the pipeline.
+type validation struct {
+	// client is the selected client.
+	client pipeline.Client
+
+	// problems are the errors that were found while the pipeline was defined. In validate mode, they are reported instead of stopping the program.
+	problems []error
+}
+
+// clientValidators returns the 'Validate' function of the selected client and of the generator clients (see 'GeneratorModes').
+// Generator clients that can not be created with the provided options are skipped.
+func clientValidators(ctx context.Context, opts clients.CommonOpts, selected pipeline.Client) []pipeline.StepValidator {
+	validators := []pipeline.StepValidator{}
+	add := func(name string, client pipeline.Client) {
+		validators = append(validators, func(s pipeline.Step) error {
+			err := client.Validate(s)
+			if err == nil {
+				return nil
+			}
+
+			// Errors that do not stop the pipeline from running are only logged.
+			if errors.Is(err, errors.ErrorSkipValidation) {
+				opts.Log.WithField("client", name).Warnln(formatError(s, err).Error())
+				return nil
+			}
+
+			return fmt.Errorf("client '%s': %w", name, err)
+		})
+	}
+
+	if selected != nil {
+		add(opts.Args.Client, selected)
+	}
+
+	for _, name := range GeneratorModes {
+		if name == opts.Args.Client {
+			continue
+		}
+
+		initializer, ok := ClientInitializers[name]
+		if !ok {
+			continue
+		}
+
+		client, err := initializer(ctx, opts)
+		if err != nil {
+			opts.Log.WithError(err).Debugf("Skipping validation for client '%s'", name)
+			continue
+		}
+
+		add(name, client)
+	}
+
+	return validators
+}
+
+// executeWithValidate writes the problems in the collection to 'w' instead of running the pipeline if the '--validate' argument was provided.
+// The problems that were found while the pipeline was defined, like steps with duplicate names, are written first.
+// If there are any problems, then an error wrapping ErrorInvalidPipeline is returned so that the program exits with an error.
+func executeWithValidate(
+	opts clients.CommonOpts,
+	w io.Writer,
+	v validation,
+	ef executeFunc,
+) executeFunc {
+	return func(ctx context.Context, collection *pipeline.Collection) error {
+		if !opts.Args.Validate {
+			return ef(ctx, collection)
+		}
+
+		for _, err := range v.problems {
+			fmt.Fprintln(w, err.Error())
+		}
+
+		problems := pipeline.Validate(collection, clientValidators(ctx, opts, v.client)...)
+		for _, v := range problems {
+			fmt.Fprintln(w, v.Error())
+		}
+
+		if n := len(v.problems) + len(problems); n != 0 {
+			return fmt.Errorf("%w: found %d problem(s)", ErrorInvalidPipeline, n)
+		}
+
+		fmt.Fprintln(w, "No problems found")
+		return nil
+	}
+}
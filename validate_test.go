@@ -0,0 +1,91 @@
+package scribe_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grafana/scribe"
+	"github.com/grafana/scribe/args"
+	"github.com/grafana/scribe/pipeline"
+	"github.com/grafana/scribe/pipeline/clients"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+)
+
+// rejecter is a client that rejects every step with the name 'reject'.
+type rejecter struct {
+	*ensurer
+}
+
+func (r *rejecter) Validate(step pipeline.Step) error {
+	if step.Name == "reject" {
+		return errors.New("step is rejected")
+	}
+
+	return nil
+}
+
+// newValidateScribe returns a Scribe that validates the pipeline. The returned function returns true if the program would have exited.
+func newValidateScribe(out *bytes.Buffer, client pipeline.Client) (*scribe.Scribe, func() bool) {
+	var (
+		log    = logrus.New()
+		exited = false
+	)
+
+	log.ExitFunc = func(int) {
+		exited = true
+	}
+
+	opts := clients.CommonOpts{
+		Name:   "test",
+		Log:    log,
+		Tracer: &opentracing.NoopTracer{},
+		Output: out,
+		Args: &args.PipelineArgs{
+			Client:   "test",
+			Validate: true,
+		},
+	}
+
+	return scribe.NewWithClient(opts, client), func() bool { return exited }
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("It should report steps with duplicate names instead of stopping the program", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		sw, exited := newValidateScribe(out, newEnsurer())
+
+		sw.Add(pipeline.NoOpStep.WithName("build").WithImage("alpine:3.16"))
+		sw.Add(pipeline.NoOpStep.WithName("build").WithImage("alpine:3.16"))
+		if exited() {
+			t.Fatal("Expected the duplicate step to be reported instead of stopping the program")
+		}
+
+		sw.Done()
+
+		if !strings.Contains(out.String(), "build") {
+			t.Fatalf("Expected the report to contain the duplicate step, but received:\n%s", out.String())
+		}
+		if !exited() {
+			t.Fatal("Expected the program to exit with an error once the problems were reported")
+		}
+	})
+
+	t.Run("It should report the steps that the selected client rejects", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		sw, exited := newValidateScribe(out, &rejecter{newEnsurer()})
+
+		sw.Add(pipeline.NoOpStep.WithName("reject").WithImage("alpine:3.16"))
+		if exited() {
+			t.Fatal("Expected the rejected step to be reported instead of stopping the program")
+		}
+
+		sw.Done()
+
+		if !strings.Contains(out.String(), "client 'test': step is rejected") {
+			t.Fatalf("Expected the report to contain the error from the selected client, but received:\n%s", out.String())
+		}
+	})
+}